@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+const maxBatchBodyBytes = 32 << 20 // 32 MiB
+
+// decodeBatch parses a batch body given either as a JSON array of samples or
+// as newline-delimited JSON objects.
+func decodeBatch(body string) ([]bayes.Sample, error) {
+	if strings.HasPrefix(strings.TrimSpace(body), "[") {
+		var samples []bayes.Sample
+		if err := decodeStrict(body, &samples); err != nil {
+			return nil, fmt.Errorf("invalid batch: %w", err)
+		}
+		return samples, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.DisallowUnknownFields()
+	samples := make([]bayes.Sample, 0)
+	for line := 1; ; line++ {
+		var sample bayes.Sample
+		err := dec.Decode(&sample)
+		if errors.Is(err, io.EOF) {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch item %d: %w", line, err)
+		}
+		samples = append(samples, sample)
+	}
+}
+
+// readBatch reads and decodes a batch request body, writing an error response on failure.
+func readBatch(w http.ResponseWriter, req *http.Request) ([]bayes.Sample, bool) {
+	extendDeadlines(w)
+	body, ok := readBodyLimit(w, req, maxBatchBodyBytes)
+	if !ok {
+		return nil, false
+	}
+
+	samples, err := decodeBatch(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return samples, true
+}
+
+// BatchTrainHandler trains every sample in a JSON array or NDJSON request body.
+func (c *ClassifierAPI) BatchTrainHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	samples, ok := readBatch(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewBatchTrainingResponse(c, c.classifier.TrainBatch(samples)))
+}
+
+// BatchUntrainHandler untrains every sample in a JSON array or NDJSON request body.
+func (c *ClassifierAPI) BatchUntrainHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	samples, ok := readBatch(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewBatchTrainingResponse(c, c.classifier.UntrainBatch(samples)))
+}
+
+// BatchClassifyHandler classifies the text of every item in a JSON array or
+// NDJSON request body. Item categories are ignored.
+func (c *ClassifierAPI) BatchClassifyHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	samples, ok := readBatch(w, req)
+	if !ok {
+		return
+	}
+
+	texts := make([]string, len(samples))
+	for i, sample := range samples {
+		texts[i] = sample.Text
+	}
+	writeJSON(w, http.StatusOK, &BatchClassifyResponse{Results: c.classifier.ClassifyBatch(texts)})
+}
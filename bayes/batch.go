@@ -0,0 +1,82 @@
+package bayes
+
+// Sample is one labeled text sample used by batch training.
+type Sample struct {
+	Category string `json:"category"`
+	Text     string `json:"text"`
+}
+
+// TrainBatch trains every sample in one critical section. Samples are
+// tokenized before the write lock is taken, so readers are blocked only while
+// counts are applied. The returned slice holds one error per sample (nil on
+// success); samples with invalid category names are skipped.
+func (c *Classifier) TrainBatch(samples []Sample) []error {
+	return c.applyBatch(samples, c.trainUnlocked)
+}
+
+// UntrainBatch untrains every sample in one critical section, tokenizing
+// outside the write lock like TrainBatch. The returned slice holds one error
+// per sample (nil on success).
+func (c *Classifier) UntrainBatch(samples []Sample) []error {
+	return c.applyBatch(samples, c.untrainUnlocked)
+}
+
+// ClassifyBatch classifies every text under a single read lock. Results are
+// in input order and match what Classify returns for each text.
+func (c *Classifier) ClassifyBatch(texts []string) []Classification {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	results := make([]Classification, len(texts))
+	for i, text := range texts {
+		tokenize, lang := c.tokenizerForUnlocked(text)
+		results[i] = bestClassification(c.scoreTokensUnlocked(tokenize(text)))
+		results[i].Language = lang
+	}
+	return results
+}
+
+// applyBatch validates and tokenizes samples, then applies each valid sample
+// with apply while holding the write lock once for the whole batch. Samples
+// are tokenized again under the write lock if Load replaced the tokenizer in
+// the meantime, so their counts always match the model they are applied to.
+func (c *Classifier) applyBatch(samples []Sample, apply func(category string, occurrences map[string]int, weight float64)) []error {
+	c.mu.RLock()
+	tokenize, epoch := c.getTokenizer(), c.tokenizerEpoch
+	c.mu.RUnlock()
+
+	errs := make([]error, len(samples))
+	occurrences := make([]map[string]int, len(samples))
+	valid := 0
+	for i, sample := range samples {
+		if !categoryNamePattern.MatchString(sample.Category) {
+			errs[i] = ErrInvalidCategoryName
+			continue
+		}
+		occurrences[i] = c.countTokenOccurrences(tokenize(sample.Text))
+		valid++
+	}
+	if valid == 0 {
+		return errs
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokenizerEpoch != epoch {
+		tokenize = c.getTokenizer()
+		for i, sample := range samples {
+			if errs[i] == nil {
+				occurrences[i] = c.countTokenOccurrences(tokenize(sample.Text))
+			}
+		}
+	}
+	for i, sample := range samples {
+		if errs[i] == nil {
+			apply(sample.Category, occurrences[i], 1)
+		}
+	}
+	c.categories.EnsureCategoryProbabilities()
+	c.revision++
+	return errs
+}
@@ -0,0 +1,138 @@
+package bayes
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestTrainBatchMatchesSequentialTraining verifies batch training builds the same model as Train.
+func TestTrainBatchMatchesSequentialTraining(t *testing.T) {
+	samples := []Sample{
+		{Category: "spam", Text: "buy cheap pills now"},
+		{Category: "ham", Text: "team meeting schedule"},
+		{Category: "spam", Text: "cheap offer"},
+	}
+
+	sequential := NewClassifier()
+	for _, sample := range samples {
+		if err := sequential.Train(sample.Category, sample.Text); err != nil {
+			t.Fatalf("unexpected train error: %v", err)
+		}
+	}
+
+	batched := NewClassifier()
+	before := batched.Revision()
+	for i, err := range batched.TrainBatch(samples) {
+		if err != nil {
+			t.Fatalf("unexpected error for sample %d: %v", i, err)
+		}
+	}
+	if batched.Revision() != before+1 {
+		t.Fatalf("expected one revision per batch, got %d -> %d", before, batched.Revision())
+	}
+
+	var want, got bytes.Buffer
+	if err := sequential.Save(&want); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	if err := batched.Save(&got); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	if want.String() != got.String() {
+		t.Fatalf("batch model differs from sequential model:\n%s\n%s", want.String(), got.String())
+	}
+	if batched.Summaries()["spam"] != sequential.Summaries()["spam"] {
+		t.Fatalf("expected matching summaries, got %+v and %+v", batched.Summaries()["spam"], sequential.Summaries()["spam"])
+	}
+}
+
+// TestTrainBatchReportsPerItemErrors verifies invalid samples are skipped and reported.
+func TestTrainBatchReportsPerItemErrors(t *testing.T) {
+	classifier := NewClassifier()
+	errs := classifier.TrainBatch([]Sample{
+		{Category: "spam", Text: "buy now"},
+		{Category: "bad name", Text: "ignored"},
+		{Category: "", Text: "ignored"},
+	})
+	if len(errs) != 3 || errs[0] != nil || !errors.Is(errs[1], ErrInvalidCategoryName) || !errors.Is(errs[2], ErrInvalidCategoryName) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if names := classifier.categories.Names(); len(names) != 1 || names[0] != "spam" {
+		t.Fatalf("expected only spam to be trained, got %v", names)
+	}
+
+	before := classifier.Revision()
+	classifier.TrainBatch([]Sample{{Category: "bad name"}})
+	if classifier.Revision() != before {
+		t.Fatal("expected a batch with no valid samples to leave the revision unchanged")
+	}
+}
+
+// TestTrainBatchRetokenizesAfterLoad verifies a batch whose tokenizer is
+// replaced by Load while it tokenizes is applied with the new tokenizer.
+func TestTrainBatchRetokenizesAfterLoad(t *testing.T) {
+	var classifier *Classifier
+	loaded := false
+	classifier = NewClassifierWithTokenizer(func(string) []string {
+		if !loaded {
+			loaded = true
+			model := `{"version":3,"categories":{},"tokenizer":{"language":"english","removeStopWords":false}}`
+			if err := classifier.Load(strings.NewReader(model)); err != nil {
+				t.Fatalf("unexpected load error: %v", err)
+			}
+		}
+		return []string{"stale"}
+	})
+
+	if errs := classifier.TrainBatch([]Sample{{Category: "spam", Text: "buying"}}); errs[0] != nil {
+		t.Fatalf("unexpected error: %v", errs[0])
+	}
+	counts := classifier.LookupToken("buying")
+	if len(counts) != 1 || counts[0].Token != "buy" || counts[0].Categories["spam"] != 1 {
+		t.Fatalf("expected counts from the loaded tokenizer, got %+v", counts)
+	}
+	if summary := classifier.Summaries()["spam"]; summary.TokenTally != 1 {
+		t.Fatalf("expected only the retokenized sample, got %+v", summary)
+	}
+}
+
+// TestUntrainBatch verifies batch untraining removes counts and empty categories.
+func TestUntrainBatch(t *testing.T) {
+	classifier := NewClassifier()
+	classifier.TrainBatch([]Sample{
+		{Category: "spam", Text: "buy now"},
+		{Category: "ham", Text: "team meeting"},
+	})
+
+	errs := classifier.UntrainBatch([]Sample{
+		{Category: "spam", Text: "buy now"},
+		{Category: "ham!", Text: "team"},
+	})
+	if errs[0] != nil || !errors.Is(errs[1], ErrInvalidCategoryName) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, ok := classifier.categories.LookupCategory("spam"); ok {
+		t.Fatal("expected spam to be removed after untraining all of its tokens")
+	}
+	if summary := classifier.Summaries()["ham"]; summary.TokenTally != 2 || summary.ProbInCat != 1 {
+		t.Fatalf("unexpected ham summary: %+v", summary)
+	}
+}
+
+// TestClassifyBatchMatchesClassify verifies each batch result equals Classify.
+func TestClassifyBatchMatchesClassify(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{})
+	texts := []string{"buy pills", "meeting notes", "", "unseen"}
+
+	results := classifier.ClassifyBatch(texts)
+	if len(results) != len(texts) {
+		t.Fatalf("expected %d results, got %d", len(texts), len(results))
+	}
+	for i, text := range texts {
+		if results[i] != classifier.Classify(text) {
+			t.Fatalf("result %d differs: %+v vs %+v", i, results[i], classifier.Classify(text))
+		}
+	}
+}
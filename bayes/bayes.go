@@ -1,28 +1,39 @@
 package bayes
 
 import (
+	"encoding/json"
 	"errors"
+	"math"
 	"regexp"
 	"sort"
-	"strings"
 	"sync"
+	"time"
 
-	"github.com/hickeroar/gobayes/v3/bayes/category"
+	"github.com/hickeroar/gobayes/v4/bayes/category"
 )
 
 // Classification is the result of classifying a text sample.
 type Classification struct {
-	Category string  `json:"category"`
-	Score    float64 `json:"score"`
+	Category    string  `json:"category"`
+	Score       float64 `json:"score"`
+	Probability float64 `json:"probability"`        // normalized share of the winning category; see Probabilities
+	Language    string  `json:"language,omitempty"` // detected sample language when language detection is on
 }
 
 // Classifier trains text categories and classifies new text samples.
 type Classifier struct {
-	categories             category.Categories
-	Tokenizer              func(string) []string
-	tokenizerLang          string // persisted when set via NewClassifierWithOptions
-	tokenizerRemoveStopWords bool  // persisted when set via NewClassifierWithOptions
-	mu                     sync.RWMutex
+	categories         category.Categories
+	Tokenizer          func(string) []string
+	tokenizerOptions   TokenizerOptions    // persisted when set via NewClassifierWithOptions or the registry
+	languageTokenizers *languageTokenizers // per-language tokenizers of a detecting Tokenizer
+	tokenizerConfig    json.RawMessage     // config of a registered tokenizer
+	scoring            ScoringOptions
+	revision           uint64    // incremented on every model mutation
+	decayedAt          time.Time // time of the last DecayUntil, persisted by Save
+	tokenizerEpoch     uint64    // incremented whenever Load replaces the tokenizer
+	mu                 sync.RWMutex
+	cacheMu            sync.Mutex     // guards scoringCache under the read lock
+	scoringCache       *categoryTerms // lazily built per-category scoring terms
 }
 
 var categoryNamePattern = regexp.MustCompile(`^[-_A-Za-z0-9]+$`)
@@ -30,6 +41,15 @@ var categoryNamePattern = regexp.MustCompile(`^[-_A-Za-z0-9]+$`)
 // ErrInvalidCategoryName indicates category input did not match the allowed pattern.
 var ErrInvalidCategoryName = errors.New("invalid category name")
 
+// ValidCategoryName reports whether name can be used as a category name.
+func ValidCategoryName(name string) bool {
+	return categoryNamePattern.MatchString(name)
+}
+
+// ErrInvalidWeight indicates a training weight that is not positive and
+// finite.
+var ErrInvalidWeight = errors.New("invalid weight")
+
 // defaultTokenizer is the tokenizer used when Classifier.Tokenizer is nil.
 var defaultTokenizer = NewDefaultTokenizer("english", false)
 
@@ -43,7 +63,8 @@ func NewClassifier() *Classifier {
 }
 
 // NewClassifierWithTokenizer returns a Classifier that uses the given tokenizer.
-// Tokenizer config is not persisted when using this constructor.
+// Tokenizer config is not persisted when using this constructor; register the
+// tokenizer and use NewClassifierWithRegisteredTokenizer to persist it.
 func NewClassifierWithTokenizer(tokenizer func(string) []string) *Classifier {
 	return &Classifier{
 		categories: *category.NewCategories(),
@@ -52,18 +73,53 @@ func NewClassifierWithTokenizer(tokenizer func(string) []string) *Classifier {
 }
 
 // NewClassifierWithOptions returns a Classifier with the given language and
-// stop-word setting. The tokenizer config is persisted on Save and restored on Load.
-func NewClassifierWithOptions(lang string, removeStopWords bool) *Classifier {
-	lang = strings.ToLower(strings.TrimSpace(lang))
-	if lang == "" {
-		lang = "english"
+// stop-word setting, customized by opts (e.g. WithNGrams). The tokenizer config
+// is persisted on Save and restored on Load.
+func NewClassifierWithOptions(lang string, removeStopWords bool, opts ...TokenizerOption) *Classifier {
+	tokenizerOpts := TokenizerOptions{Language: lang, RemoveStopWords: removeStopWords}
+	for _, opt := range opts {
+		opt(&tokenizerOpts)
 	}
+	tokenizerOpts = tokenizerOpts.normalize()
+	tokenizer, langs := newClassifierTokenizer(tokenizerOpts)
 	return &Classifier{
-		categories:               *category.NewCategories(),
-		Tokenizer:                NewDefaultTokenizer(lang, removeStopWords),
-		tokenizerLang:            lang,
-		tokenizerRemoveStopWords: removeStopWords,
+		categories:         *category.NewCategories(),
+		Tokenizer:          tokenizer,
+		tokenizerOptions:   tokenizerOpts,
+		languageTokenizers: langs,
+	}
+}
+
+// EmptyCopy returns an untrained Classifier with the same tokenizer and
+// scoring options as c, e.g. for training on a subset of the data.
+func (c *Classifier) EmptyCopy() *Classifier {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	clone := &Classifier{
+		categories:         *category.NewCategories(),
+		Tokenizer:          c.Tokenizer,
+		tokenizerOptions:   c.tokenizerOptions,
+		languageTokenizers: c.languageTokenizers,
+		tokenizerConfig:    cloneConfig(c.tokenizerConfig),
+		scoring:            c.scoring,
+	}
+	clone.applyPriorSource()
+	return clone
+}
+
+// TokenizerOptions returns the tokenizer configuration. A classifier using the
+// built-in default tokenizer reports english single words without stop-word
+// removal; one using a registered tokenizer reports only its name in
+// Tokenizer; one using any other custom tokenizer reports the zero value.
+func (c *Classifier) TokenizerOptions() TokenizerOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.Tokenizer == nil {
+		return TokenizerOptions{Language: "english", NGrams: 1, Tokenizer: TokenizerWords}
 	}
+	return c.tokenizerOptions
 }
 
 // tokenizeText returns tokens using the default tokenizer. When snowball.Stem
@@ -97,53 +153,61 @@ func (c *Classifier) Flush() {
 	defer c.mu.Unlock()
 
 	c.categories = *category.NewCategories()
-	c.categories.EnsureCategoryProbabilities()
+	c.applyPriorSource()
+	c.revision++
+}
+
+// Revision returns a counter that changes whenever the trained model changes.
+// Callers can compare revisions to detect unsaved modifications.
+func (c *Classifier) Revision() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.revision
 }
 
 // Train updates a category with token counts from a text sample.
 func (c *Classifier) Train(category string, text string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if !categoryNamePattern.MatchString(category) {
-		return ErrInvalidCategoryName
-	}
-
-	cat := c.categories.GetCategory(category)
-
-	tokens := c.getTokenizer()(text)
-	occurrences := c.countTokenOccurrences(tokens)
-
-	for token, count := range occurrences {
-		_ = cat.TrainToken(token, count)
-	}
-
-	c.cleanUpCategory(cat)
-	c.categories.EnsureCategoryProbabilities()
-	return nil
+	return c.TrainInput(category, InputText, text)
 }
 
 // Untrain removes token counts from a category using a text sample.
 func (c *Classifier) Untrain(category string, text string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if !categoryNamePattern.MatchString(category) {
-		return ErrInvalidCategoryName
-	}
+	return c.UntrainInput(category, InputText, text)
+}
 
-	cat := c.categories.GetCategory(category)
+// TrainWeighted is Train with the sample counted weight times, so that, for
+// example, hand-labeled samples can outweigh auto-labeled ones. weight must
+// be positive and finite; Train uses a weight of 1.
+func (c *Classifier) TrainWeighted(category string, text string, weight float64) error {
+	return c.TrainInputWeighted(category, InputText, text, weight)
+}
 
-	tokens := c.getTokenizer()(text)
-	occurrences := c.countTokenOccurrences(tokens)
+// UntrainWeighted reverses TrainWeighted with the same weight.
+func (c *Classifier) UntrainWeighted(category string, text string, weight float64) error {
+	return c.UntrainInputWeighted(category, InputText, text, weight)
+}
 
-	for token, count := range occurrences {
-		_ = cat.UntrainToken(token, count)
-	}
+// trainUnlocked adds one document's token occurrences, counted weight times,
+// to a category while the write lock is held. Callers refresh priors and the
+// revision.
+func (c *Classifier) trainUnlocked(category string, occurrences map[string]int, weight float64) {
+	cat := c.categories.GetCategory(category)
+	_ = c.categories.TrainWeightedDocument(cat, occurrences, weight)
+	c.cleanUpCategory(cat)
+}
 
+// untrainUnlocked removes one document's token occurrences, counted weight
+// times, from a category while the write lock is held. Callers refresh
+// priors and the revision.
+func (c *Classifier) untrainUnlocked(category string, occurrences map[string]int, weight float64) {
+	cat := c.categories.GetCategory(category)
+	_ = c.categories.UntrainWeightedDocument(cat, occurrences, weight)
 	c.cleanUpCategory(cat)
-	c.categories.EnsureCategoryProbabilities()
-	return nil
+}
+
+// validWeight reports whether weight can be used for weighted training.
+func validWeight(weight float64) bool {
+	return weight > 0 && !math.IsInf(weight, 0)
 }
 
 // cleanUpCategory removes an empty category.
@@ -158,32 +222,138 @@ func (c *Classifier) Classify(text string) Classification {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	scores := c.scoreUnlocked(text)
-	result := Classification{}
+	tokenize, lang := c.tokenizerForUnlocked(text)
+	result := bestClassification(c.scoreTokensUnlocked(tokenize(text)))
+	result.Language = lang
+	return result
+}
+
+// ClassifyOptions configures ClassifyWithOptions.
+type ClassifyOptions struct {
+	// N is the number of top-ranked categories to return in Ranking; zero
+	// returns none.
+	N int
+	// MinScore makes the classifier abstain when the best score is below it.
+	// Nil disables the check. It is a pointer because zero is a meaningful
+	// threshold for log-space scoring modes, whose scores are negative.
+	MinScore *float64
+	// MinMargin makes the classifier abstain when the best score leads the
+	// runner-up by less than this. Zero disables the check.
+	MinMargin float64
+	// Unknown is the category reported when the classifier abstains.
+	Unknown string
+}
 
-	// If we had no scores returned we just return the Classification object without a category
-	if len(scores) == 0 {
+// RankedClassification is the result of ClassifyWithOptions.
+type RankedClassification struct {
+	Classification                  // best match, or Unknown with the best match's score when abstaining
+	Abstained      bool             `json:"abstained"`
+	Ranking        []Classification `json:"ranking,omitempty"` // top categories, best first
+}
+
+// ClassifyTopN returns up to n categories ordered from best to worst match,
+// breaking score ties by name. Each entry's Probability is its share of the
+// normalized distribution returned by Probabilities.
+func (c *Classifier) ClassifyTopN(text string, n int) []Classification {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return topClassifications(c.rankUnlocked(text), n)
+}
+
+// ClassifyWithOptions classifies text like Classify, and abstains by reporting
+// opts.Unknown when nothing scores or the best match misses the configured
+// score or margin thresholds.
+func (c *Classifier) ClassifyWithOptions(text string, opts ClassifyOptions) RankedClassification {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tokenize, lang := c.tokenizerForUnlocked(text)
+	return c.classifyTokensUnlocked(tokenize(text), lang, opts)
+}
+
+// classifyTokensUnlocked implements ClassifyWithOptions for tokens of a
+// sample in the detected language lang while the classifier lock is held.
+func (c *Classifier) classifyTokensUnlocked(tokens []string, lang string, opts ClassifyOptions) RankedClassification {
+	ranking := c.rankTokensUnlocked(tokens, lang)
+	result := RankedClassification{Ranking: topClassifications(ranking, opts.N)}
+	if len(ranking) == 0 {
+		result.Category = opts.Unknown
+		result.Language = lang
+		result.Abstained = true
 		return result
 	}
 
-	names := make([]string, 0, len(scores))
-	for name := range scores {
-		names = append(names, name)
+	result.Classification = ranking[0]
+	if opts.MinScore != nil && ranking[0].Score < *opts.MinScore {
+		result.Abstained = true
 	}
-	sort.Strings(names)
+	if opts.MinMargin != 0 && len(ranking) > 1 && ranking[0].Score-ranking[1].Score < opts.MinMargin {
+		result.Abstained = true
+	}
+	if result.Abstained {
+		result.Category = opts.Unknown
+	}
+	return result
+}
+
+// bestClassification picks the highest-scoring category, breaking ties by name.
+func bestClassification(scores map[string]float64) Classification {
+	ranking := rankClassifications(scores)
 
-	for _, name := range names {
-		score := scores[name]
-		if score > result.Score {
-			result.Category = name
-			result.Score = score
+	// If we had no scores returned we just return the Classification object without a category
+	if len(ranking) == 0 {
+		return Classification{}
+	}
+	return ranking[0]
+}
+
+// rankUnlocked scores and ranks text while the classifier lock is held,
+// recording the detected language on every entry.
+func (c *Classifier) rankUnlocked(text string) []Classification {
+	tokenize, lang := c.tokenizerForUnlocked(text)
+	return c.rankTokensUnlocked(tokenize(text), lang)
+}
+
+// rankTokensUnlocked is rankUnlocked for tokens of a sample in the detected
+// language lang.
+func (c *Classifier) rankTokensUnlocked(tokens []string, lang string) []Classification {
+	ranking := rankClassifications(c.scoreTokensUnlocked(tokens))
+	if lang != "" {
+		for i := range ranking {
+			ranking[i].Language = lang
 		}
 	}
+	return ranking
+}
 
-	return result
+// rankClassifications orders scored categories from best to worst match,
+// breaking ties by name.
+func rankClassifications(scores map[string]float64) []Classification {
+	probabilities := normalizeScores(scores)
+	ranking := make([]Classification, 0, len(scores))
+	for name, score := range scores {
+		ranking = append(ranking, Classification{Category: name, Score: score, Probability: probabilities[name]})
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].Score != ranking[j].Score {
+			return ranking[i].Score > ranking[j].Score
+		}
+		return ranking[i].Category < ranking[j].Category
+	})
+	return ranking
 }
 
-// Score computes Bayesian scores for each category given a text sample.
+// topClassifications returns at most n leading entries of ranking.
+func topClassifications(ranking []Classification, n int) []Classification {
+	if n <= 0 {
+		return nil
+	}
+	return ranking[:min(n, len(ranking))]
+}
+
+// Score computes scores for each category given a text sample using the
+// configured ScoringMode. Higher scores indicate a better match.
 func (c *Classifier) Score(text string) map[string]float64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -191,6 +361,17 @@ func (c *Classifier) Score(text string) map[string]float64 {
 	return c.scoreUnlocked(text)
 }
 
+// Probabilities returns per-category values that sum to 1, computed with a
+// numerically stable softmax over the category scores. For multinomial scoring
+// these are posterior probabilities; for classic scoring they are a normalized
+// ranking of the relative scores. Categories absent from Score are omitted.
+func (c *Classifier) Probabilities(text string) map[string]float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return normalizeScores(c.scoreUnlocked(text))
+}
+
 // Summaries returns a response-oriented snapshot of all category data.
 func (c *Classifier) Summaries() map[string]category.CategorySummary {
 	c.mu.RLock()
@@ -200,9 +381,30 @@ func (c *Classifier) Summaries() map[string]category.CategorySummary {
 
 // scoreUnlocked computes category scores while the classifier lock is already held.
 func (c *Classifier) scoreUnlocked(text string) map[string]float64 {
-	tokens := c.getTokenizer()(text)
+	return c.scoreTokensUnlocked(c.getTokenizer()(text))
+}
+
+// scoreTokensUnlocked computes category scores for tokens while the
+// classifier lock is already held.
+func (c *Classifier) scoreTokensUnlocked(tokens []string) map[string]float64 {
 	occurrences := c.countTokenOccurrences(tokens)
 
+	opts := c.scoringUnlocked()
+	switch opts.Mode {
+	case ScoringMultinomial:
+		return c.multinomialScores(occurrences, opts)
+	case ScoringComplement:
+		return c.complementScores(occurrences, opts)
+	case ScoringBernoulli:
+		return c.bernoulliScores(occurrences, opts)
+	default:
+		return c.classicScores(occurrences)
+	}
+}
+
+// classicScores sums per-token Bayesian probabilities for each category.
+// Categories with no matching tokens are omitted.
+func (c *Classifier) classicScores(occurrences map[string]int) map[string]float64 {
 	scores := make(map[string]float64)
 	categoryNames := c.categories.Names()
 	categoriesByName := make(map[string]*category.Category, len(categoryNames))
@@ -219,7 +421,7 @@ func (c *Classifier) scoreUnlocked(text string) map[string]float64 {
 
 		// Getting the tallies of this token from all categories
 		for name, cat := range categoriesByName {
-			tokenScores[name] = float64(cat.GetTokenCount(word))
+			tokenScores[name] = cat.GetTokenCount(word)
 			tokenTally += tokenScores[name]
 		}
 
@@ -26,7 +26,7 @@ func FuzzClassifierInvariants(f *testing.F) {
 				continue
 			}
 			if cat.GetTally() < 0 {
-				t.Fatalf("category %q has negative tally: %d", name, cat.GetTally())
+				t.Fatalf("category %q has negative tally: %v", name, cat.GetTally())
 			}
 			if cat.GetProbInCat() < 0 || cat.GetProbInCat() > 1 {
 				t.Fatalf("category %q has invalid probIn: %f", name, cat.GetProbInCat())
@@ -2,9 +2,14 @@ package bayes
 
 import (
 	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
-	"github.com/hickeroar/gobayes/v3/bayes/category"
+	"github.com/hickeroar/gobayes/v4/bayes/category"
+	"github.com/hickeroar/gobayes/v4/bayes/stopwords"
 )
 
 // TestTrainUntrainLifecycle verifies train untrain lifecycle.
@@ -19,10 +24,10 @@ func TestTrainUntrainLifecycle(t *testing.T) {
 		t.Fatal("expected spam category to exist after training")
 	}
 	if spam.GetTally() != 4 {
-		t.Fatalf("unexpected spam tally: got %d, want 4", spam.GetTally())
+		t.Fatalf("unexpected spam tally: got %v, want 4", spam.GetTally())
 	}
 	if spam.GetTokenCount("buy") != 2 {
-		t.Fatalf("unexpected buy token count: got %d, want 2", spam.GetTokenCount("buy"))
+		t.Fatalf("unexpected buy token count: got %v, want 2", spam.GetTokenCount("buy"))
 	}
 
 	if err := classifier.Untrain("spam", "buy now"); err != nil {
@@ -33,7 +38,7 @@ func TestTrainUntrainLifecycle(t *testing.T) {
 		t.Fatal("expected spam category to still exist")
 	}
 	if spam.GetTally() != 2 {
-		t.Fatalf("unexpected spam tally after untrain: got %d, want 2", spam.GetTally())
+		t.Fatalf("unexpected spam tally after untrain: got %v, want 2", spam.GetTally())
 	}
 
 	if err := classifier.Untrain("spam", "buy now"); err != nil {
@@ -105,6 +110,120 @@ func TestClassifyTieBreaksDeterministically(t *testing.T) {
 	}
 }
 
+// newThreeCategoryClassifier returns a classifier with spam, ham, and news categories.
+func newThreeCategoryClassifier(t *testing.T) *Classifier {
+	t.Helper()
+	classifier := NewClassifier()
+	for name, sample := range map[string]string{
+		"spam": "free prize click now free",
+		"ham":  "team meeting schedule project",
+		"news": "election results prize story",
+	} {
+		if err := classifier.Train(name, sample); err != nil {
+			t.Fatalf("unexpected train error: %v", err)
+		}
+	}
+	return classifier
+}
+
+// TestClassifyTopN verifies ranked results are ordered, truncated, and match Classify.
+func TestClassifyTopN(t *testing.T) {
+	classifier := newThreeCategoryClassifier(t)
+
+	ranking := classifier.ClassifyTopN("free prize meeting", 5)
+	if len(ranking) != 3 {
+		t.Fatalf("expected 3 ranked categories, got %+v", ranking)
+	}
+	if ranking[0] != classifier.Classify("free prize meeting") {
+		t.Fatalf("expected first ranked entry to match Classify, got %+v", ranking[0])
+	}
+	sum := 0.0
+	for i, entry := range ranking {
+		sum += entry.Probability
+		if i > 0 && entry.Score > ranking[i-1].Score {
+			t.Fatalf("expected descending scores, got %+v", ranking)
+		}
+	}
+	if sum < 0.999999 || sum > 1.000001 {
+		t.Fatalf("expected probabilities to sum to 1, got %v", sum)
+	}
+
+	if top := classifier.ClassifyTopN("free prize meeting", 2); len(top) != 2 || top[0] != ranking[0] || top[1] != ranking[1] {
+		t.Fatalf("expected top 2 prefix of ranking, got %+v", top)
+	}
+	if top := classifier.ClassifyTopN("free prize meeting", 0); len(top) != 0 {
+		t.Fatalf("expected no results for n=0, got %+v", top)
+	}
+	if top := classifier.ClassifyTopN("unseen", 3); len(top) != 0 {
+		t.Fatalf("expected no results for unmatched text, got %+v", top)
+	}
+}
+
+// TestClassifyWithOptionsAbstains verifies the score and margin thresholds and the unknown label.
+func TestClassifyWithOptionsAbstains(t *testing.T) {
+	classifier := newThreeCategoryClassifier(t)
+	best := classifier.Classify("free prize meeting")
+	ranking := classifier.ClassifyTopN("free prize meeting", 2)
+	margin := ranking[0].Score - ranking[1].Score
+
+	tests := []struct {
+		name      string
+		opts      ClassifyOptions
+		abstained bool
+	}{
+		{name: "no thresholds", opts: ClassifyOptions{Unknown: "unknown"}},
+		{name: "score met", opts: ClassifyOptions{MinScore: new(best.Score), Unknown: "unknown"}},
+		{name: "score missed", opts: ClassifyOptions{MinScore: new(best.Score + 1), Unknown: "unknown"}, abstained: true},
+		{name: "margin met", opts: ClassifyOptions{MinMargin: margin, Unknown: "unknown"}},
+		{name: "margin missed", opts: ClassifyOptions{MinMargin: margin + 0.01, Unknown: "unknown"}, abstained: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := classifier.ClassifyWithOptions("free prize meeting", tt.opts)
+			if result.Abstained != tt.abstained {
+				t.Fatalf("expected abstained=%v, got %+v", tt.abstained, result)
+			}
+			want := best.Category
+			if tt.abstained {
+				want = "unknown"
+			}
+			if result.Category != want || result.Score != best.Score {
+				t.Fatalf("expected category %q with best score, got %+v", want, result)
+			}
+			if len(result.Ranking) != 0 {
+				t.Fatalf("expected no ranking for N=0, got %+v", result.Ranking)
+			}
+		})
+	}
+
+	result := classifier.ClassifyWithOptions("unseen", ClassifyOptions{N: 3, Unknown: "unknown"})
+	if !result.Abstained || result.Category != "unknown" || len(result.Ranking) != 0 {
+		t.Fatalf("expected abstain when nothing scores, got %+v", result)
+	}
+	result = classifier.ClassifyWithOptions("free prize meeting", ClassifyOptions{N: 2, MinMargin: 1000})
+	if !result.Abstained || result.Category != "" || len(result.Ranking) != 2 {
+		t.Fatalf("expected ranking alongside empty unknown label, got %+v", result)
+	}
+}
+
+// TestClassifyWithOptionsZeroMinScore verifies a zero MinScore is a real
+// threshold in log-space modes, where every score is negative.
+func TestClassifyWithOptionsZeroMinScore(t *testing.T) {
+	classifier := newThreeCategoryClassifier(t)
+	if err := classifier.SetScoringOptions(ScoringOptions{Mode: ScoringMultinomial}); err != nil {
+		t.Fatalf("unexpected scoring error: %v", err)
+	}
+
+	result := classifier.ClassifyWithOptions("free prize meeting", ClassifyOptions{MinScore: new(0.0), Unknown: "unknown"})
+	if result.Score >= 0 || !result.Abstained || result.Category != "unknown" {
+		t.Fatalf("expected abstain below a zero threshold, got %+v", result)
+	}
+	result = classifier.ClassifyWithOptions("free prize meeting", ClassifyOptions{Unknown: "unknown"})
+	if result.Abstained {
+		t.Fatalf("expected no abstain without a threshold, got %+v", result)
+	}
+}
+
 // TestTrainReturnsErrorForInvalidCategoryNames verifies train returns error for invalid category names.
 func TestTrainReturnsErrorForInvalidCategoryNames(t *testing.T) {
 	classifier := NewClassifier()
@@ -138,7 +257,7 @@ func TestUntrainReturnsErrorForInvalidCategoryNames(t *testing.T) {
 		t.Fatal("expected spam category to remain")
 	}
 	if got := cat.GetTally(); got != 2 {
-		t.Fatalf("expected spam tally unchanged, got %d", got)
+		t.Fatalf("expected spam tally unchanged, got %v", got)
 	}
 }
 
@@ -169,7 +288,7 @@ func TestCustomTokenizerIsUsed(t *testing.T) {
 		t.Fatal("expected tech category")
 	}
 	if got := cat.GetTokenCount("custom"); got != 2 {
-		t.Fatalf("expected custom token count 2, got %d", got)
+		t.Fatalf("expected custom token count 2, got %v", got)
 	}
 }
 
@@ -188,10 +307,10 @@ func TestSummariesReturnsSnapshot(t *testing.T) {
 		t.Fatalf("expected 2 category summaries, got %d", len(summaries))
 	}
 	if summaries["spam"].TokenTally != 2 {
-		t.Fatalf("expected spam tally 2, got %d", summaries["spam"].TokenTally)
+		t.Fatalf("expected spam tally 2, got %v", summaries["spam"].TokenTally)
 	}
 	if summaries["ham"].TokenTally != 3 {
-		t.Fatalf("expected ham tally 3, got %d", summaries["ham"].TokenTally)
+		t.Fatalf("expected ham tally 3, got %v", summaries["ham"].TokenTally)
 	}
 }
 
@@ -217,17 +336,17 @@ func TestNewClassifierWithTokenizer(t *testing.T) {
 	}
 	cat, _ := c.categories.LookupCategory("a")
 	if cat.GetTokenCount("x") != 1 || cat.GetTokenCount("y") != 1 {
-		t.Fatalf("expected custom tokenizer output, got counts x=%d y=%d", cat.GetTokenCount("x"), cat.GetTokenCount("y"))
+		t.Fatalf("expected custom tokenizer output, got counts x=%v y=%v", cat.GetTokenCount("x"), cat.GetTokenCount("y"))
 	}
 }
 
 // TestNewClassifierWithOptions verifies options constructor and tokenizer config.
 func TestNewClassifierWithOptions(t *testing.T) {
 	c := NewClassifierWithOptions("spanish", true)
-	if c.tokenizerLang != "spanish" {
-		t.Fatalf("expected tokenizerLang spanish, got %q", c.tokenizerLang)
+	if c.tokenizerOptions.Language != "spanish" {
+		t.Fatalf("expected tokenizerLang spanish, got %q", c.tokenizerOptions.Language)
 	}
-	if !c.tokenizerRemoveStopWords {
+	if !c.tokenizerOptions.RemoveStopWords {
 		t.Fatal("expected tokenizerRemoveStopWords true")
 	}
 	if err := c.Train("spam", "el gato"); err != nil {
@@ -239,15 +358,150 @@ func TestNewClassifierWithOptions(t *testing.T) {
 	}
 }
 
+// TestTokenizerOptions verifies the reported tokenizer configuration for each constructor.
+func TestTokenizerOptions(t *testing.T) {
+	if opts := NewClassifier().TokenizerOptions(); !reflect.DeepEqual(opts, TokenizerOptions{Language: "english", NGrams: 1, Tokenizer: TokenizerWords}) {
+		t.Fatalf("unexpected default tokenizer options: %+v", opts)
+	}
+	if opts := NewClassifierWithOptions("French", true, WithNGrams(2)).TokenizerOptions(); !reflect.DeepEqual(opts, TokenizerOptions{Language: "french", RemoveStopWords: true, NGrams: 2, Tokenizer: TokenizerWords}) {
+		t.Fatalf("unexpected tokenizer options: %+v", opts)
+	}
+	custom := NewClassifierWithTokenizer(func(string) []string { return nil })
+	if opts := custom.TokenizerOptions(); !reflect.DeepEqual(opts, TokenizerOptions{}) {
+		t.Fatalf("expected zero options for custom tokenizer, got %+v", opts)
+	}
+}
+
+// TestTokenizerNGrams verifies n-grams are built from stems after stop-word filtering.
+func TestTokenizerNGrams(t *testing.T) {
+	tokens := NewTokenizer(TokenizerOptions{Language: "english", RemoveStopWords: true, NGrams: 2})("The movie was a terrible film")
+	want := []string{"movi", "terribl", "film", "movi terribl", "terribl film"}
+	if !slices.Equal(tokens, want) {
+		t.Fatalf("expected %v, got %v", want, tokens)
+	}
+
+	tokens = NewTokenizer(TokenizerOptions{NGrams: 3})("not good at all")
+	want = []string{"not", "good", "at", "all", "not good", "good at", "at all", "not good at", "good at all"}
+	if !slices.Equal(tokens, want) {
+		t.Fatalf("expected %v, got %v", want, tokens)
+	}
+
+	for _, n := range []int{-1, 0, 1} {
+		if tokens := NewTokenizer(TokenizerOptions{NGrams: n})("not good"); !slices.Equal(tokens, []string{"not", "good"}) {
+			t.Fatalf("expected single words for NGrams=%d, got %v", n, tokens)
+		}
+	}
+}
+
+// TestCharTokenizer verifies character n-grams for continuous scripts and whole words elsewhere.
+func TestCharTokenizer(t *testing.T) {
+	tokens := NewTokenizer(TokenizerOptions{Language: "chinese"})("我爱北京")
+	want := []string{"我", "爱", "北", "京", "我爱", "爱北", "北京"}
+	if !slices.Equal(tokens, want) {
+		t.Fatalf("expected %v, got %v", want, tokens)
+	}
+
+	tokens = NewTokenizer(TokenizerOptions{Tokenizer: "CHARS", CharNGramMin: 2, CharNGramMax: 2})("Ｈｅｌｌｏ 東京タワー, the CAFÉ!")
+	want = []string{"hello", "東京", "京タ", "タワ", "ワー", "the", "café"}
+	if !slices.Equal(tokens, want) {
+		t.Fatalf("expected %v, got %v", want, tokens)
+	}
+
+	tokens = NewTokenizer(TokenizerOptions{Language: "english", Tokenizer: TokenizerChars, RemoveStopWords: true, CharNGramMin: 3, CharNGramMax: 1})("the 日本 한국어")
+	want = []string{"日本", "한국어"}
+	if !slices.Equal(tokens, want) {
+		t.Fatalf("expected stop words removed and short runs kept whole, got %v", tokens)
+	}
+
+	tokens = NewTokenizer(TokenizerOptions{Language: "thai", CharNGramMin: 3, CharNGramMax: 3})("สวัสดี")
+	want = []string{"สวั", "วัส", "ัสด", "สดี"}
+	if !slices.Equal(tokens, want) {
+		t.Fatalf("expected thai run with combining marks, got %v", tokens)
+	}
+}
+
+// TestScriptClass verifies continuous scripts are told apart, so runs of
+// different scripts are not joined into one n-gram.
+func TestScriptClass(t *testing.T) {
+	for r, want := range map[rune]int{
+		'京': scriptCJK, 'カ': scriptCJK, '한': scriptHangul, 'ส': scriptThai,
+		'ລ': scriptLao, 'ខ': scriptKhmer, 'မ': scriptMyanmar, 'a': scriptWords, '7': scriptWords,
+	} {
+		if got := scriptClass(r); got != want {
+			t.Fatalf("scriptClass(%q) = %d, want %d", r, got, want)
+		}
+	}
+}
+
+// TestCharTokenizerSelection verifies tokenizer selection by name and language.
+func TestCharTokenizerSelection(t *testing.T) {
+	for _, tc := range []struct {
+		opts TokenizerOptions
+		want TokenizerOptions
+	}{
+		{TokenizerOptions{Language: "Japanese"}, TokenizerOptions{Language: "japanese", NGrams: 1, Tokenizer: TokenizerChars, CharNGramMin: 1, CharNGramMax: 2}},
+		{TokenizerOptions{Language: "korean", Tokenizer: "words"}, TokenizerOptions{Language: "korean", NGrams: 1, Tokenizer: TokenizerWords}},
+		{TokenizerOptions{Tokenizer: "unknown", CharNGramMin: 2}, TokenizerOptions{Language: "english", NGrams: 1, Tokenizer: TokenizerWords}},
+		{TokenizerOptions{Tokenizer: "chars", CharNGramMin: 3}, TokenizerOptions{Language: "english", NGrams: 1, Tokenizer: TokenizerChars, CharNGramMin: 3, CharNGramMax: 3}},
+	} {
+		if got := tc.opts.normalize(); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("normalize(%+v): expected %+v, got %+v", tc.opts, tc.want, got)
+		}
+	}
+
+	c := NewClassifierWithOptions("english", false, WithTokenizer(TokenizerChars), WithCharNGrams(2, 3))
+	if opts := c.TokenizerOptions(); opts.Tokenizer != TokenizerChars || opts.CharNGramMin != 2 || opts.CharNGramMax != 3 {
+		t.Fatalf("unexpected tokenizer options: %+v", opts)
+	}
+
+	if name, err := ParseTokenizer(" Chars "); err != nil || name != TokenizerChars {
+		t.Fatalf("expected chars, got %q, %v", name, err)
+	}
+	if _, err := ParseTokenizer("bigrams"); !errors.Is(err, ErrInvalidTokenizer) {
+		t.Fatalf("expected ErrInvalidTokenizer, got %v", err)
+	}
+}
+
+// TestCharTokenizerClassifies verifies a chars classifier separates Chinese samples.
+func TestCharTokenizerClassifies(t *testing.T) {
+	c := NewClassifierWithOptions("chinese", false)
+	if err := c.Train("sports", "足球比赛今天开始，球队赢了"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := c.Train("finance", "股票市场今天下跌，银行利率上升"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if got := c.Classify("球队比赛").Category; got != "sports" {
+		t.Fatalf("expected sports, got %q", got)
+	}
+	if got := c.Classify("银行股票").Category; got != "finance" {
+		t.Fatalf("expected finance, got %q", got)
+	}
+}
+
+// TestNGramsSeparateNegation verifies bigrams let "not good" differ from "good".
+func TestNGramsSeparateNegation(t *testing.T) {
+	classifier := NewClassifierWithOptions("english", false, WithNGrams(2))
+	if err := classifier.Train("positive", "good good good"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := classifier.Train("negative", "not good"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if got := classifier.Classify("not good").Category; got != "negative" {
+		t.Fatalf("expected negative for \"not good\", got %q", got)
+	}
+}
+
 // TestNewClassifierWithOptionsNormalizesLang verifies language normalization.
 func TestNewClassifierWithOptionsNormalizesLang(t *testing.T) {
 	c := NewClassifierWithOptions("  SPANISH  ", false)
-	if c.tokenizerLang != "spanish" {
-		t.Fatalf("expected normalized lang spanish, got %q", c.tokenizerLang)
+	if c.tokenizerOptions.Language != "spanish" {
+		t.Fatalf("expected normalized lang spanish, got %q", c.tokenizerOptions.Language)
 	}
 	c2 := NewClassifierWithOptions("", false)
-	if c2.tokenizerLang != "english" {
-		t.Fatalf("expected default lang english for empty, got %q", c2.tokenizerLang)
+	if c2.tokenizerOptions.Language != "english" {
+		t.Fatalf("expected default lang english for empty, got %q", c2.tokenizerOptions.Language)
 	}
 }
 
@@ -309,9 +563,173 @@ func TestDefaultTokenizerNormalizesPunctuationAndStems(t *testing.T) {
 	}
 
 	if got := cat.GetTokenCount("run"); got < 2 {
-		t.Fatalf("expected stemming to accumulate run tokens, got %d", got)
+		t.Fatalf("expected stemming to accumulate run tokens, got %v", got)
 	}
 	if got := cat.GetTokenCount("running"); got != 0 {
-		t.Fatalf("expected running token to be stemmed away, got %d", got)
+		t.Fatalf("expected running token to be stemmed away, got %v", got)
+	}
+}
+
+// TestRevisionTracksMutations verifies revision changes on mutation and not on reads.
+func TestRevisionTracksMutations(t *testing.T) {
+	classifier := NewClassifier()
+	start := classifier.Revision()
+
+	classifier.Score("anything")
+	classifier.Classify("anything")
+	_ = classifier.Summaries()
+	if got := classifier.Revision(); got != start {
+		t.Fatalf("expected read-only calls to keep revision %d, got %d", start, got)
+	}
+
+	if err := classifier.Train("spam", "buy now"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	afterTrain := classifier.Revision()
+	if afterTrain == start {
+		t.Fatal("expected train to change revision")
+	}
+
+	if err := classifier.Untrain("spam", "buy"); err != nil {
+		t.Fatalf("unexpected untrain error: %v", err)
+	}
+	afterUntrain := classifier.Revision()
+	if afterUntrain == afterTrain {
+		t.Fatal("expected untrain to change revision")
+	}
+
+	classifier.Flush()
+	if classifier.Revision() == afterUntrain {
+		t.Fatal("expected flush to change revision")
+	}
+
+	if err := classifier.Train("bad name", "text"); err == nil {
+		t.Fatal("expected invalid category error")
+	}
+}
+
+// TestPriorsRefreshWhenRetrainingExistingCategory verifies priors update after retraining a category.
+func TestPriorsRefreshWhenRetrainingExistingCategory(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.Train("spam", "buy now"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := classifier.Train("ham", "team meeting"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := classifier.Train("spam", "cheap pills free offer"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	summaries := classifier.Summaries()
+	if got := summaries["spam"].ProbInCat; got != 0.75 {
+		t.Fatalf("unexpected spam prior after retraining: got %f, want 0.75", got)
+	}
+	if got := summaries["ham"].ProbInCat; got != 0.25 {
+		t.Fatalf("unexpected ham prior after retraining: got %f, want 0.25", got)
+	}
+}
+
+// TestCustomStopWords verifies extra and excluded stop words for both tokenizers.
+func TestCustomStopWords(t *testing.T) {
+	tokenize := NewTokenizer(TokenizerOptions{
+		Language:          "english",
+		RemoveStopWords:   true,
+		ExtraStopWords:    []string{" Unsubscribe ", "acme", "acme"},
+		ExcludedStopWords: []string{"NOT"},
+	})
+	tokens := tokenize("Acme offers: not the unsubscribed deal")
+	want := []string{"offer", "not", "deal"}
+	if !slices.Equal(tokens, want) {
+		t.Fatalf("expected %v, got %v", want, tokens)
+	}
+
+	tokens = NewTokenizer(TokenizerOptions{ExtraStopWords: []string{"acme"}})("the acme offer")
+	if !slices.Equal(tokens, []string{"the", "offer"}) {
+		t.Fatalf("expected extra stop words without RemoveStopWords, got %v", tokens)
+	}
+
+	tokens = NewTokenizer(TokenizerOptions{ExcludedStopWords: []string{"the"}})("the offer")
+	if !slices.Equal(tokens, []string{"the", "offer"}) {
+		t.Fatalf("expected excluded stop words alone to filter nothing, got %v", tokens)
+	}
+
+	tokens = NewTokenizer(TokenizerOptions{Tokenizer: TokenizerChars, ExtraStopWords: []string{"acme"}})("acme 東京")
+	if !slices.Equal(tokens, []string{"東", "京", "東京"}) {
+		t.Fatalf("expected chars tokenizer to filter extra stop words, got %v", tokens)
+	}
+
+	c := NewClassifierWithOptions("english", true, WithStopWords([]string{"Zeta", "alpha"}, []string{"not"}))
+	opts := c.TokenizerOptions()
+	if !slices.Equal(opts.ExtraStopWords, []string{"alpha", "zeta"}) || !slices.Equal(opts.ExcludedStopWords, []string{"not"}) {
+		t.Fatalf("expected normalized stop-word customizations, got %+v", opts)
+	}
+	if _, ok := stopwords.Get("english")["not"]; !ok {
+		t.Fatal("expected built-in stop list to be left unchanged")
+	}
+}
+
+// TestTrainWeighted verifies weighted samples count weight times and that
+// invalid weights are rejected without changing the model.
+func TestTrainWeighted(t *testing.T) {
+	c := NewClassifierWithTokenizer(strings.Fields)
+	if err := c.TrainWeighted("spam", "buy now", 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Train("ham", "buy lunch"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summaries := c.Summaries()
+	if summaries["spam"].TokenTally != 6 || summaries["spam"].DocumentCount != 3 {
+		t.Fatalf("unexpected weighted summary: %+v", summaries["spam"])
+	}
+	if got := c.Classify("buy").Category; got != "spam" {
+		t.Fatalf("expected weighted category to win, got %q", got)
+	}
+
+	revision := c.Revision()
+	for _, weight := range []float64{0, -2, math.NaN(), math.Inf(1)} {
+		if err := c.TrainWeighted("spam", "buy", weight); !errors.Is(err, ErrInvalidWeight) {
+			t.Fatalf("TrainWeighted(%v): expected ErrInvalidWeight, got %v", weight, err)
+		}
+		if err := c.UntrainWeighted("spam", "buy", weight); !errors.Is(err, ErrInvalidWeight) {
+			t.Fatalf("UntrainWeighted(%v): expected ErrInvalidWeight, got %v", weight, err)
+		}
+	}
+	if c.Revision() != revision {
+		t.Fatal("expected invalid weights to leave the model unchanged")
+	}
+
+	if err := c.UntrainWeighted("spam", "buy now", 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := c.Summaries()["spam"]; ok {
+		t.Fatal("expected weighted untrain to remove the category")
+	}
+}
+
+// TestEmptyCopy verifies EmptyCopy keeps configuration but not training.
+func TestEmptyCopy(t *testing.T) {
+	c := NewClassifierWithOptions("spanish", true, WithNGrams(2))
+	if err := c.SetScoringOptions(ScoringOptions{Mode: ScoringMultinomial, Prior: PriorDocuments}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = c.Train("spam", "compra ahora")
+
+	clone := c.EmptyCopy()
+	if len(clone.Summaries()) != 0 {
+		t.Fatal("expected an untrained copy")
+	}
+	if !reflect.DeepEqual(clone.TokenizerOptions(), c.TokenizerOptions()) || clone.ScoringOptions() != c.ScoringOptions() {
+		t.Fatalf("expected matching options, got %+v %+v", clone.TokenizerOptions(), clone.ScoringOptions())
+	}
+	_ = clone.Train("ham", "hola amigo")
+	if _, ok := c.Summaries()["ham"]; ok {
+		t.Fatal("expected training the copy to leave the original unchanged")
+	}
+
+	if !ValidCategoryName("spam-2_x") || ValidCategoryName("no spaces") || ValidCategoryName("") {
+		t.Fatal("unexpected ValidCategoryName result")
 	}
 }
@@ -0,0 +1,94 @@
+package bayes
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hickeroar/gobayes/v4/bayes/category"
+)
+
+// ErrCategoryNotFound indicates an operation on a category that has not been
+// trained.
+var ErrCategoryNotFound = category.ErrCategoryNotFound
+
+// ErrCategoryExists indicates a rename onto a category that already exists.
+var ErrCategoryExists = category.ErrCategoryExists
+
+// DeleteCategory removes a category and all of its training data.
+func (c *Classifier) DeleteCategory(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.lookupCategoryUnlocked(name); err != nil {
+		return err
+	}
+	c.categories.DeleteCategory(name)
+	c.categories.EnsureCategoryProbabilities()
+	c.revision++
+	return nil
+}
+
+// RenameCategory renames a category, keeping its training data. Renaming a
+// category onto itself is a no-op.
+func (c *Classifier) RenameCategory(oldName, newName string) error {
+	for _, name := range []string{oldName, newName} {
+		if !ValidCategoryName(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidCategoryName, name)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.categories.RenameCategory(oldName, newName); err != nil {
+		return err
+	}
+	if oldName == newName {
+		return nil
+	}
+	c.categories.EnsureCategoryProbabilities()
+	c.revision++
+	return nil
+}
+
+// MergeCategories folds the training data of every source category into dst,
+// creating dst when it does not exist, and deletes the sources, e.g. to
+// collapse labels that turned out to mean the same thing. Nothing changes
+// when a source does not exist. Merging no sources, or only dst itself, is a
+// no-op.
+func (c *Classifier) MergeCategories(dst string, srcs ...string) error {
+	if len(srcs) == 0 {
+		return nil
+	}
+	for _, name := range append([]string{dst}, srcs...) {
+		if !ValidCategoryName(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidCategoryName, name)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.categories.MergeCategories(dst, srcs...); err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(srcs, func(src string) bool { return src != dst }) {
+		return nil
+	}
+	c.categories.EnsureCategoryProbabilities()
+	c.revision++
+	return nil
+}
+
+// lookupCategoryUnlocked returns a trained category by name while the
+// classifier lock is held.
+func (c *Classifier) lookupCategoryUnlocked(name string) (*category.Category, error) {
+	if !ValidCategoryName(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCategoryName, name)
+	}
+	cat, ok := c.categories.LookupCategory(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrCategoryNotFound, name)
+	}
+	return cat, nil
+}
@@ -0,0 +1,125 @@
+package bayes
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestDeleteCategory verifies deleting a category and recomputing priors.
+func TestDeleteCategory(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "buy now")
+	_ = c.Train("ham", "hello friend")
+	revision := c.Revision()
+
+	if err := c.DeleteCategory("missing"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+	if err := c.DeleteCategory("bad name"); !errors.Is(err, ErrInvalidCategoryName) {
+		t.Fatalf("expected ErrInvalidCategoryName, got %v", err)
+	}
+	if c.Revision() != revision {
+		t.Fatal("expected failed deletes to leave the model unchanged")
+	}
+
+	if err := c.DeleteCategory("spam"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	summaries := c.Summaries()
+	if _, ok := summaries["spam"]; ok || summaries["ham"].ProbInCat != 1 || c.Revision() == revision {
+		t.Fatalf("unexpected summaries after delete: %+v", summaries)
+	}
+	if got := c.Classify("buy now").Category; got != "" {
+		t.Fatalf("expected deleted tokens to be forgotten, got %q", got)
+	}
+}
+
+// TestRenameCategory verifies renaming keeps training data and priors.
+func TestRenameCategory(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "buy now")
+	_ = c.Train("ham", "hello")
+	before := c.Summaries()["spam"]
+
+	if err := c.RenameCategory("spam", "junk"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	summaries := c.Summaries()
+	if _, ok := summaries["spam"]; ok || summaries["junk"] != before {
+		t.Fatalf("unexpected summaries after rename: %+v", summaries)
+	}
+	if got := c.Classify("buy now").Category; got != "junk" {
+		t.Fatalf("expected renamed category to classify, got %q", got)
+	}
+
+	if err := c.RenameCategory("junk", "ham"); !errors.Is(err, ErrCategoryExists) {
+		t.Fatalf("expected ErrCategoryExists, got %v", err)
+	}
+	if err := c.RenameCategory("spam", "other"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+	if err := c.RenameCategory("junk", "bad/name"); !errors.Is(err, ErrInvalidCategoryName) {
+		t.Fatalf("expected ErrInvalidCategoryName, got %v", err)
+	}
+
+	revision := c.Revision()
+	if err := c.RenameCategory("junk", "junk"); err != nil || c.Revision() != revision {
+		t.Fatalf("expected renaming a category onto itself to leave the revision unchanged, got %v", err)
+	}
+	if err := c.RenameCategory("spam", "spam"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+}
+
+// TestMergeCategories verifies merging sums training data and recomputes priors.
+func TestMergeCategories(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "buy now")
+	_ = c.Train("phishing", "login now")
+	_ = c.Train("ham", "hello friend")
+
+	revision := c.Revision()
+	if err := c.MergeCategories("spam"); err != nil || c.Revision() != revision {
+		t.Fatalf("expected merging no sources to be a no-op, got %v", err)
+	}
+	if err := c.MergeCategories("spam", "not valid"); !errors.Is(err, ErrInvalidCategoryName) {
+		t.Fatalf("expected ErrInvalidCategoryName, got %v", err)
+	}
+	if err := c.MergeCategories("spam", "phishing", "missing"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+	if len(c.Summaries()) != 3 {
+		t.Fatal("expected a failed merge to leave categories unchanged")
+	}
+
+	if err := c.MergeCategories("spam", "phishing"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	summaries := c.Summaries()
+	spam := summaries["spam"]
+	if len(summaries) != 2 || spam.TokenTally != 4 || spam.DocumentCount != 2 {
+		t.Fatalf("unexpected summaries after merge: %+v", summaries)
+	}
+	if math.Abs(spam.ProbInCat-4.0/6.0) > 1e-12 {
+		t.Fatalf("expected priors to be recomputed, got %v", spam.ProbInCat)
+	}
+	if got := c.Classify("login").Category; got != "spam" {
+		t.Fatalf("expected merged tokens to classify as spam, got %q", got)
+	}
+
+	if err := c.MergeCategories("junk", "spam", "ham"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summaries := c.Summaries(); len(summaries) != 1 || summaries["junk"].ProbInCat != 1 {
+		t.Fatalf("expected merging into a new category to create it, got %+v", summaries)
+	}
+
+	revision = c.Revision()
+	if err := c.MergeCategories("junk", "junk", "junk"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Revision() != revision || c.Summaries()["junk"].TokenTally != 6 {
+		t.Fatalf("expected merging a category into itself to change nothing, got %+v", c.Summaries())
+	}
+}
@@ -1,17 +1,24 @@
 package category
 
-import "fmt"
+import (
+	"fmt"
+	"iter"
+	"math"
+)
 
 // CategorySummary is a read-only summary used by API responses.
 type CategorySummary struct {
-	TokenTally   int
-	ProbNotInCat float64
-	ProbInCat    float64
+	TokenTally    float64
+	DocumentCount float64
+	ProbNotInCat  float64
+	ProbInCat     float64
 }
 
 // Categories stores and manages trained Category values.
 type Categories struct {
 	categories         map[string]*Category // Map of category names to categories
+	vocabulary         map[string]float64   // Map of tokens to their total count across categories
+	documentPriors     bool                 // Compute priors from document counts instead of token tallies
 	probabilitiesDirty bool
 }
 
@@ -19,6 +26,7 @@ type Categories struct {
 func NewCategories() *Categories {
 	return &Categories{
 		categories:         make(map[string]*Category),
+		vocabulary:         make(map[string]float64),
 		probabilitiesDirty: true,
 	}
 }
@@ -45,10 +53,202 @@ func (cats *Categories) GetCategory(name string) *Category {
 
 // DeleteCategory removes a category by name.
 func (cats *Categories) DeleteCategory(name string) {
+	if cat, ok := cats.categories[name]; ok {
+		for token, count := range cat.tokens {
+			cats.forgetToken(token, count)
+		}
+	}
 	delete(cats.categories, name)
 	cats.probabilitiesDirty = true
 }
 
+// RenameCategory gives the category oldName the name newName, keeping its
+// counts. It fails when oldName does not exist or newName does.
+func (cats *Categories) RenameCategory(oldName, newName string) error {
+	cat, ok := cats.categories[oldName]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrCategoryNotFound, oldName)
+	}
+	if oldName == newName {
+		return nil
+	}
+	if _, exists := cats.categories[newName]; exists {
+		return fmt.Errorf("%w: %q", ErrCategoryExists, newName)
+	}
+
+	delete(cats.categories, oldName)
+	cat.name = newName
+	cats.categories[newName] = cat
+	cats.probabilitiesDirty = true
+	return nil
+}
+
+// MergeCategories adds the counts of every source category to dst, creating
+// dst when missing, and deletes the sources. A source equal to dst or listed
+// twice is merged once. It fails without changes when a source does not
+// exist. The shared vocabulary is unchanged because no token is added or
+// removed overall.
+func (cats *Categories) MergeCategories(dst string, srcs ...string) error {
+	for _, src := range srcs {
+		if _, ok := cats.categories[src]; !ok {
+			return fmt.Errorf("%w: %q", ErrCategoryNotFound, src)
+		}
+	}
+	if len(srcs) == 0 {
+		return nil
+	}
+
+	target := cats.GetCategory(dst)
+	for _, src := range srcs {
+		cat, ok := cats.categories[src]
+		if !ok || src == dst {
+			continue
+		}
+		target.absorb(cat)
+		delete(cats.categories, src)
+	}
+	cats.probabilitiesDirty = true
+	return nil
+}
+
+// TrainToken adds count occurrences of word to cat, keeping the shared
+// vocabulary and priors in sync. Prefer it over Category.TrainToken for
+// categories owned by this collection.
+func (cats *Categories) TrainToken(cat *Category, word string, count float64) error {
+	if err := cat.TrainToken(word, count); err != nil {
+		return err
+	}
+	cats.vocabulary[word] += count
+	cats.probabilitiesDirty = true
+	return nil
+}
+
+// UntrainToken removes count occurrences of word from cat, keeping the shared
+// vocabulary and priors in sync.
+func (cats *Categories) UntrainToken(cat *Category, word string, count float64) error {
+	before := cat.GetTokenCount(word)
+	if err := cat.UntrainToken(word, count); err != nil {
+		return err
+	}
+	cats.forgetToken(word, before-cat.GetTokenCount(word))
+	cats.probabilitiesDirty = true
+	return nil
+}
+
+// TrainDocument adds one document's token occurrences to cat and records it
+// in the category's document statistics.
+func (cats *Categories) TrainDocument(cat *Category, occurrences map[string]int) error {
+	return cats.TrainWeightedDocument(cat, occurrences, 1)
+}
+
+// TrainWeightedDocument is TrainDocument with every occurrence, and the
+// document itself, counted weight times. weight must be positive and finite.
+func (cats *Categories) TrainWeightedDocument(cat *Category, occurrences map[string]int, weight float64) error {
+	if err := validateDocument(occurrences, weight); err != nil {
+		return err
+	}
+	for token, count := range occurrences {
+		_ = cats.TrainToken(cat, token, float64(count)*weight)
+	}
+	cat.addDocument(occurrences, weight)
+	return nil
+}
+
+// UntrainDocument removes one document's token occurrences from cat and
+// forgets it in the category's document statistics.
+func (cats *Categories) UntrainDocument(cat *Category, occurrences map[string]int) error {
+	return cats.UntrainWeightedDocument(cat, occurrences, 1)
+}
+
+// UntrainWeightedDocument reverses TrainWeightedDocument with the same weight.
+func (cats *Categories) UntrainWeightedDocument(cat *Category, occurrences map[string]int, weight float64) error {
+	if err := validateDocument(occurrences, weight); err != nil {
+		return err
+	}
+	// Forget the document before removing tokens so the token-count cap on
+	// document frequencies applies to the already-decremented values.
+	cat.removeDocument(occurrences, weight)
+	for token, count := range occurrences {
+		_ = cats.UntrainToken(cat, token, float64(count)*weight)
+	}
+	return nil
+}
+
+// validateDocument checks that every occurrence count and the weight are
+// positive, and that the weighted counts are finite.
+func validateDocument(occurrences map[string]int, weight float64) error {
+	if !validCount(weight) {
+		return ErrInvalidTokenCount
+	}
+	for _, count := range occurrences {
+		if count <= 0 || !validCount(float64(count)*weight) {
+			return ErrInvalidTokenCount
+		}
+	}
+	return nil
+}
+
+// Decay multiplies every count, including document counts and frequencies,
+// by factor, which must be in (0, 1]. Decaying regularly makes older
+// training weigh exponentially less than recent training. Tokens whose
+// counts become negligible are dropped; categories left without tokens are
+// kept for the caller to remove.
+func (cats *Categories) Decay(factor float64) error {
+	if !(factor > 0 && factor <= 1) {
+		return ErrInvalidDecayFactor
+	}
+	if factor == 1 {
+		return nil
+	}
+
+	for _, cat := range cats.categories {
+		cat.scale(factor)
+	}
+	vocabulary := make(map[string]float64, len(cats.vocabulary))
+	for _, cat := range cats.categories {
+		for token, count := range cat.tokens {
+			vocabulary[token] += count
+		}
+	}
+	cats.vocabulary = vocabulary
+	cats.probabilitiesDirty = true
+	return nil
+}
+
+// VocabularySize returns the number of distinct tokens across all categories.
+func (cats *Categories) VocabularySize() int {
+	return len(cats.vocabulary)
+}
+
+// TokenTotal returns the number of times word appears across all categories.
+func (cats *Categories) TokenTotal(word string) float64 {
+	return cats.vocabulary[word]
+}
+
+// Vocabulary iterates over every distinct token and its total count across
+// all categories. The collection must not be modified during iteration.
+func (cats *Categories) Vocabulary() iter.Seq2[string, float64] {
+	return func(yield func(string, float64) bool) {
+		for token, total := range cats.vocabulary {
+			if !yield(token, total) {
+				return
+			}
+		}
+	}
+}
+
+// forgetToken removes count occurrences of token from the vocabulary totals.
+func (cats *Categories) forgetToken(token string, count float64) {
+	if count <= 0 {
+		return
+	}
+	if cats.vocabulary[token] <= count+countEpsilon {
+		delete(cats.vocabulary, token)
+		return
+	}
+	cats.vocabulary[token] -= count
+}
+
 // Names returns all known category names.
 func (cats *Categories) Names() []string {
 	names := make([]string, 0, len(cats.categories))
@@ -76,6 +276,15 @@ func (cats *Categories) MarkProbabilitiesDirty() {
 	cats.probabilitiesDirty = true
 }
 
+// SetDocumentPriors selects whether priors are computed from document counts
+// (true) or token tallies (false, the default).
+func (cats *Categories) SetDocumentPriors(enabled bool) {
+	if cats.documentPriors != enabled {
+		cats.documentPriors = enabled
+		cats.probabilitiesDirty = true
+	}
+}
+
 // EnsureCategoryProbabilities recalculates priors when data has changed.
 func (cats *Categories) EnsureCategoryProbabilities() {
 	if !cats.probabilitiesDirty {
@@ -86,7 +295,14 @@ func (cats *Categories) EnsureCategoryProbabilities() {
 	probabilities := make(map[string]float64, len(cats.categories))
 
 	for name, cat := range cats.categories {
-		tally := float64(cat.GetTally())
+		tally := cat.GetTally()
+		if cats.documentPriors {
+			// A category that still holds tokens was trained at least once,
+			// even if untraining has driven its document count to zero.
+			if tally = cat.GetDocumentCount(); tally <= 0 {
+				tally = 1
+			}
+		}
 		probabilities[name] = tally
 		totalTally += tally
 	}
@@ -108,9 +324,10 @@ func (cats *Categories) Summaries() map[string]CategorySummary {
 	snapshot := make(map[string]CategorySummary, len(cats.categories))
 	for name, cat := range cats.categories {
 		snapshot[name] = CategorySummary{
-			TokenTally:   cat.GetTally(),
-			ProbNotInCat: cat.GetProbNotInCat(),
-			ProbInCat:    cat.GetProbInCat(),
+			TokenTally:    cat.GetTally(),
+			DocumentCount: cat.GetDocumentCount(),
+			ProbNotInCat:  cat.GetProbNotInCat(),
+			ProbInCat:     cat.GetProbInCat(),
 		}
 	}
 	return snapshot
@@ -125,29 +342,49 @@ func (cats *Categories) ExportStates() map[string]PersistedCategory {
 	return states
 }
 
+// TallyMatches reports whether a recorded tally equals the sum of its token
+// counts, allowing for floating-point rounding in fractional counts.
+func TallyMatches(tally, sum float64) bool {
+	return math.Abs(tally-sum) <= 1e-9*max(1, math.Abs(sum))
+}
+
 // ReplaceStates replaces all categories from a persisted state snapshot.
 func (cats *Categories) ReplaceStates(states map[string]PersistedCategory) error {
 	next := make(map[string]*Category, len(states))
+	vocabulary := make(map[string]float64)
 
 	for name, state := range states {
 		cat := NewCategory(name)
-		sum := 0
+		sum := 0.0
 		for token, count := range state.Tokens {
-			if count <= 0 {
-				return fmt.Errorf("invalid token count for %q token %q: %d", name, token, count)
+			if !validCount(count) {
+				return fmt.Errorf("invalid token count for %q token %q: %v", name, token, count)
 			}
 			cat.tokens[token] = count
+			vocabulary[token] += count
 			sum += count
 		}
 
-		if sum != state.Tally {
-			return fmt.Errorf("invalid tally for %q: tally=%d sum=%d", name, state.Tally, sum)
+		if !TallyMatches(state.Tally, sum) {
+			return fmt.Errorf("invalid tally for %q: tally=%v sum=%v", name, state.Tally, sum)
+		}
+		cat.tally = sum
+
+		if !(state.Documents >= 0) || math.IsInf(state.Documents, 0) {
+			return fmt.Errorf("invalid document count for %q: %v", name, state.Documents)
+		}
+		cat.documents = state.Documents
+		for token, documents := range state.DocumentFrequencies {
+			if !validCount(documents) || documents > cat.tokens[token]+countEpsilon || documents > cat.documents+countEpsilon {
+				return fmt.Errorf("invalid document frequency for %q token %q: %v", name, token, documents)
+			}
+			cat.documentFrequencies[token] = documents
 		}
-		cat.tally = state.Tally
 		next[name] = cat
 	}
 
 	cats.categories = next
+	cats.vocabulary = vocabulary
 	cats.probabilitiesDirty = true
 	return nil
 }
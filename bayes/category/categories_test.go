@@ -1,6 +1,10 @@
 package category
 
-import "testing"
+import (
+	"errors"
+	"math"
+	"testing"
+)
 
 // TestAddCategoryCreatesAndReturnsCategory verifies add category creates and returns category.
 func TestAddCategoryCreatesAndReturnsCategory(t *testing.T) {
@@ -82,7 +86,7 @@ func TestSummariesReturnsValueSnapshot(t *testing.T) {
 
 	real := cats.GetCategory("spam")
 	if got := real.GetTokenCount("buy"); got != 2 {
-		t.Fatalf("expected internal state unchanged by snapshot mutation: got %d, want %d", got, 2)
+		t.Fatalf("expected internal state unchanged by snapshot mutation: got %v, want %v", got, 2)
 	}
 	if _, ok := cats.Summaries()["spam"]; !ok {
 		t.Fatal("expected category to remain after snapshot map deletion")
@@ -112,10 +116,28 @@ func TestExportAndReplaceStates(t *testing.T) {
 		t.Fatal("expected spam category after restore")
 	}
 	if got := cat.GetTokenCount("buy"); got != 2 {
-		t.Fatalf("unexpected buy count: got %d want 2", got)
+		t.Fatalf("unexpected buy count: got %v want 2", got)
 	}
 	if got := cat.GetTally(); got != 3 {
-		t.Fatalf("unexpected tally: got %d want 3", got)
+		t.Fatalf("unexpected tally: got %v want 3", got)
+	}
+}
+
+// TestExportAndReplaceStatesKeepsDocuments verifies document statistics
+// survive export and replace states.
+func TestExportAndReplaceStatesKeepsDocuments(t *testing.T) {
+	original := NewCategories()
+	if err := original.TrainDocument(original.GetCategory("spam"), map[string]int{"buy": 2, "now": 1}); err != nil {
+		t.Fatalf("unexpected error training document: %v", err)
+	}
+
+	restored := NewCategories()
+	if err := restored.ReplaceStates(original.ExportStates()); err != nil {
+		t.Fatalf("replace states failed: %v", err)
+	}
+	cat, _ := restored.LookupCategory("spam")
+	if cat.GetDocumentCount() != 1 || cat.GetTokenDocumentCount("buy") != 1 {
+		t.Fatalf("unexpected document statistics: %v documents, %v with buy", cat.GetDocumentCount(), cat.GetTokenDocumentCount("buy"))
 	}
 }
 
@@ -123,7 +145,7 @@ func TestExportAndReplaceStates(t *testing.T) {
 func TestReplaceStatesRejectsInvalidState(t *testing.T) {
 	cats := NewCategories()
 	err := cats.ReplaceStates(map[string]PersistedCategory{
-		"spam": {Tokens: map[string]int{"buy": 2}, Tally: 1},
+		"spam": {Tokens: map[string]float64{"buy": 2}, Tally: 1},
 	})
 	if err == nil {
 		t.Fatal("expected error for tally mismatch")
@@ -134,13 +156,28 @@ func TestReplaceStatesRejectsInvalidState(t *testing.T) {
 func TestReplaceStatesRejectsInvalidTokenCount(t *testing.T) {
 	cats := NewCategories()
 	err := cats.ReplaceStates(map[string]PersistedCategory{
-		"spam": {Tokens: map[string]int{"buy": 0}, Tally: 0},
+		"spam": {Tokens: map[string]float64{"buy": 0}, Tally: 0},
 	})
 	if err == nil {
 		t.Fatal("expected error for invalid token count")
 	}
 }
 
+// TestReplaceStatesRejectsInvalidDocumentFrequency verifies replace states
+// rejects a token in more documents than the category or the token count allow.
+func TestReplaceStatesRejectsInvalidDocumentFrequency(t *testing.T) {
+	for _, state := range []PersistedCategory{
+		{Tokens: map[string]float64{"buy": 1}, Tally: 1, Documents: 2, DocumentFrequencies: map[string]float64{"buy": 2}},
+		{Tokens: map[string]float64{"buy": 2}, Tally: 2, Documents: 1, DocumentFrequencies: map[string]float64{"buy": 2}},
+		{Tokens: map[string]float64{"buy": 1}, Tally: 1, Documents: 1, DocumentFrequencies: map[string]float64{"buy": 0}},
+	} {
+		cats := NewCategories()
+		if err := cats.ReplaceStates(map[string]PersistedCategory{"spam": state}); err == nil {
+			t.Fatalf("expected error for invalid document frequency in %+v", state)
+		}
+	}
+}
+
 // TestEnsureCategoryProbabilitiesNoOpWhenClean verifies ensure category probabilities no op when clean.
 func TestEnsureCategoryProbabilitiesNoOpWhenClean(t *testing.T) {
 	cats := NewCategories()
@@ -181,3 +218,320 @@ func TestMarkProbabilitiesDirtyForcesRecalc(t *testing.T) {
 		t.Fatal("expected probability to change after marking dirty and recalculating")
 	}
 }
+
+// TestDocumentPriorsUseDocumentCounts verifies priors follow document counts when enabled.
+func TestDocumentPriorsUseDocumentCounts(t *testing.T) {
+	cats := NewCategories()
+	spam := cats.GetCategory("spam")
+	ham := cats.GetCategory("ham")
+	if err := cats.TrainDocument(spam, map[string]int{"buy": 6}); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	for _, doc := range []map[string]int{{"team": 1}, {"notes": 1}, {"project": 1}} {
+		if err := cats.TrainDocument(ham, doc); err != nil {
+			t.Fatalf("unexpected train error: %v", err)
+		}
+	}
+
+	cats.EnsureCategoryProbabilities()
+	if got := cats.Summaries()["spam"].ProbInCat; got != 2.0/3.0 {
+		t.Fatalf("expected token prior 2/3, got %v", got)
+	}
+
+	cats.SetDocumentPriors(true)
+	cats.EnsureCategoryProbabilities()
+	summaries := cats.Summaries()
+	if summaries["spam"].ProbInCat != 0.25 || summaries["ham"].ProbInCat != 0.75 {
+		t.Fatalf("expected document priors 0.25/0.75, got %+v", summaries)
+	}
+	if summaries["spam"].DocumentCount != 1 || summaries["ham"].DocumentCount != 3 {
+		t.Fatalf("unexpected document counts: %+v", summaries)
+	}
+
+	// Tokens trained without a document still count as one document.
+	if err := cats.TrainToken(cats.GetCategory("legacy"), "old", 1); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	cats.EnsureCategoryProbabilities()
+	if got := cats.Summaries()["legacy"].ProbInCat; got != 0.2 {
+		t.Fatalf("expected a category without documents to count as one, got %v", got)
+	}
+}
+
+// TestVocabularyTracksTrainUntrainAndDelete verifies vocabulary size follows token mutations.
+func TestVocabularyTracksTrainUntrainAndDelete(t *testing.T) {
+	cats := NewCategories()
+	spam := cats.GetCategory("spam")
+	ham := cats.GetCategory("ham")
+
+	if err := cats.TrainToken(spam, "buy", 2); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := cats.TrainToken(ham, "buy", 1); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := cats.TrainToken(ham, "team", 1); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if got := cats.VocabularySize(); got != 2 {
+		t.Fatalf("unexpected vocabulary size: got %d, want 2", got)
+	}
+
+	if err := cats.UntrainToken(spam, "buy", 1); err != nil {
+		t.Fatalf("unexpected untrain error: %v", err)
+	}
+	if err := cats.UntrainToken(ham, "buy", 5); err != nil {
+		t.Fatalf("unexpected untrain error: %v", err)
+	}
+	if got := cats.VocabularySize(); got != 2 {
+		t.Fatalf("expected buy to remain while spam holds it: got %v", got)
+	}
+
+	cats.DeleteCategory("spam")
+	if got := cats.VocabularySize(); got != 1 {
+		t.Fatalf("unexpected vocabulary size after delete: got %d, want 1", got)
+	}
+
+	if err := cats.TrainToken(ham, "team", 0); err == nil {
+		t.Fatal("expected error for invalid train count")
+	}
+	if err := cats.UntrainToken(ham, "team", 0); err == nil {
+		t.Fatal("expected error for invalid untrain count")
+	}
+
+	if err := cats.ReplaceStates(map[string]PersistedCategory{
+		"a": {Tokens: map[string]float64{"x": 1, "y": 1}, Tally: 2},
+		"b": {Tokens: map[string]float64{"y": 2, "z": 1}, Tally: 3},
+	}); err != nil {
+		t.Fatalf("unexpected replace error: %v", err)
+	}
+	if got := cats.VocabularySize(); got != 3 {
+		t.Fatalf("unexpected vocabulary size after replace: got %d, want 3", got)
+	}
+}
+
+// TestTrainTokenMarksProbabilitiesDirty verifies collection-level training refreshes priors.
+func TestTrainTokenMarksProbabilitiesDirty(t *testing.T) {
+	cats := NewCategories()
+	spam := cats.GetCategory("spam")
+	ham := cats.GetCategory("ham")
+	_ = cats.TrainToken(spam, "buy", 1)
+	_ = cats.TrainToken(ham, "team", 1)
+	cats.EnsureCategoryProbabilities()
+
+	_ = cats.TrainToken(spam, "now", 2)
+	cats.EnsureCategoryProbabilities()
+	if got := cats.Summaries()["spam"].ProbInCat; got != 0.75 {
+		t.Fatalf("unexpected spam prior: got %f, want 0.75", got)
+	}
+}
+
+// TestTokenTotalsAndIterators verifies vocabulary totals and token iteration.
+func TestTokenTotalsAndIterators(t *testing.T) {
+	cats := NewCategories()
+	spam := cats.GetCategory("spam")
+	ham := cats.GetCategory("ham")
+	_ = cats.TrainToken(spam, "buy", 3)
+	_ = cats.TrainToken(ham, "buy", 2)
+	_ = cats.TrainToken(ham, "team", 1)
+	_ = cats.UntrainToken(spam, "buy", 1)
+
+	if got := cats.TokenTotal("buy"); got != 4 {
+		t.Fatalf("unexpected buy total: got %v, want 4", got)
+	}
+
+	totals := map[string]float64{}
+	for token, total := range cats.Vocabulary() {
+		totals[token] = total
+	}
+	if len(totals) != 2 || totals["team"] != 1 {
+		t.Fatalf("unexpected vocabulary: %v", totals)
+	}
+
+	tokens := map[string]float64{}
+	for token, count := range ham.Tokens() {
+		tokens[token] = count
+	}
+	if len(tokens) != 2 || tokens["buy"] != 2 {
+		t.Fatalf("unexpected ham tokens: %v", tokens)
+	}
+
+	// Early termination must be honored by both iterators.
+	for range cats.Vocabulary() {
+		break
+	}
+	for range ham.Tokens() {
+		break
+	}
+}
+
+// TestReplaceStatesRejectsInvalidDocumentStats verifies document statistics are validated.
+func TestReplaceStatesRejectsInvalidDocumentStats(t *testing.T) {
+	cats := NewCategories()
+	for _, state := range []PersistedCategory{
+		{Tokens: map[string]float64{"x": 1}, Tally: 1, Documents: -1},
+		{Tokens: map[string]float64{"x": 1}, Tally: 1, Documents: math.NaN()},
+		{Tokens: map[string]float64{"x": 1}, Tally: 1, Documents: math.Inf(1)},
+		{Tokens: map[string]float64{"x": 1}, Tally: 1, Documents: 1, DocumentFrequencies: map[string]float64{"x": 2}},
+		{Tokens: map[string]float64{"x": 1}, Tally: 1, Documents: 1, DocumentFrequencies: map[string]float64{"y": 1}},
+		{Tokens: map[string]float64{"x": 3}, Tally: 3, Documents: 1, DocumentFrequencies: map[string]float64{"x": 2}},
+	} {
+		if err := cats.ReplaceStates(map[string]PersistedCategory{"a": state}); err == nil {
+			t.Fatalf("expected error for state %+v", state)
+		}
+	}
+}
+
+// TestExportStatesCapsDocumentFrequencies verifies exported document
+// frequencies never exceed the category's document count.
+func TestExportStatesCapsDocumentFrequencies(t *testing.T) {
+	cats := NewCategories()
+	spam := cats.GetCategory("spam")
+	_ = cats.TrainDocument(spam, map[string]int{"buy": 1, "now": 1})
+	_ = cats.TrainDocument(spam, map[string]int{"buy": 1})
+	// Untraining documents that were never trained lowers the document count
+	// but not the frequency of tokens they did not contain.
+	_ = cats.UntrainDocument(spam, map[string]int{"later": 1})
+	_ = cats.UntrainDocument(spam, map[string]int{"later": 1})
+
+	state := cats.ExportStates()["spam"]
+	if state.Documents != 0 || len(state.DocumentFrequencies) != 0 {
+		t.Fatalf("expected frequencies capped at the document count, got %+v", state)
+	}
+	if err := NewCategories().ReplaceStates(map[string]PersistedCategory{"spam": state}); err != nil {
+		t.Fatalf("expected the exported state to load, got %v", err)
+	}
+}
+
+// TestDecayScalesCounts verifies Decay scales counts, statistics, and vocabulary totals.
+func TestDecayScalesCounts(t *testing.T) {
+	cats := NewCategories()
+	spam := cats.GetCategory("spam")
+	_ = cats.TrainDocument(spam, map[string]int{"buy": 4, "now": 2})
+	_ = cats.TrainDocument(spam, map[string]int{"buy": 2})
+
+	for _, factor := range []float64{0, -1, 2, math.NaN()} {
+		if err := cats.Decay(factor); !errors.Is(err, ErrInvalidDecayFactor) {
+			t.Fatalf("Decay(%v): expected ErrInvalidDecayFactor, got %v", factor, err)
+		}
+	}
+
+	if err := cats.Decay(1); err != nil || spam.GetTokenCount("buy") != 6 {
+		t.Fatalf("expected a factor of one to change nothing, got %v", err)
+	}
+	if err := cats.Decay(0.5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spam.GetTokenCount("buy") != 3 || spam.GetTally() != 4 || spam.GetDocumentCount() != 1 || spam.GetTokenDocumentCount("buy") != 1 {
+		t.Fatalf("unexpected decayed counts: buy=%v tally=%v docs=%v df=%v", spam.GetTokenCount("buy"), spam.GetTally(), spam.GetDocumentCount(), spam.GetTokenDocumentCount("buy"))
+	}
+	if cats.TokenTotal("now") != 1 {
+		t.Fatalf("unexpected vocabulary total: %v", cats.TokenTotal("now"))
+	}
+
+	// Counts that decay to nothing are dropped.
+	_ = cats.Decay(1e-12)
+	if cats.VocabularySize() != 0 || spam.GetTally() != 0 {
+		t.Fatalf("expected negligible tokens to be dropped, got vocabulary=%d tally=%v", cats.VocabularySize(), spam.GetTally())
+	}
+}
+
+// TestWeightedDocumentsScaleCounts verifies weighted documents scale token
+// counts, document statistics, and the vocabulary, and untrain cleanly.
+func TestWeightedDocumentsScaleCounts(t *testing.T) {
+	cats := NewCategories()
+	spam := cats.GetCategory("spam")
+	if err := cats.TrainWeightedDocument(spam, map[string]int{"buy": 2, "now": 1}, 2.5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spam.GetTokenCount("buy") != 5 || spam.GetTally() != 7.5 || spam.GetDocumentCount() != 2.5 || spam.GetTokenDocumentCount("buy") != 2.5 {
+		t.Fatalf("unexpected weighted counts: buy=%v tally=%v docs=%v df=%v", spam.GetTokenCount("buy"), spam.GetTally(), spam.GetDocumentCount(), spam.GetTokenDocumentCount("buy"))
+	}
+	if cats.TokenTotal("now") != 2.5 {
+		t.Fatalf("unexpected vocabulary total: %v", cats.TokenTotal("now"))
+	}
+
+	for _, weight := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if err := cats.TrainWeightedDocument(spam, map[string]int{"buy": 1}, weight); !errors.Is(err, ErrInvalidTokenCount) {
+			t.Fatalf("TrainWeightedDocument(%v): expected ErrInvalidTokenCount, got %v", weight, err)
+		}
+	}
+
+	if err := cats.UntrainWeightedDocument(spam, map[string]int{"buy": 2, "now": 1}, 2.5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spam.GetTally() != 0 || spam.GetDocumentCount() != 0 || cats.VocabularySize() != 0 {
+		t.Fatalf("expected weighted untrain to reverse training, got tally=%v docs=%v vocabulary=%d", spam.GetTally(), spam.GetDocumentCount(), cats.VocabularySize())
+	}
+}
+
+// TestRenameCategoryKeepsCounts verifies rename category moves counts to the new name.
+func TestRenameCategoryKeepsCounts(t *testing.T) {
+	cats := NewCategories()
+	_ = cats.TrainDocument(cats.GetCategory("spam"), map[string]int{"buy": 2})
+	cats.AddCategory("ham")
+	cats.EnsureCategoryProbabilities()
+
+	if err := cats.RenameCategory("spam", "junk"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cats.LookupCategory("spam"); ok {
+		t.Fatal("expected spam to be gone")
+	}
+	junk, ok := cats.LookupCategory("junk")
+	if !ok || junk.Name() != "junk" || junk.GetTokenCount("buy") != 2 || junk.GetDocumentCount() != 1 {
+		t.Fatalf("unexpected renamed category: %+v", junk)
+	}
+	if !cats.probabilitiesDirty {
+		t.Fatal("expected rename to mark probabilities dirty")
+	}
+
+	if err := cats.RenameCategory("missing", "other"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+	if err := cats.RenameCategory("junk", "ham"); !errors.Is(err, ErrCategoryExists) {
+		t.Fatalf("expected ErrCategoryExists, got %v", err)
+	}
+	if err := cats.RenameCategory("junk", "junk"); err != nil {
+		t.Fatalf("expected renaming a category to itself to succeed, got %v", err)
+	}
+}
+
+// TestMergeCategoriesAddsCounts verifies merge categories sums counts into the destination.
+func TestMergeCategoriesAddsCounts(t *testing.T) {
+	cats := NewCategories()
+	_ = cats.TrainDocument(cats.GetCategory("spam"), map[string]int{"buy": 2, "now": 1})
+	_ = cats.TrainDocument(cats.GetCategory("phish"), map[string]int{"buy": 1, "login": 1})
+	_ = cats.TrainDocument(cats.GetCategory("ham"), map[string]int{"hello": 1})
+
+	if err := cats.MergeCategories("spam", "phish", "missing"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+	if _, ok := cats.LookupCategory("phish"); !ok {
+		t.Fatal("expected a failed merge to leave sources in place")
+	}
+
+	if err := cats.MergeCategories("junk", "spam", "phish", "phish"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := cats.Names(); len(names) != 2 {
+		t.Fatalf("expected junk and ham to remain, got %v", names)
+	}
+	junk, _ := cats.LookupCategory("junk")
+	if junk.GetTokenCount("buy") != 3 || junk.GetTally() != 5 || junk.GetDocumentCount() != 2 || junk.GetTokenDocumentCount("buy") != 2 {
+		t.Fatalf("unexpected merged counts: buy=%v tally=%v docs=%v df=%v", junk.GetTokenCount("buy"), junk.GetTally(), junk.GetDocumentCount(), junk.GetTokenDocumentCount("buy"))
+	}
+	if cats.TokenTotal("buy") != 3 || cats.VocabularySize() != 4 {
+		t.Fatalf("expected vocabulary to be unchanged, got buy=%v size=%d", cats.TokenTotal("buy"), cats.VocabularySize())
+	}
+
+	if err := cats.MergeCategories("ham", "ham"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cats.MergeCategories("ham"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ham, _ := cats.LookupCategory("ham"); ham.GetTally() != 1 {
+		t.Fatalf("expected merging a category into itself to be a no-op, got tally %v", ham.GetTally())
+	}
+}
@@ -1,39 +1,68 @@
 package category
 
-import "errors"
+import (
+	"errors"
+	"iter"
+	"math"
+)
 
-// ErrInvalidTokenCount indicates token mutation was requested with a non-positive count.
+// ErrInvalidTokenCount indicates token mutation was requested with a non-positive or non-finite count.
 var ErrInvalidTokenCount = errors.New("count must be greater than zero")
 
+// ErrInvalidDecayFactor indicates a decay factor outside (0, 1].
+var ErrInvalidDecayFactor = errors.New("decay factor must be greater than zero and at most one")
+
+// ErrCategoryNotFound indicates an operation on a category that does not exist.
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrCategoryExists indicates a rename onto a category that already exists.
+var ErrCategoryExists = errors.New("category already exists")
+
+// countEpsilon is the magnitude at or below which a count is treated as zero,
+// so floating-point residue from decay and untraining leaves no phantom tokens.
+const countEpsilon = 1e-9
+
 // Category stores token and probability data for one classification category.
+// Counts are fractional so they can be decayed over time; without decay they
+// stay whole numbers.
 type Category struct {
-	name         string         // Name of this category
-	tokens       map[string]int // Map of tokens to their count
-	tally        int            // Total tokens in this category
-	probNotInCat float64        // Probability that an arbitrary token is not in this category
-	probInCat    float64        // Probability that an arbitrary token is in this category
+	name                string             // Name of this category
+	tokens              map[string]float64 // Map of tokens to their count
+	tally               float64            // Total tokens in this category
+	documents           float64            // Number of documents (samples) trained into this category
+	documentFrequencies map[string]float64 // Map of tokens to the number of documents containing them
+	probNotInCat        float64            // Probability that an arbitrary token is not in this category
+	probInCat           float64            // Probability that an arbitrary token is in this category
 }
 
 // PersistedCategory is a serializable representation of Category data.
 type PersistedCategory struct {
-	Tokens map[string]int
-	Tally  int
+	Tokens              map[string]float64
+	Tally               float64
+	Documents           float64
+	DocumentFrequencies map[string]float64
 }
 
 // NewCategory returns a new Category with initialized token storage.
 func NewCategory(name string) *Category {
 	return &Category{
-		name:         name,
-		tokens:       make(map[string]int),
-		tally:        0,
-		probNotInCat: 0.0,
-		probInCat:    0.0,
+		name:                name,
+		tokens:              make(map[string]float64),
+		tally:               0,
+		documentFrequencies: make(map[string]float64),
+		probNotInCat:        0.0,
+		probInCat:           0.0,
 	}
 }
 
+// validCount reports whether count is a positive, finite token count.
+func validCount(count float64) bool {
+	return count > 0 && !math.IsInf(count, 0)
+}
+
 // TrainToken adds count occurrences of word to the category.
-func (cat *Category) TrainToken(word string, count int) error {
-	if count <= 0 {
+func (cat *Category) TrainToken(word string, count float64) error {
+	if !validCount(count) {
 		return ErrInvalidTokenCount
 	}
 
@@ -44,8 +73,8 @@ func (cat *Category) TrainToken(word string, count int) error {
 }
 
 // UntrainToken removes count occurrences of word from the category.
-func (cat *Category) UntrainToken(word string, count int) error {
-	if count <= 0 {
+func (cat *Category) UntrainToken(word string, count float64) error {
+	if !validCount(count) {
 		return ErrInvalidTokenCount
 	}
 
@@ -53,32 +82,136 @@ func (cat *Category) UntrainToken(word string, count int) error {
 
 	if keyExists {
 		// if we're removing equal or more counts than we have, we kill the token
-		if count >= curCount {
+		if count >= curCount-countEpsilon {
 			cat.tally -= cat.tokens[word]
 			delete(cat.tokens, word)
+			delete(cat.documentFrequencies, word)
+			if len(cat.tokens) == 0 {
+				cat.tally = 0
+			}
 		} else {
 			cat.tokens[word] -= count
 			cat.tally -= count
+			// A token cannot appear in more documents than it has occurrences.
+			if cat.documentFrequencies[word] > cat.tokens[word] {
+				cat.documentFrequencies[word] = cat.tokens[word]
+			}
 		}
 	}
 	return nil
 }
 
+// addDocument records one trained document containing the given tokens,
+// counted weight times.
+func (cat *Category) addDocument(tokens map[string]int, weight float64) {
+	cat.documents += weight
+	for token := range tokens {
+		if cat.tokens[token] > 0 {
+			cat.documentFrequencies[token] += weight
+		}
+	}
+}
+
+// removeDocument forgets one untrained document containing the given tokens,
+// counted weight times.
+func (cat *Category) removeDocument(tokens map[string]int, weight float64) {
+	cat.documents = max(cat.documents-weight, 0)
+	for token := range tokens {
+		if cat.documentFrequencies[token] <= weight+countEpsilon {
+			delete(cat.documentFrequencies, token)
+			continue
+		}
+		cat.documentFrequencies[token] -= weight
+	}
+}
+
+// absorb adds every count of other to the category.
+func (cat *Category) absorb(other *Category) {
+	for token, count := range other.tokens {
+		cat.tokens[token] += count
+		cat.tally += count
+	}
+	for token, documents := range other.documentFrequencies {
+		cat.documentFrequencies[token] += documents
+	}
+	cat.documents += other.documents
+}
+
+// scale multiplies every count by factor, dropping tokens whose count falls
+// to countEpsilon or below.
+func (cat *Category) scale(factor float64) {
+	cat.tally = 0
+	for token, count := range cat.tokens {
+		count *= factor
+		if count <= countEpsilon {
+			delete(cat.tokens, token)
+			delete(cat.documentFrequencies, token)
+			continue
+		}
+		cat.tokens[token] = count
+		cat.tally += count
+	}
+	for token, documents := range cat.documentFrequencies {
+		cat.documentFrequencies[token] = documents * factor
+	}
+	cat.documents *= factor
+}
+
 // Name returns the category name.
 func (cat Category) Name() string {
 	return cat.name
 }
 
 // GetTokenCount returns the number of times word appears in the category.
-func (cat Category) GetTokenCount(word string) int {
+func (cat Category) GetTokenCount(word string) float64 {
 	if val, ok := cat.tokens[word]; ok {
 		return val
 	}
 	return 0
 }
 
+// Tokens iterates over the category's tokens and their counts. The category
+// must not be modified during iteration.
+func (cat *Category) Tokens() iter.Seq2[string, float64] {
+	return func(yield func(string, float64) bool) {
+		for token, count := range cat.tokens {
+			if !yield(token, count) {
+				return
+			}
+		}
+	}
+}
+
+// VocabularySize returns the number of distinct tokens in the category.
+func (cat Category) VocabularySize() int {
+	return len(cat.tokens)
+}
+
+// TokenDocumentCounts iterates over tokens and the number of documents in
+// this category that contained them. The category must not be modified during
+// iteration.
+func (cat *Category) TokenDocumentCounts() iter.Seq2[string, float64] {
+	return func(yield func(string, float64) bool) {
+		for token, documents := range cat.documentFrequencies {
+			if !yield(token, documents) {
+				return
+			}
+		}
+	}
+}
+
+// GetTokenDocumentCount returns the number of documents in the category that contained word.
+func (cat Category) GetTokenDocumentCount(word string) float64 {
+	return cat.documentFrequencies[word]
+}
+
+// GetDocumentCount returns the number of documents trained into this category.
+func (cat Category) GetDocumentCount() float64 {
+	return cat.documents
+}
+
 // GetTally returns the total trained token count for this category.
-func (cat Category) GetTally() int {
+func (cat Category) GetTally() float64 {
 	return cat.tally
 }
 
@@ -100,13 +233,24 @@ func (cat *Category) setProbabilities(probInCat float64, probNotInCat float64) {
 
 // exportState returns a copy of category state for persistence.
 func (cat *Category) exportState() PersistedCategory {
-	tokens := make(map[string]int, len(cat.tokens))
+	tokens := make(map[string]float64, len(cat.tokens))
 	for token, count := range cat.tokens {
 		tokens[token] = count
 	}
+	// Untraining documents that were never trained lowers the document
+	// count without touching other tokens' frequencies; no token can have
+	// been in more documents than the category has.
+	documentFrequencies := make(map[string]float64, len(cat.documentFrequencies))
+	for token, documents := range cat.documentFrequencies {
+		if documents = min(documents, cat.documents); documents > 0 {
+			documentFrequencies[token] = documents
+		}
+	}
 
 	return PersistedCategory{
-		Tokens: tokens,
-		Tally:  cat.tally,
+		Tokens:              tokens,
+		Tally:               cat.tally,
+		Documents:           cat.documents,
+		DocumentFrequencies: documentFrequencies,
 	}
 }
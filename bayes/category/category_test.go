@@ -17,13 +17,16 @@ func TestTrainTokenCreatesAndIncrements(t *testing.T) {
 	}
 
 	if got := cat.GetTokenCount("buy"); got != 5 {
-		t.Fatalf("unexpected buy count: got %d, want %d", got, 5)
+		t.Fatalf("unexpected buy count: got %v, want %v", got, 5)
 	}
 	if got := cat.GetTokenCount("now"); got != 1 {
-		t.Fatalf("unexpected now count: got %d, want %d", got, 1)
+		t.Fatalf("unexpected now count: got %v, want %v", got, 1)
 	}
 	if got := cat.GetTally(); got != 6 {
-		t.Fatalf("unexpected tally: got %d, want %d", got, 6)
+		t.Fatalf("unexpected tally: got %v, want %v", got, 6)
+	}
+	if got := cat.VocabularySize(); got != 2 {
+		t.Fatalf("unexpected vocabulary size: got %v, want %v", got, 2)
 	}
 }
 
@@ -41,20 +44,20 @@ func TestUntrainTokenDecrementsAndDeletes(t *testing.T) {
 		t.Fatalf("unexpected error untraining token: %v", err)
 	}
 	if got := cat.GetTokenCount("buy"); got != 3 {
-		t.Fatalf("unexpected buy count after partial untrain: got %d, want %d", got, 3)
+		t.Fatalf("unexpected buy count after partial untrain: got %v, want %v", got, 3)
 	}
 	if got := cat.GetTally(); got != 4 {
-		t.Fatalf("unexpected tally after partial untrain: got %d, want %d", got, 4)
+		t.Fatalf("unexpected tally after partial untrain: got %v, want %v", got, 4)
 	}
 
 	if err := cat.UntrainToken("buy", 3); err != nil {
 		t.Fatalf("unexpected error untraining token: %v", err)
 	}
 	if got := cat.GetTokenCount("buy"); got != 0 {
-		t.Fatalf("expected buy token to be removed, got count %d", got)
+		t.Fatalf("expected buy token to be removed, got count %v", got)
 	}
 	if got := cat.GetTally(); got != 1 {
-		t.Fatalf("unexpected tally after removing buy token: got %d, want %d", got, 1)
+		t.Fatalf("unexpected tally after removing buy token: got %v, want %v", got, 1)
 	}
 }
 
@@ -70,10 +73,10 @@ func TestUntrainTokenNoOpForMissingToken(t *testing.T) {
 	}
 
 	if got := cat.GetTokenCount("hello"); got != 2 {
-		t.Fatalf("existing token should remain unchanged: got %d, want %d", got, 2)
+		t.Fatalf("existing token should remain unchanged: got %v, want %v", got, 2)
 	}
 	if got := cat.GetTally(); got != 2 {
-		t.Fatalf("tally should remain unchanged: got %d, want %d", got, 2)
+		t.Fatalf("tally should remain unchanged: got %v, want %v", got, 2)
 	}
 }
 
@@ -98,9 +101,75 @@ func TestInvalidCountsReturnError(t *testing.T) {
 	}
 
 	if got := cat.GetTokenCount("hello"); got != 2 {
-		t.Fatalf("expected count unchanged after invalid operations: got %d, want %d", got, 2)
+		t.Fatalf("expected count unchanged after invalid operations: got %v, want %v", got, 2)
 	}
 	if got := cat.GetTally(); got != 2 {
-		t.Fatalf("expected tally unchanged after invalid operations: got %d, want %d", got, 2)
+		t.Fatalf("expected tally unchanged after invalid operations: got %v, want %v", got, 2)
+	}
+}
+
+// TestDocumentStatistics verifies document counts and token document frequencies.
+func TestDocumentStatistics(t *testing.T) {
+	cats := NewCategories()
+	cat := cats.GetCategory("spam")
+
+	if err := cats.TrainDocument(cat, map[string]int{"free": 3, "prize": 1}); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := cats.TrainDocument(cat, map[string]int{"free": 1}); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if cat.GetDocumentCount() != 2 || cat.GetTokenDocumentCount("free") != 2 || cat.GetTokenDocumentCount("prize") != 1 {
+		t.Fatalf("unexpected document stats: docs=%v free=%v prize=%v", cat.GetDocumentCount(), cat.GetTokenDocumentCount("free"), cat.GetTokenDocumentCount("prize"))
+	}
+
+	if err := cats.UntrainDocument(cat, map[string]int{"free": 3, "prize": 1}); err != nil {
+		t.Fatalf("unexpected untrain error: %v", err)
+	}
+	if cat.GetDocumentCount() != 1 || cat.GetTokenDocumentCount("free") != 1 || cat.GetTokenDocumentCount("prize") != 0 {
+		t.Fatalf("unexpected document stats after untrain: docs=%v free=%v prize=%v", cat.GetDocumentCount(), cat.GetTokenDocumentCount("free"), cat.GetTokenDocumentCount("prize"))
+	}
+
+	frequencies := map[string]float64{}
+	for token, documents := range cat.TokenDocumentCounts() {
+		frequencies[token] = documents
+	}
+	if len(frequencies) != 1 || frequencies["free"] != 1 {
+		t.Fatalf("unexpected frequencies: %v", frequencies)
+	}
+	for range cat.TokenDocumentCounts() {
+		break
+	}
+
+	// Untraining more documents than exist never goes negative.
+	_ = cats.UntrainDocument(cat, map[string]int{"free": 1})
+	_ = cats.UntrainDocument(cat, map[string]int{"other": 1})
+	if cat.GetDocumentCount() != 0 || cat.GetTokenDocumentCount("free") != 0 {
+		t.Fatalf("expected document stats to bottom out at zero: docs=%v free=%v", cat.GetDocumentCount(), cat.GetTokenDocumentCount("free"))
+	}
+
+	if err := cats.TrainDocument(cat, map[string]int{"bad": 0}); err == nil {
+		t.Fatal("expected error for invalid train count")
+	}
+	if err := cats.UntrainDocument(cat, map[string]int{"bad": 0}); err == nil {
+		t.Fatal("expected error for invalid untrain count")
+	}
+}
+
+// TestDocumentFrequencyCappedByTokenCount verifies partial untraining keeps frequencies consistent.
+func TestDocumentFrequencyCappedByTokenCount(t *testing.T) {
+	cat := NewCategory("spam")
+	_ = cat.TrainToken("free", 2)
+	cat.addDocument(map[string]int{"free": 1}, 1)
+	_ = cat.TrainToken("free", 1)
+	cat.addDocument(map[string]int{"free": 1}, 1)
+
+	_ = cat.UntrainToken("free", 2)
+	if got := cat.GetTokenDocumentCount("free"); got != 1 {
+		t.Fatalf("expected document frequency capped at token count 1, got %v", got)
+	}
+	state := cat.exportState()
+	if state.Documents != 2 || state.DocumentFrequencies["free"] != 1 {
+		t.Fatalf("unexpected exported document stats: %+v", state)
 	}
 }
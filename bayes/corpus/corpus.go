@@ -0,0 +1,244 @@
+// Package corpus trains classifiers from labeled corpora: directory trees
+// whose subdirectory names are categories, CSV files, and NDJSON streams.
+// Samples are read and trained one at a time, so memory use does not grow
+// with the size of the corpus.
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// MaxRejects is the number of rejected samples listed in a Report.
+const MaxRejects = 100
+
+// MaxLineBytes is the longest NDJSON line ImportNDJSON accepts.
+const MaxLineBytes = 16 << 20 // 16 MiB
+
+// ErrInvalidCSV indicates a CSV corpus without the configured columns.
+var ErrInvalidCSV = errors.New("invalid CSV corpus")
+
+var (
+	readFile = os.ReadFile
+	walkDir  = filepath.WalkDir
+)
+
+// Reject describes a sample that was not imported.
+type Reject struct {
+	// Source is the file path, relative to the imported directory, or
+	// "line N" for CSV and NDJSON input.
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// Report summarizes an import.
+type Report struct {
+	Imported int `json:"imported"`
+	Rejected int `json:"rejected"`
+	// Categories counts imported samples per category.
+	Categories map[string]int `json:"categories"`
+	// Rejects lists the first MaxRejects rejected samples; Rejected is the
+	// full number.
+	Rejects []Reject `json:"rejects,omitempty"`
+}
+
+// CSVOptions configures ImportCSV.
+type CSVOptions struct {
+	// LabelColumn and TextColumn name the header columns holding each row's
+	// category and text. Empty means "category" and "text".
+	LabelColumn string
+	TextColumn  string
+	// Comma is the field delimiter. Zero means ','.
+	Comma rune
+}
+
+// importer trains samples into a classifier and records the outcome.
+type importer struct {
+	classifier *bayes.Classifier
+	format     bayes.InputFormat
+	report     Report
+}
+
+// newImporter returns an importer that trains c with input in format.
+func newImporter(c *bayes.Classifier, format bayes.InputFormat) *importer {
+	return &importer{
+		classifier: c,
+		format:     format,
+		report:     Report{Categories: make(map[string]int)},
+	}
+}
+
+// add trains one sample, or rejects it when its category is invalid, its
+// text is blank, or it cannot be parsed in the importer's format.
+func (im *importer) add(source, category, text string) {
+	if !bayes.ValidCategoryName(category) {
+		im.reject(source, fmt.Sprintf("invalid category %q", category))
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		im.reject(source, "empty text")
+		return
+	}
+	if err := im.classifier.TrainInput(category, im.format, text); err != nil {
+		im.reject(source, err.Error())
+		return
+	}
+	im.report.Imported++
+	im.report.Categories[category]++
+}
+
+// reject records a sample that was not imported.
+func (im *importer) reject(source, reason string) {
+	im.report.Rejected++
+	if len(im.report.Rejects) < MaxRejects {
+		im.report.Rejects = append(im.report.Rejects, Reject{Source: source, Reason: reason})
+	}
+}
+
+// ImportDir trains c with every file below root, one sample per file in
+// format (empty means bayes.InputText). A file's category is the name of the
+// subdirectory of root it is in, so root/spam/2024/1.eml is spam; files
+// directly in root and files that cannot be read are rejected. Files and
+// directories whose names start with "." are skipped.
+func ImportDir(c *bayes.Classifier, root string, format bayes.InputFormat) (Report, error) {
+	if _, err := bayes.ParseInputFormat(string(format)); err != nil {
+		return Report{}, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return Report{}, err
+	}
+	if !info.IsDir() {
+		return Report{}, fmt.Errorf("%s is not a directory", root)
+	}
+
+	im := newImporter(c, format)
+	err = walkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		// path is below root, so Rel cannot fail.
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		category, _, nested := strings.Cut(rel, "/")
+		if !nested {
+			im.reject(rel, "not in a category directory")
+			return nil
+		}
+		text, err := readFile(path)
+		if err != nil {
+			// The source already names the file; drop the absolute path.
+			if pathErr := (*fs.PathError)(nil); errors.As(err, &pathErr) {
+				err = pathErr.Err
+			}
+			im.reject(rel, err.Error())
+			return nil
+		}
+		im.add(rel, category, string(text))
+		return nil
+	})
+	return im.report, err
+}
+
+// ImportCSV trains c with every row of a CSV stream that has a header row.
+// Rows missing the label or text column are rejected, as are rows that are
+// not valid CSV; reading continues with the next row.
+func ImportCSV(c *bayes.Classifier, r io.Reader, opts CSVOptions) (Report, error) {
+	labelColumn := cmp.Or(opts.LabelColumn, "category")
+	textColumn := cmp.Or(opts.TextColumn, "text")
+
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return Report{}, fmt.Errorf("%w: read header: %v", ErrInvalidCSV, err)
+	}
+	// Spreadsheet exports often start with a byte order mark.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	labelIndex := slices.Index(header, labelColumn)
+	textIndex := slices.Index(header, textColumn)
+	if labelIndex < 0 || textIndex < 0 {
+		return Report{}, fmt.Errorf("%w: header must have %q and %q columns", ErrInvalidCSV, labelColumn, textColumn)
+	}
+
+	im := newImporter(c, bayes.InputText)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return im.report, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			im.reject(fmt.Sprintf("line %d", parseErr.StartLine), parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return im.report, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		source := fmt.Sprintf("line %d", line)
+		if labelIndex >= len(record) || textIndex >= len(record) {
+			im.reject(source, "missing columns")
+			continue
+		}
+		im.add(source, strings.TrimSpace(record[labelIndex]), record[textIndex])
+	}
+}
+
+// ImportNDJSON trains c with every {"category", "text"} object in an NDJSON
+// stream. Blank lines are skipped, and lines that are not such an object are
+// rejected. Lines longer than MaxLineBytes end the import with an error.
+func ImportNDJSON(c *bayes.Classifier, r io.Reader) (Report, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, MaxLineBytes)
+
+	im := newImporter(c, bayes.InputText)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var sample bayes.Sample
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&sample); err != nil || dec.More() {
+			im.reject(fmt.Sprintf("line %d", line), "invalid JSON sample")
+			continue
+		}
+		im.add(fmt.Sprintf("line %d", line), sample.Category, sample.Text)
+	}
+	return im.report, scanner.Err()
+}
@@ -0,0 +1,211 @@
+package corpus
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// TestImportDir verifies subdirectory names become categories and that
+// misplaced, hidden, and invalid files are handled.
+func TestImportDir(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"spam/1.txt":         "cheap pills",
+		"spam/2024/2.txt":    "buy pills now",
+		"ham/1.txt":          "team meeting",
+		"ham/empty.txt":      "  \n",
+		"bad name/1.txt":     "hello",
+		"README.txt":         "not a sample",
+		".git/config":        "skipped",
+		"ham/.DS_Store":      "skipped",
+		"spam/message.eml":   "From: x@deals.example\r\nSubject: Pills\r\n\r\ncheap",
+		"ham/broken-message": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := bayes.NewClassifierWithTokenizer(strings.Fields)
+	report, err := ImportDir(c, root, bayes.InputText)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Imported != 4 || !reflect.DeepEqual(report.Categories, map[string]int{"spam": 3, "ham": 1}) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	wantRejects := []Reject{
+		{Source: "README.txt", Reason: "not in a category directory"},
+		{Source: "bad name/1.txt", Reason: `invalid category "bad name"`},
+		{Source: "ham/broken-message", Reason: "empty text"},
+		{Source: "ham/empty.txt", Reason: "empty text"},
+	}
+	if report.Rejected != 4 || !reflect.DeepEqual(report.Rejects, wantRejects) {
+		t.Fatalf("unexpected rejects: %+v", report.Rejects)
+	}
+	if got := c.Summaries()["spam"].DocumentCount; got != 3 {
+		t.Fatalf("unexpected spam documents: %v", got)
+	}
+
+	email := bayes.NewClassifierWithTokenizer(strings.Fields)
+	report, err = ImportDir(email, root, bayes.InputEmail)
+	if err != nil || report.Imported != 1 || report.Rejects[2].Reason != `invalid input: invalid email message: malformed header line: "team meeting"` {
+		t.Fatalf("unexpected email import: %+v %v", report, err)
+	}
+	if _, ok := email.Score("subject:Pills")["spam"]; !ok {
+		t.Fatal("expected email headers to be tokenized")
+	}
+
+	if _, err := ImportDir(c, filepath.Join(root, "README.txt"), bayes.InputText); err == nil {
+		t.Fatal("expected error for a file root")
+	}
+	if _, err := ImportDir(c, root, "pdf"); !errors.Is(err, bayes.ErrInvalidInputFormat) {
+		t.Fatalf("expected ErrInvalidInputFormat, got %v", err)
+	}
+}
+
+// TestImportDirRejectsUnreadableFiles verifies a file that cannot be read is
+// rejected without stopping the import.
+func TestImportDirRejectsUnreadableFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"spam/1.txt", "spam/2.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("cheap pills"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	origReadFile := readFile
+	defer func() { readFile = origReadFile }()
+	readFile = func(path string) ([]byte, error) {
+		if filepath.Base(path) == "1.txt" {
+			return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission}
+		}
+		return origReadFile(path)
+	}
+
+	c := bayes.NewClassifierWithTokenizer(strings.Fields)
+	report, err := ImportDir(c, root, bayes.InputText)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Reject{{Source: "spam/1.txt", Reason: "permission denied"}}
+	if report.Imported != 1 || report.Rejected != 1 || !reflect.DeepEqual(report.Rejects, want) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+// TestImportDirErrors verifies a missing root and a failed directory walk are
+// reported as errors.
+func TestImportDirErrors(t *testing.T) {
+	c := bayes.NewClassifierWithTokenizer(strings.Fields)
+	root := t.TempDir()
+	if _, err := ImportDir(c, filepath.Join(root, "missing"), bayes.InputText); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+
+	origWalkDir := walkDir
+	defer func() { walkDir = origWalkDir }()
+	walkDir = func(root string, fn fs.WalkDirFunc) error {
+		return fn(root, nil, fs.ErrPermission)
+	}
+	if _, err := ImportDir(c, root, bayes.InputText); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("expected ErrPermission, got %v", err)
+	}
+}
+
+// TestImportCSV verifies configurable columns and per-row rejects.
+func TestImportCSV(t *testing.T) {
+	input := "\ufeffid;label ;body\n" +
+		"1;spam;cheap pills\n" +
+		"2;ham;\"team; meeting\"\n" +
+		"3;not valid;hello\n" +
+		"4;spam\n" +
+		"5;ham;bad \"quote\n" +
+		"6;ham;lunch\n"
+	c := bayes.NewClassifierWithTokenizer(strings.Fields)
+	report, err := ImportCSV(c, strings.NewReader(input), CSVOptions{LabelColumn: "label", TextColumn: "body", Comma: ';'})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Imported != 3 || !reflect.DeepEqual(report.Categories, map[string]int{"spam": 1, "ham": 2}) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Rejected != 3 || report.Rejects[0] != (Reject{Source: "line 4", Reason: `invalid category "not valid"`}) || report.Rejects[1] != (Reject{Source: "line 5", Reason: "missing columns"}) || report.Rejects[2].Source != "line 6" {
+		t.Fatalf("unexpected rejects: %+v", report.Rejects)
+	}
+	if got := c.Summaries()["ham"].TokenTally; got != 3 {
+		t.Fatalf("unexpected ham tally: %v", got)
+	}
+
+	for _, input := range []string{"", "category,body\nspam,x\n"} {
+		if _, err := ImportCSV(c, strings.NewReader(input), CSVOptions{}); !errors.Is(err, ErrInvalidCSV) {
+			t.Fatalf("%q: expected ErrInvalidCSV, got %v", input, err)
+		}
+	}
+
+	r := io.MultiReader(strings.NewReader("category,text\nspam,x\n"), iotest.ErrReader(io.ErrUnexpectedEOF))
+	if report, err := ImportCSV(c, r, CSVOptions{}); !errors.Is(err, io.ErrUnexpectedEOF) || report.Imported != 1 {
+		t.Fatalf("expected the read error after one row, got %+v, %v", report, err)
+	}
+}
+
+// TestImportNDJSON verifies NDJSON import, blank lines, and rejects.
+func TestImportNDJSON(t *testing.T) {
+	input := `{"category":"spam","text":"cheap pills"}
+
+{"category":"ham","text":"team meeting"}
+not json
+{"category":"ham","text":"x","extra":1}
+{"category":"ham","text":""}
+{"category":"spam","text":"pills"} {"category":"spam","text":"two"}
+`
+	c := bayes.NewClassifierWithTokenizer(strings.Fields)
+	report, err := ImportNDJSON(c, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantRejects := []Reject{
+		{Source: "line 4", Reason: "invalid JSON sample"},
+		{Source: "line 5", Reason: "invalid JSON sample"},
+		{Source: "line 6", Reason: "empty text"},
+		{Source: "line 7", Reason: "invalid JSON sample"},
+	}
+	if report.Imported != 2 || report.Rejected != 4 || !reflect.DeepEqual(report.Rejects, wantRejects) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	long := `{"category":"spam","text":"` + strings.Repeat("x", MaxLineBytes) + `"}`
+	if _, err := ImportNDJSON(c, strings.NewReader(long)); err == nil {
+		t.Fatal("expected error for an overlong line")
+	}
+}
+
+// TestRejectsAreCapped verifies only MaxRejects rejects are listed.
+func TestRejectsAreCapped(t *testing.T) {
+	input := strings.Repeat("{}\n", MaxRejects+5)
+	report, err := ImportNDJSON(bayes.NewClassifier(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Rejected != MaxRejects+5 || len(report.Rejects) != MaxRejects {
+		t.Fatalf("unexpected reject counts: rejected=%d listed=%d", report.Rejected, len(report.Rejects))
+	}
+}
@@ -0,0 +1,86 @@
+package bayes
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hickeroar/gobayes/v4/bayes/category"
+)
+
+// ErrInvalidDecayFactor indicates a decay factor that is not greater than
+// zero and at most one.
+var ErrInvalidDecayFactor = category.ErrInvalidDecayFactor
+
+// Decay multiplies every trained count, including document counts and
+// frequencies, by factor, which must be greater than zero and at most one.
+// Decaying regularly makes older training weigh exponentially less than
+// recent training, so the model follows drifting data. Counts become
+// fractional; tokens whose counts become negligible are dropped, and
+// categories left without tokens are deleted. A factor of one is a no-op.
+func (c *Classifier) Decay(factor float64) error {
+	if !(factor > 0 && factor <= 1) {
+		return fmt.Errorf("%w: %v", ErrInvalidDecayFactor, factor)
+	}
+	if factor == 1 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.decayUnlocked(factor)
+	c.revision++
+	return nil
+}
+
+// decayUnlocked multiplies every count by factor and deletes what Decay
+// documents it deletes. Callers must hold the write lock.
+func (c *Classifier) decayUnlocked(factor float64) {
+	_ = c.categories.Decay(factor)
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		c.cleanUpCategory(cat)
+	}
+	c.categories.EnsureCategoryProbabilities()
+}
+
+// DecayUntil decays the model for the time elapsed between its last
+// DecayUntil and now, so counts halve every halfLife, and records now as the
+// time of the last decay. The time is persisted by Save, so a model that is
+// loaded after being saved also decays for the time in between. A model that
+// was never decayed this way only records now, and a now before the recorded
+// time changes nothing.
+func (c *Classifier) DecayUntil(now time.Time, halfLife time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !now.After(c.decayedAt) {
+		return
+	}
+	if !c.decayedAt.IsZero() {
+		if factor := DecayFactor(now.Sub(c.decayedAt), halfLife); factor < 1 {
+			c.decayUnlocked(factor)
+		}
+	}
+	c.decayedAt = now
+	c.revision++
+}
+
+// DecayedAt returns the time recorded by the last DecayUntil, or the zero
+// time when the model has not been decayed that way.
+func (c *Classifier) DecayedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.decayedAt
+}
+
+// DecayFactor returns the factor that, passed to Decay after elapsed time,
+// halves counts every halfLife: 0.5^(elapsed/halfLife). It returns 1 when
+// elapsed or halfLife is not positive, and never returns zero.
+func DecayFactor(elapsed, halfLife time.Duration) float64 {
+	if elapsed <= 0 || halfLife <= 0 {
+		return 1
+	}
+	return max(math.Exp2(-elapsed.Seconds()/halfLife.Seconds()), math.SmallestNonzeroFloat64)
+}
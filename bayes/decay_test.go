@@ -0,0 +1,139 @@
+package bayes
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestDecayFactor verifies half-life decay factors.
+func TestDecayFactor(t *testing.T) {
+	tests := []struct {
+		elapsed, halfLife time.Duration
+		want              float64
+	}{
+		{time.Hour, time.Hour, 0.5},
+		{2 * time.Hour, time.Hour, 0.25},
+		{time.Hour, 0, 1},
+		{0, time.Hour, 1},
+	}
+	for _, tc := range tests {
+		if got := DecayFactor(tc.elapsed, tc.halfLife); math.Abs(got-tc.want) > 1e-12 {
+			t.Fatalf("DecayFactor(%v, %v) = %v, want %v", tc.elapsed, tc.halfLife, got, tc.want)
+		}
+	}
+}
+
+// TestDecay verifies decay scales counts and lets recent training outweigh old training.
+func TestDecay(t *testing.T) {
+	c := NewClassifier()
+	for range 3 {
+		_ = c.Train("old", "sale sale")
+	}
+	revision := c.Revision()
+
+	for _, factor := range []float64{0, -0.5, 1.5, math.NaN()} {
+		if err := c.Decay(factor); !errors.Is(err, ErrInvalidDecayFactor) {
+			t.Fatalf("Decay(%v): expected ErrInvalidDecayFactor, got %v", factor, err)
+		}
+	}
+	if err := c.Decay(1); err != nil || c.Revision() != revision {
+		t.Fatalf("expected Decay(1) to be a no-op, got %v", err)
+	}
+
+	if err := c.Decay(0.25); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	summary := c.Summaries()["old"]
+	if summary.TokenTally != 1.5 || summary.DocumentCount != 0.75 || c.Revision() == revision {
+		t.Fatalf("unexpected decayed summary: %+v", summary)
+	}
+
+	_ = c.Train("new", "sale sale")
+	if got := c.Classify("sale").Category; got != "new" {
+		t.Fatalf("expected recent training to win, got %q", got)
+	}
+
+	// Untraining more than a decayed count removes the token and category.
+	_ = c.Untrain("old", "sale sale")
+	if _, ok := c.Summaries()["old"]; ok {
+		t.Fatal("expected old to be removed once its decayed counts were untrained")
+	}
+}
+
+// TestDecayPersistence verifies fractional counts survive a save and load.
+func TestDecayPersistence(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "cheap pills")
+	_ = c.Decay(0.5)
+
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := loaded.Summaries()["spam"]; got.TokenTally != 1 || got.DocumentCount != 0.5 {
+		t.Fatalf("unexpected loaded summary: %+v", got)
+	}
+}
+
+// TestDecayUntil verifies decay by elapsed time since the recorded decay time.
+func TestDecayUntil(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "cheap pills")
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	revision := c.Revision()
+	c.DecayUntil(start, time.Hour)
+	if got := c.Summaries()["spam"].TokenTally; got != 2 {
+		t.Fatalf("expected the first DecayUntil to only record the time, got tally %v", got)
+	}
+	if !c.DecayedAt().Equal(start) || c.Revision() == revision {
+		t.Fatalf("expected the decay time recorded as a change, got %v", c.DecayedAt())
+	}
+
+	c.DecayUntil(start.Add(time.Hour), time.Hour)
+	if got := c.Summaries()["spam"].TokenTally; got != 1 {
+		t.Fatalf("expected tally halved after one half-life, got %v", got)
+	}
+
+	revision = c.Revision()
+	c.DecayUntil(start, time.Hour)
+	if got := c.Summaries()["spam"].TokenTally; got != 1 || c.Revision() != revision {
+		t.Fatalf("expected an earlier time to change nothing, got tally %v", got)
+	}
+
+	c.DecayUntil(start.Add(2*time.Hour), 0)
+	if got := c.Summaries()["spam"].TokenTally; got != 1 || !c.DecayedAt().Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("expected no decay without a half-life, got tally %v at %v", got, c.DecayedAt())
+	}
+}
+
+// TestDecayUntilPersistence verifies the decay time survives a save and load.
+func TestDecayUntilPersistence(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "cheap pills")
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.DecayUntil(start, time.Hour)
+
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !loaded.DecayedAt().Equal(start) {
+		t.Fatalf("unexpected loaded decay time: %v", loaded.DecayedAt())
+	}
+	loaded.DecayUntil(start.Add(time.Hour), time.Hour)
+	if got := loaded.Summaries()["spam"].TokenTally; got != 1 {
+		t.Fatalf("expected tally halved after one half-life, got %v", got)
+	}
+}
@@ -0,0 +1,158 @@
+package bayes
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hickeroar/gobayes/v4/bayes/stopwords"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	stopWordIndexOnce sync.Once
+	// stopWordIndex maps each stop word to the detectable languages that list it.
+	stopWordIndex map[string][]string
+)
+
+// detectableLanguages returns the languages DetectLanguage can report: those
+// with both a stemmer and a stop-word list, sorted by name.
+func detectableLanguages() []string {
+	langs := make([]string, 0, len(snowballLang))
+	for lang := range snowballLang {
+		if stopwords.Supported(lang) {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// buildStopWordIndex fills stopWordIndex from the stopwords package.
+func buildStopWordIndex() {
+	stopWordIndex = make(map[string][]string)
+	for _, lang := range detectableLanguages() {
+		for word := range stopwords.Get(lang) {
+			stopWordIndex[word] = append(stopWordIndex[word], lang)
+		}
+	}
+}
+
+// DetectLanguage guesses the language of text from its stop words and returns
+// one of the languages supported by NewDefaultTokenizer, or "" when text
+// contains no stop words of any of them. Each stop word counts toward every
+// language that lists it, weighted down by how many languages share it, and
+// the highest total wins. Detection works offline and is meant for telling
+// apart samples of a few sentences; very short texts may be misdetected.
+func DetectLanguage(text string) string {
+	return detectLanguage(text, "")
+}
+
+// detectLanguage is DetectLanguage with ties, including texts without stop
+// words, resolved in favor of fallback. Other ties go to the language that
+// sorts first.
+func detectLanguage(text, fallback string) string {
+	stopWordIndexOnce.Do(buildStopWordIndex)
+
+	text = strings.ToLower(norm.NFKC.String(text))
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	scores := make(map[string]float64)
+	for _, word := range words {
+		langs := stopWordIndex[word]
+		for _, lang := range langs {
+			scores[lang] += 1 / float64(len(langs))
+		}
+	}
+
+	best, bestScore := fallback, scores[fallback]
+	for _, lang := range detectableLanguages() {
+		if score := scores[lang]; score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
+
+// languageTokenizers are the per-language tokenizers of a detecting
+// tokenizer.
+type languageTokenizers struct {
+	language   string // used when detection finds nothing
+	fallback   func(string) []string
+	tokenizers map[string]func(string) []string
+}
+
+// newLanguageTokenizers builds a TokenizerWords tokenizer for every detectable
+// language from opts, and one for opts.Language as the fallback.
+func newLanguageTokenizers(opts TokenizerOptions) *languageTokenizers {
+	fixed := opts
+	fixed.DetectLanguage = false
+	langs := &languageTokenizers{
+		language:   opts.Language,
+		fallback:   NewTokenizer(fixed),
+		tokenizers: make(map[string]func(string) []string),
+	}
+	for _, lang := range detectableLanguages() {
+		fixed.Language = lang
+		langs.tokenizers[lang] = NewTokenizer(fixed)
+	}
+	return langs
+}
+
+// tokenize tokenizes sample with the rules of lang, or with the fallback
+// tokenizer when lang is not detectable.
+func (t *languageTokenizers) tokenize(sample, lang string) []string {
+	if tokenize, ok := t.tokenizers[lang]; ok {
+		return tokenize(sample)
+	}
+	return t.fallback(sample)
+}
+
+// detectAndTokenize tokenizes sample with the rules of its detected language.
+func (t *languageTokenizers) detectAndTokenize(sample string) []string {
+	return t.tokenize(sample, detectLanguage(sample, t.language))
+}
+
+// newDetectingTokenizer returns a TokenizerWords tokenizer that detects each
+// sample's language and stems and filters it with that language's rules,
+// using opts.Language when detection finds nothing.
+func newDetectingTokenizer(opts TokenizerOptions) func(string) []string {
+	return newLanguageTokenizers(opts).detectAndTokenize
+}
+
+// newClassifierTokenizer returns NewTokenizer(opts) for a classifier, along
+// with its per-language tokenizers when it is a detecting tokenizer, so the
+// classifier can detect a sample's language once and reuse the result.
+func newClassifierTokenizer(opts TokenizerOptions) (func(string) []string, *languageTokenizers) {
+	opts = opts.normalize()
+	if !opts.DetectLanguage {
+		return NewTokenizer(opts), nil
+	}
+	langs := newLanguageTokenizers(opts)
+	return langs.detectAndTokenize, langs
+}
+
+// detectedLanguageUnlocked returns the language the tokenizer detects for
+// text, or "" when language detection is off. The caller must hold c.mu.
+func (c *Classifier) detectedLanguageUnlocked(text string) string {
+	if !c.tokenizerOptions.DetectLanguage {
+		return ""
+	}
+	return detectLanguage(text, c.tokenizerOptions.Language)
+}
+
+// tokenizerForUnlocked returns the language detected for text, as
+// detectedLanguageUnlocked does, and a tokenizer that applies that language's
+// rules without detecting it again. The caller must hold c.mu.
+func (c *Classifier) tokenizerForUnlocked(text string) (func(string) []string, string) {
+	lang := c.detectedLanguageUnlocked(text)
+	if c.languageTokenizers == nil {
+		return c.getTokenizer(), lang
+	}
+	return func(sample string) []string {
+		return c.languageTokenizers.tokenize(sample, lang)
+	}, lang
+}
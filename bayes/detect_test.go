@@ -0,0 +1,111 @@
+package bayes
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestDetectLanguage verifies stop-word based detection for supported languages.
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The weather is nice and we are going to the beach", "english"},
+		{"El tiempo es bueno y nosotros vamos a la playa con los niños", "spanish"},
+		{"Le temps est beau et nous allons à la plage avec les enfants", "french"},
+		{"Погода хорошая, и мы идём на пляж с детьми", "russian"},
+		{"12345 !!!", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Fatalf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	if got := detectLanguage("xyzzy", "spanish"); got != "spanish" {
+		t.Fatalf("expected fallback when no stop words match, got %q", got)
+	}
+}
+
+// TestDetectingTokenizer verifies each sample is stemmed with its detected language.
+func TestDetectingTokenizer(t *testing.T) {
+	detecting := NewTokenizer(TokenizerOptions{Language: "english", RemoveStopWords: true, DetectLanguage: true})
+
+	spanish := "los gatos y los perros"
+	if got, want := detecting(spanish), NewDefaultTokenizer("spanish", true)(spanish); !slices.Equal(got, want) {
+		t.Fatalf("expected spanish tokens %v, got %v", want, got)
+	}
+	english := "the cats and the dogs"
+	if got, want := detecting(english), NewDefaultTokenizer("english", true)(english); !slices.Equal(got, want) {
+		t.Fatalf("expected english tokens %v, got %v", want, got)
+	}
+	// Samples without stop words use the configured language, even one
+	// detection does not support.
+	german := NewTokenizer(TokenizerOptions{Language: "german", DetectLanguage: true})
+	if got, want := german("zzz 123"), NewDefaultTokenizer("german", false)("zzz 123"); !slices.Equal(got, want) {
+		t.Fatalf("expected fallback tokens %v, got %v", want, got)
+	}
+
+	if opts := (TokenizerOptions{Language: "chinese", DetectLanguage: true}).normalize(); opts.DetectLanguage {
+		t.Fatalf("expected detection to be ignored by the chars tokenizer, got %+v", opts)
+	}
+}
+
+// TestClassifyReportsDetectedLanguage verifies classification results carry the detected language.
+func TestClassifyReportsDetectedLanguage(t *testing.T) {
+	c := NewClassifierWithOptions("english", false, WithLanguageDetection())
+	if err := c.Train("billing", "I want a refund for my invoice"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := c.Train("billing", "Quiero un reembolso de mi factura"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	text := "Necesito el reembolso de la factura"
+	if got := c.Classify(text); got.Category != "billing" || got.Language != "spanish" {
+		t.Fatalf("unexpected classification: %+v", got)
+	}
+	if got := c.ClassifyWithOptions("zzz", ClassifyOptions{Unknown: "unknown"}); !got.Abstained || got.Language != "english" {
+		t.Fatalf("expected fallback language when abstaining, got %+v", got)
+	}
+	if got := c.ClassifyTopN(text, 1); len(got) != 1 || got[0].Language != "spanish" {
+		t.Fatalf("unexpected ranking: %+v", got)
+	}
+	if got := c.ClassifyBatch([]string{text, "where is my invoice"}); got[0].Language != "spanish" || got[1].Language != "english" {
+		t.Fatalf("unexpected batch results: %+v", got)
+	}
+	if got := c.Explain(text).Classification.Language; got != "spanish" {
+		t.Fatalf("expected explanation language spanish, got %q", got)
+	}
+
+	if got := NewClassifier().Classify(text).Language; got != "" {
+		t.Fatalf("expected no language without detection, got %q", got)
+	}
+}
+
+// TestLanguageDetectionRoundTrip verifies the detection setting is persisted and restored.
+func TestLanguageDetectionRoundTrip(t *testing.T) {
+	original := NewClassifierWithOptions("french", false, WithLanguageDetection())
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"detectLanguage":true`) {
+		t.Fatalf("expected detectLanguage in payload, got %s", buf.String())
+	}
+
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if opts := loaded.TokenizerOptions(); !opts.DetectLanguage || opts.Language != "french" {
+		t.Fatalf("unexpected tokenizer options after load: %+v", opts)
+	}
+	if got := loaded.Classify("the cat is on the table").Language; got != "english" {
+		t.Fatalf("expected english detection after load, got %q", got)
+	}
+}
@@ -0,0 +1,256 @@
+// Package eval measures classifier quality on labeled samples, either
+// against a trained classifier or with k-fold cross-validation.
+package eval
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// DefaultFolds is the number of folds CrossValidate uses when
+// Options.Folds is zero.
+const DefaultFolds = 5
+
+// NoPrediction is the predicted label recorded when the classifier scores no
+// category for a sample. It cannot collide with a valid category name.
+const NoPrediction = "(none)"
+
+var (
+	// ErrNoSamples indicates an evaluation without samples.
+	ErrNoSamples = errors.New("no samples")
+	// ErrInvalidSample indicates a sample whose category is not a valid
+	// category name.
+	ErrInvalidSample = errors.New("invalid sample")
+	// ErrInvalidOptions indicates cross-validation options that cannot be used
+	// with the given samples.
+	ErrInvalidOptions = errors.New("invalid evaluation options")
+)
+
+// Metrics are the precision, recall, and F1 score of one category.
+type Metrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	// Support is the number of samples labeled with the category.
+	Support int `json:"support"`
+}
+
+// Averages are precision, recall, and F1 averaged over categories.
+type Averages struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// Report summarizes how well predictions matched sample labels.
+type Report struct {
+	Samples  int     `json:"samples"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+	// Categories holds metrics for every label that occurs as an actual or
+	// predicted category.
+	Categories map[string]Metrics `json:"categories"`
+	// Macro averages the per-category metrics with equal weight per category.
+	Macro Averages `json:"macro"`
+	// Micro computes the metrics from prediction totals. Micro recall equals
+	// accuracy, and micro precision differs only when some samples get
+	// NoPrediction.
+	Micro Averages `json:"micro"`
+	// ConfusionMatrix counts samples by actual then predicted category.
+	ConfusionMatrix map[string]map[string]int `json:"confusionMatrix"`
+	// FoldAccuracy holds the accuracy of each fold of a cross-validation.
+	FoldAccuracy []float64 `json:"foldAccuracy,omitempty"`
+}
+
+// Options configures CrossValidate.
+type Options struct {
+	// Folds is the number of folds, at least 2 and at most the number of
+	// samples. Zero means DefaultFolds.
+	Folds int
+	// Seed seeds the shuffle that assigns samples to folds, so results are
+	// reproducible for the same seed.
+	Seed uint64
+}
+
+// Evaluate classifies the text of every sample with c and compares the
+// predictions with the sample categories. c is not trained or changed.
+func Evaluate(c *bayes.Classifier, samples []bayes.Sample) (Report, error) {
+	if err := validateSamples(samples); err != nil {
+		return Report{}, err
+	}
+
+	matrix := make(confusionMatrix)
+	matrix.classify(c, samples)
+	return matrix.report(), nil
+}
+
+// CrossValidate runs k-fold cross-validation: samples are split into folds
+// stratified by category, and each fold is classified by an EmptyCopy of
+// template trained on the other folds. The report pools predictions from
+// every fold. template itself is not trained or changed.
+func CrossValidate(template *bayes.Classifier, samples []bayes.Sample, opts Options) (Report, error) {
+	if err := validateSamples(samples); err != nil {
+		return Report{}, err
+	}
+	folds := opts.Folds
+	if folds == 0 {
+		folds = DefaultFolds
+	}
+	if folds < 2 || folds > len(samples) {
+		return Report{}, fmt.Errorf("%w: %d folds for %d samples", ErrInvalidOptions, folds, len(samples))
+	}
+
+	// Shuffle, then deal samples grouped by category round-robin so every
+	// fold gets a similar share of each category.
+	order := rand.New(rand.NewPCG(opts.Seed, opts.Seed)).Perm(len(samples))
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(samples[a].Category, samples[b].Category)
+	})
+	assigned := make([]int, len(samples))
+	for i, idx := range order {
+		assigned[idx] = i % folds
+	}
+
+	matrix := make(confusionMatrix)
+	foldAccuracy := make([]float64, folds)
+	for fold := range folds {
+		var train, test []bayes.Sample
+		for i, sample := range samples {
+			if assigned[i] == fold {
+				test = append(test, sample)
+			} else {
+				train = append(train, sample)
+			}
+		}
+
+		c := template.EmptyCopy()
+		_ = c.TrainBatch(train)
+		foldMatrix := make(confusionMatrix)
+		foldMatrix.classify(c, test)
+		foldAccuracy[fold] = foldMatrix.report().Accuracy
+		matrix.merge(foldMatrix)
+	}
+
+	report := matrix.report()
+	report.FoldAccuracy = foldAccuracy
+	return report, nil
+}
+
+// validateSamples checks that there are samples and that every sample has a
+// valid category name.
+func validateSamples(samples []bayes.Sample) error {
+	if len(samples) == 0 {
+		return ErrNoSamples
+	}
+	for i, sample := range samples {
+		if !bayes.ValidCategoryName(sample.Category) {
+			return fmt.Errorf("%w %d: category %q", ErrInvalidSample, i, sample.Category)
+		}
+	}
+	return nil
+}
+
+// confusionMatrix counts samples by actual then predicted category.
+type confusionMatrix map[string]map[string]int
+
+// add counts one sample.
+func (m confusionMatrix) add(actual, predicted string, n int) {
+	if m[actual] == nil {
+		m[actual] = make(map[string]int)
+	}
+	m[actual][predicted] += n
+}
+
+// classify counts c's prediction for every sample.
+func (m confusionMatrix) classify(c *bayes.Classifier, samples []bayes.Sample) {
+	texts := make([]string, len(samples))
+	for i, sample := range samples {
+		texts[i] = sample.Text
+	}
+	for i, result := range c.ClassifyBatch(texts) {
+		predicted := result.Category
+		if predicted == "" {
+			predicted = NoPrediction
+		}
+		m.add(samples[i].Category, predicted, 1)
+	}
+}
+
+// merge adds the counts of other to m.
+func (m confusionMatrix) merge(other confusionMatrix) {
+	for actual, row := range other {
+		for predicted, n := range row {
+			m.add(actual, predicted, n)
+		}
+	}
+}
+
+// report computes accuracy and per-category, macro, and micro metrics.
+func (m confusionMatrix) report() Report {
+	report := Report{
+		Categories:      make(map[string]Metrics),
+		ConfusionMatrix: m,
+	}
+	predictedTotals := make(map[string]int)
+	for actual, row := range m {
+		support := 0
+		for predicted, n := range row {
+			support += n
+			predictedTotals[predicted] += n
+		}
+		report.Samples += support
+		report.Correct += row[actual]
+		report.Categories[actual] = Metrics{Support: support}
+	}
+	for predicted := range predictedTotals {
+		if _, ok := report.Categories[predicted]; !ok && predicted != NoPrediction {
+			report.Categories[predicted] = Metrics{}
+		}
+	}
+	if report.Samples == 0 {
+		return report
+	}
+	report.Accuracy = float64(report.Correct) / float64(report.Samples)
+
+	for name, metrics := range report.Categories {
+		truePositives := m[name][name]
+		metrics.Precision = ratio(truePositives, predictedTotals[name])
+		metrics.Recall = ratio(truePositives, metrics.Support)
+		metrics.F1 = f1(metrics.Precision, metrics.Recall)
+		report.Categories[name] = metrics
+
+		report.Macro.Precision += metrics.Precision
+		report.Macro.Recall += metrics.Recall
+		report.Macro.F1 += metrics.F1
+	}
+	categories := float64(len(report.Categories))
+	report.Macro.Precision /= categories
+	report.Macro.Recall /= categories
+	report.Macro.F1 /= categories
+
+	report.Micro.Precision = ratio(report.Correct, report.Samples-predictedTotals[NoPrediction])
+	report.Micro.Recall = report.Accuracy
+	report.Micro.F1 = f1(report.Micro.Precision, report.Micro.Recall)
+	return report
+}
+
+// ratio returns n/d, or 0 when d is 0.
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// f1 returns the harmonic mean of precision and recall, or 0 when both are 0.
+func f1(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0
+	}
+	return 2 * precision * recall / (precision + recall)
+}
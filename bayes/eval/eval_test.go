@@ -0,0 +1,146 @@
+package eval
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// TestEvaluateReportsMetrics verifies accuracy, per-category metrics,
+// averages, and the confusion matrix for a trained classifier.
+func TestEvaluateReportsMetrics(t *testing.T) {
+	c := bayes.NewClassifierWithTokenizer(strings.Fields)
+	_ = c.Train("spam", "cheap pills")
+	_ = c.Train("ham", "team meeting")
+	revision := c.Revision()
+
+	report, err := Evaluate(c, []bayes.Sample{
+		{Category: "spam", Text: "cheap pills"},
+		{Category: "spam", Text: "meeting"},
+		{Category: "ham", Text: "team"},
+		{Category: "ham", Text: "unknown words"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Revision() != revision {
+		t.Fatal("expected Evaluate not to change the classifier")
+	}
+
+	wantMatrix := map[string]map[string]int{
+		"spam": {"spam": 1, "ham": 1},
+		"ham":  {"ham": 1, NoPrediction: 1},
+	}
+	if !reflect.DeepEqual(report.ConfusionMatrix, wantMatrix) {
+		t.Fatalf("unexpected confusion matrix: %v", report.ConfusionMatrix)
+	}
+	if report.Samples != 4 || report.Correct != 2 || report.Accuracy != 0.5 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if got := report.Categories["spam"]; got != (Metrics{Precision: 1, Recall: 0.5, F1: 2.0 / 3, Support: 2}) {
+		t.Fatalf("unexpected spam metrics: %+v", got)
+	}
+	if got := report.Categories["ham"]; got != (Metrics{Precision: 0.5, Recall: 0.5, F1: 0.5, Support: 2}) {
+		t.Fatalf("unexpected ham metrics: %+v", got)
+	}
+	if _, ok := report.Categories[NoPrediction]; ok {
+		t.Fatal("expected no metrics for NoPrediction")
+	}
+	if math.Abs(report.Macro.Precision-0.75) > 1e-12 || report.Macro.Recall != 0.5 || math.Abs(report.Macro.F1-7.0/12) > 1e-12 {
+		t.Fatalf("unexpected macro averages: %+v", report.Macro)
+	}
+	if math.Abs(report.Micro.Precision-2.0/3) > 1e-12 || report.Micro.Recall != 0.5 || math.Abs(report.Micro.F1-4.0/7) > 1e-12 {
+		t.Fatalf("unexpected micro averages: %+v", report.Micro)
+	}
+}
+
+// TestEvaluateUnpredictedCategories verifies categories that are never
+// predicted, or only predicted, get zero metrics instead of dividing by zero.
+func TestEvaluateUnpredictedCategories(t *testing.T) {
+	c := bayes.NewClassifierWithTokenizer(strings.Fields)
+	_ = c.Train("spam", "cheap")
+	_ = c.Train("ham", "team")
+
+	report, err := Evaluate(c, []bayes.Sample{{Category: "spam", Text: "team"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]Metrics{"spam": {Support: 1}, "ham": {}}
+	if !reflect.DeepEqual(report.Categories, want) || report.Accuracy != 0 || report.Macro != (Averages{}) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	if report := (confusionMatrix{}).report(); report.Samples != 0 || len(report.Categories) != 0 {
+		t.Fatalf("expected an empty report, got %+v", report)
+	}
+}
+
+// TestEvaluateRejectsInvalidSamples verifies empty sets and invalid labels
+// are rejected.
+func TestEvaluateRejectsInvalidSamples(t *testing.T) {
+	c := bayes.NewClassifier()
+	if _, err := Evaluate(c, nil); !errors.Is(err, ErrNoSamples) {
+		t.Fatalf("expected ErrNoSamples, got %v", err)
+	}
+	if _, err := Evaluate(c, []bayes.Sample{{Category: "not valid", Text: "x"}}); !errors.Is(err, ErrInvalidSample) {
+		t.Fatalf("expected ErrInvalidSample, got %v", err)
+	}
+	if _, err := CrossValidate(c, []bayes.Sample{{Text: "x"}, {Category: "a", Text: "y"}}, Options{Folds: 2}); !errors.Is(err, ErrInvalidSample) {
+		t.Fatalf("expected ErrInvalidSample, got %v", err)
+	}
+}
+
+// TestCrossValidate verifies stratified folds, reproducible results, and
+// that the template classifier is left untouched.
+func TestCrossValidate(t *testing.T) {
+	template := bayes.NewClassifierWithOptions("english", true)
+	if err := template.SetScoringOptions(bayes.ScoringOptions{Mode: bayes.ScoringMultinomial}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var samples []bayes.Sample
+	for range 4 {
+		samples = append(samples,
+			bayes.Sample{Category: "spam", Text: "buy cheap pills now"},
+			bayes.Sample{Category: "spam", Text: "cheap pills discount"},
+			bayes.Sample{Category: "ham", Text: "team meeting notes"},
+		)
+	}
+
+	report, err := CrossValidate(template, samples, Options{Folds: 4, Seed: 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Samples != len(samples) || report.Accuracy != 1 || len(report.FoldAccuracy) != 4 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for i, accuracy := range report.FoldAccuracy {
+		if accuracy != 1 {
+			t.Fatalf("fold %d: unexpected accuracy %v", i, accuracy)
+		}
+	}
+	if report.Categories["ham"].Support != 4 || report.Macro.F1 != 1 {
+		t.Fatalf("unexpected metrics: %+v", report)
+	}
+	if len(template.Summaries()) != 0 {
+		t.Fatal("expected the template classifier to stay untrained")
+	}
+
+	again, _ := CrossValidate(template, samples, Options{Folds: 4, Seed: 7})
+	if !reflect.DeepEqual(report, again) {
+		t.Fatal("expected the same seed to give the same report")
+	}
+
+	if _, err := CrossValidate(template, samples, Options{}); err != nil {
+		t.Fatalf("expected default folds to work, got %v", err)
+	}
+	for _, folds := range []int{1, -1, len(samples) + 1} {
+		if _, err := CrossValidate(template, samples, Options{Folds: folds}); !errors.Is(err, ErrInvalidOptions) {
+			t.Fatalf("Folds=%d: expected ErrInvalidOptions, got %v", folds, err)
+		}
+	}
+}
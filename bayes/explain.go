@@ -0,0 +1,225 @@
+package bayes
+
+import (
+	"math"
+	"sort"
+)
+
+// TokenContribution describes how one token of a text sample affected a
+// category's score.
+type TokenContribution struct {
+	Token         string  `json:"token"`
+	Count         int     `json:"count"`         // occurrences of the token in the text sample
+	CategoryCount float64 `json:"categoryCount"` // occurrences of the token in the category's training data, fractional after Decay
+	Contribution  float64 `json:"contribution"`  // amount the token added to the category's score
+}
+
+// CategoryExplanation breaks a category's score into per-token contributions.
+type CategoryExplanation struct {
+	Score float64 `json:"score"`
+	// Base is the part of the score not attributable to any text token, such
+	// as the log prior. Base plus every token contribution equals Score.
+	Base   float64             `json:"base"`
+	Tokens []TokenContribution `json:"tokens"` // sorted by descending absolute contribution
+}
+
+// Explanation is the result of explaining how a text sample was classified.
+type Explanation struct {
+	Classification Classification                 `json:"classification"`
+	Categories     map[string]CategoryExplanation `json:"categories"` // categories scored by Score
+}
+
+// Explain classifies text and reports, for every scored category, how much
+// each token contributed to its score under the configured ScoringMode.
+// Tokens that did not change a category's score are omitted.
+func (c *Classifier) Explain(text string) Explanation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tokenize, lang := c.tokenizerForUnlocked(text)
+	occurrences := c.countTokenOccurrences(tokenize(text))
+
+	var categories map[string]CategoryExplanation
+	opts := c.scoringUnlocked()
+	switch opts.Mode {
+	case ScoringMultinomial:
+		categories = c.explainMultinomial(occurrences, opts)
+	case ScoringComplement:
+		categories = c.explainComplement(occurrences, opts)
+	case ScoringBernoulli:
+		categories = c.explainBernoulli(occurrences, opts)
+	default:
+		categories = c.explainClassic(occurrences)
+	}
+
+	scores := make(map[string]float64, len(categories))
+	for name, explanation := range categories {
+		sortContributions(explanation.Tokens)
+		scores[name] = explanation.Score
+	}
+	classification := bestClassification(scores)
+	classification.Language = lang
+	return Explanation{
+		Classification: classification,
+		Categories:     categories,
+	}
+}
+
+// explainClassic mirrors classicScores, recording each token's weighted
+// Bayesian probability. Categories with no matching tokens are omitted.
+func (c *Classifier) explainClassic(occurrences map[string]int) map[string]CategoryExplanation {
+	explanations := make(map[string]CategoryExplanation)
+	names := c.categories.Names()
+
+	for word, count := range occurrences {
+		tokenTally := c.categories.TokenTotal(word)
+		if tokenTally == 0.0 {
+			continue
+		}
+
+		for _, name := range names {
+			cat, _ := c.categories.LookupCategory(name)
+			tokenScore := cat.GetTokenCount(word)
+			contribution := float64(count) * c.calculateBayesianProbability(*cat, tokenScore, tokenTally)
+			if contribution == 0.0 {
+				continue
+			}
+			explanation := explanations[name]
+			explanation.Score += contribution
+			explanation.Tokens = append(explanation.Tokens, TokenContribution{
+				Token:         word,
+				Count:         count,
+				CategoryCount: tokenScore,
+				Contribution:  contribution,
+			})
+			explanations[name] = explanation
+		}
+	}
+	return explanations
+}
+
+// explainMultinomial mirrors multinomialScores. The base is the log prior.
+func (c *Classifier) explainMultinomial(occurrences map[string]int, opts ScoringOptions) map[string]CategoryExplanation {
+	explanations := make(map[string]CategoryExplanation)
+	if len(occurrences) == 0 {
+		return explanations
+	}
+
+	vocabularySize := float64(c.vocabularySize(opts))
+	alpha := opts.Smoothing
+
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		if cat.GetProbInCat() <= 0 {
+			continue
+		}
+		denominator := cat.GetTally() + alpha*vocabularySize
+		base := math.Log(cat.GetProbInCat())
+		explanation := CategoryExplanation{Score: base, Base: base}
+		for token, count := range occurrences {
+			tokenCount := cat.GetTokenCount(token)
+			contribution := float64(count) * math.Log((tokenCount+alpha)/denominator)
+			explanation.Score += contribution
+			explanation.Tokens = append(explanation.Tokens, TokenContribution{
+				Token:         token,
+				Count:         count,
+				CategoryCount: tokenCount,
+				Contribution:  contribution,
+			})
+		}
+		explanations[name] = explanation
+	}
+	return explanations
+}
+
+// explainComplement mirrors complementScores. The base is always zero.
+func (c *Classifier) explainComplement(occurrences map[string]int, opts ScoringOptions) map[string]CategoryExplanation {
+	explanations := make(map[string]CategoryExplanation)
+	if len(occurrences) == 0 {
+		return explanations
+	}
+
+	names := c.categories.Names()
+	totalTally := 0.0
+	for _, name := range names {
+		cat, _ := c.categories.LookupCategory(name)
+		totalTally += cat.GetTally()
+	}
+	vocabularySize := c.vocabularySize(opts)
+	normalizers := c.complementNormalizers(opts, vocabularySize, totalTally)
+	alpha := opts.Smoothing
+
+	for _, name := range names {
+		cat, _ := c.categories.LookupCategory(name)
+		denominator := totalTally - cat.GetTally() + alpha*float64(vocabularySize)
+		explanation := CategoryExplanation{}
+		for token, count := range occurrences {
+			tokenCount := cat.GetTokenCount(token)
+			complementCount := c.categories.TokenTotal(token) - tokenCount
+			weight := math.Log((complementCount + alpha) / denominator)
+			contribution := -float64(count) * weight / normalizers[name]
+			explanation.Score += contribution
+			explanation.Tokens = append(explanation.Tokens, TokenContribution{
+				Token:         token,
+				Count:         count,
+				CategoryCount: tokenCount,
+				Contribution:  contribution,
+			})
+		}
+		explanations[name] = explanation
+	}
+	return explanations
+}
+
+// explainBernoulli mirrors bernoulliScores. The base is the log prior plus
+// the absent-token terms of the whole vocabulary; each present token swaps
+// its absent term for its present term.
+func (c *Classifier) explainBernoulli(occurrences map[string]int, opts ScoringOptions) map[string]CategoryExplanation {
+	explanations := make(map[string]CategoryExplanation)
+	if len(occurrences) == 0 {
+		return explanations
+	}
+
+	vocabularySize := c.vocabularySize(opts)
+	absentLogSums := c.cachedCategoryTerms(opts, func() map[string]float64 {
+		return c.computeBernoulliAbsentLogSums(opts, vocabularySize)
+	})
+	alpha := opts.Smoothing
+
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		if cat.GetProbInCat() <= 0 {
+			continue
+		}
+		base := math.Log(cat.GetProbInCat()) + absentLogSums[name]
+		explanation := CategoryExplanation{Score: base, Base: base}
+		for token, count := range occurrences {
+			if c.categories.TokenTotal(token) == 0 {
+				continue
+			}
+			p := bernoulliProbability(cat, token, alpha)
+			contribution := math.Log(p) - math.Log1p(-p)
+			explanation.Score += contribution
+			explanation.Tokens = append(explanation.Tokens, TokenContribution{
+				Token:         token,
+				Count:         count,
+				CategoryCount: cat.GetTokenCount(token),
+				Contribution:  contribution,
+			})
+		}
+		explanations[name] = explanation
+	}
+	return explanations
+}
+
+// sortContributions orders tokens by descending absolute contribution,
+// breaking ties by token.
+func sortContributions(tokens []TokenContribution) {
+	sort.Slice(tokens, func(i, j int) bool {
+		a, b := math.Abs(tokens[i].Contribution), math.Abs(tokens[j].Contribution)
+		if a != b {
+			return a > b
+		}
+		return tokens[i].Token < tokens[j].Token
+	})
+}
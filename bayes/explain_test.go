@@ -0,0 +1,93 @@
+package bayes
+
+import (
+	"math"
+	"testing"
+)
+
+// TestExplainMatchesScore verifies explanations reproduce Score and Classify in every mode.
+func TestExplainMatchesScore(t *testing.T) {
+	text := "buy cheap meeting notes unknown buy"
+	for _, mode := range []ScoringMode{ScoringClassic, ScoringMultinomial, ScoringComplement, ScoringBernoulli} {
+		t.Run(string(mode), func(t *testing.T) {
+			classifier := newMultinomialClassifier(t, ScoringOptions{Mode: mode})
+			explanation := classifier.Explain(text)
+			scores := classifier.Score(text)
+
+			// Scores are sums over map iteration, so compare within a tolerance.
+			classification := classifier.Classify(text)
+			if got := explanation.Classification; got.Category != classification.Category || math.Abs(got.Score-classification.Score) > 1e-9 || math.Abs(got.Probability-classification.Probability) > 1e-9 {
+				t.Fatalf("explain classification %+v differs from Classify %+v", got, classification)
+			}
+			if len(explanation.Categories) != len(scores) {
+				t.Fatalf("expected %d explained categories, got %d", len(scores), len(explanation.Categories))
+			}
+			for name, score := range scores {
+				category, ok := explanation.Categories[name]
+				if !ok {
+					t.Fatalf("missing explanation for %q", name)
+				}
+				sum := category.Base
+				for i, token := range category.Tokens {
+					sum += token.Contribution
+					if i > 0 && math.Abs(token.Contribution) > math.Abs(category.Tokens[i-1].Contribution) {
+						t.Fatalf("tokens for %q not sorted by impact: %+v", name, category.Tokens)
+					}
+				}
+				if math.Abs(category.Score-score) > 1e-9 || math.Abs(sum-score) > 1e-9 {
+					t.Fatalf("%q: score=%v explained=%v sum=%v", name, score, category.Score, sum)
+				}
+			}
+		})
+	}
+}
+
+// TestExplainTokenDetails verifies token counts and omission of non-contributing tokens.
+func TestExplainTokenDetails(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{})
+	explanation := classifier.Explain("buy buy meeting unknown")
+
+	spam := explanation.Categories["spam"]
+	if len(spam.Tokens) != 1 {
+		t.Fatalf("expected only buy to contribute to spam, got %+v", spam.Tokens)
+	}
+	if token := spam.Tokens[0]; token.Token != "buy" || token.Count != 2 || token.CategoryCount != 2 || token.Contribution != 2 {
+		t.Fatalf("unexpected spam token contribution: %+v", token)
+	}
+	if spam.Base != 0 {
+		t.Fatalf("expected zero base for classic scoring, got %v", spam.Base)
+	}
+
+	multinomial := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringMultinomial})
+	ham := multinomial.Explain("buy meeting").Categories["ham"]
+	if ham.Base != math.Log(multinomial.Summaries()["ham"].ProbInCat) {
+		t.Fatalf("expected log prior base, got %v", ham.Base)
+	}
+	if len(ham.Tokens) != 2 || ham.Tokens[0].Token != "buy" || ham.Tokens[0].CategoryCount != 0 {
+		t.Fatalf("expected unseen buy to have the largest impact on ham, got %+v", ham.Tokens)
+	}
+}
+
+// TestExplainEmpty verifies an untrained classifier or empty text explains nothing.
+func TestExplainEmpty(t *testing.T) {
+	if explanation := NewClassifier().Explain("anything"); len(explanation.Categories) != 0 || explanation.Classification.Category != "" {
+		t.Fatalf("expected empty explanation, got %+v", explanation)
+	}
+	for _, mode := range []ScoringMode{ScoringMultinomial, ScoringComplement, ScoringBernoulli} {
+		classifier := newMultinomialClassifier(t, ScoringOptions{Mode: mode})
+		if explanation := classifier.Explain(""); len(explanation.Categories) != 0 {
+			t.Fatalf("%s: expected empty explanation for empty text, got %+v", mode, explanation)
+		}
+	}
+}
+
+// TestExplainSkipsEmptyCategories verifies a category without a prior is
+// explained only when Score scores it.
+func TestExplainSkipsEmptyCategories(t *testing.T) {
+	for _, mode := range []ScoringMode{ScoringMultinomial, ScoringBernoulli} {
+		classifier := loadWithEmptyCategory(t, ScoringOptions{Mode: mode})
+		if explanation := classifier.Explain("buy"); len(explanation.Categories) != 1 || explanation.Classification.Category != "spam" {
+			t.Fatalf("%s: expected only spam to be explained, got %+v", mode, explanation)
+		}
+	}
+}
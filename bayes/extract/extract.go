@@ -0,0 +1,345 @@
+// Package extract pulls classifiable text out of HTML documents and MIME
+// email messages.
+package extract
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxPartDepth bounds how deeply nested multipart bodies are followed.
+const maxPartDepth = 10
+
+// ErrInvalidMessage indicates an email that could not be parsed.
+var ErrInvalidMessage = errors.New("invalid email message")
+
+// Message is the classifiable content of an email.
+type Message struct {
+	Subject string
+	// Senders holds the lowercased domains of the From, Reply-To, and
+	// Return-Path addresses, keyed by lowercased header name. Headers that
+	// are missing or have no parseable address are omitted.
+	Senders map[string]string
+	// Text is the visible text of the body: text/plain parts, and text/html
+	// parts reduced with HTMLText. Parts are decoded from quoted-printable or
+	// base64 and converted to UTF-8. Attachments are skipped, and for
+	// multipart/alternative only the plain text version is used when present.
+	Text string
+}
+
+// senderHeaders are the headers whose address domains Message.Senders reports.
+var senderHeaders = []string{"From", "Reply-To", "Return-Path"}
+
+// Email parses a raw RFC 5322 message.
+func Email(raw string) (Message, error) {
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		return Message{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	result := Message{Subject: subject, Senders: make(map[string]string)}
+	for _, name := range senderHeaders {
+		if domain := addressDomain(msg.Header.Get(name)); domain != "" {
+			result.Senders[strings.ToLower(name)] = domain
+		}
+	}
+
+	var text strings.Builder
+	if err := appendPart(&text, partHeader(msg.Header), msg.Body, 0); err != nil {
+		return Message{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	result.Text = text.String()
+	return result, nil
+}
+
+// partHeader is the subset of MIME part headers appendPart reads.
+type partHeader interface {
+	Get(key string) string
+}
+
+// appendPart writes the visible text of one MIME entity to text.
+func appendPart(text *strings.Builder, header partHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition")); disposition == "attachment" {
+		return nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxPartDepth || params["boundary"] == "" {
+			return nil
+		}
+		return appendMultipart(text, mediaType, multipart.NewReader(body, params["boundary"]), depth)
+	}
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil
+	}
+
+	content, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+	decoded := toUTF8(content, params["charset"])
+	if mediaType == "text/html" {
+		decoded = HTMLText(decoded)
+	}
+	text.WriteString(decoded)
+	text.WriteString("\n")
+	return nil
+}
+
+// appendMultipart writes the visible text of each part. For
+// multipart/alternative, only the first text/plain part is used when one
+// exists.
+func appendMultipart(text *strings.Builder, mediaType string, reader *multipart.Reader, depth int) error {
+	type rawPart struct {
+		header partHeader
+		body   []byte
+	}
+	var parts []rawPart
+	for {
+		part, err := reader.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, rawPart{header: part.Header, body: body})
+	}
+
+	if mediaType == "multipart/alternative" {
+		for _, part := range parts {
+			if partType, _, _ := mime.ParseMediaType(part.header.Get("Content-Type")); partType == "text/plain" {
+				parts = []rawPart{part}
+				break
+			}
+		}
+	}
+	for _, part := range parts {
+		if err := appendPart(text, part.header, bytes.NewReader(part.body), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding.
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &whitespaceStripper{r: body})
+	default:
+		return body
+	}
+}
+
+// whitespaceStripper drops the line breaks and spaces base64 bodies contain.
+type whitespaceStripper struct {
+	r io.Reader
+}
+
+func (s *whitespaceStripper) Read(p []byte) (int, error) {
+	for {
+		n, err := s.r.Read(p)
+		kept := 0
+		for _, b := range p[:n] {
+			if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+				p[kept] = b
+				kept++
+			}
+		}
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// toUTF8 converts content from charset to UTF-8. Unknown charsets are
+// returned unchanged.
+func toUTF8(content []byte, charset string) string {
+	if charset == "" {
+		return string(content)
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return string(content)
+	}
+	if decoded, err := enc.NewDecoder().Bytes(content); err == nil {
+		content = decoded
+	}
+	return string(content)
+}
+
+// charsetReader returns a reader converting input from charset to UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// addressDomain returns the lowercased domain of the first address in value.
+func addressDomain(value string) string {
+	address := strings.TrimSpace(value)
+	if parsed, err := mail.ParseAddress(value); err == nil {
+		address = parsed.Address
+	}
+	address = strings.Trim(address, "<> ")
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(address[at+1:], ">. "))
+}
+
+// hiddenElements are elements whose content is never displayed.
+var hiddenElements = []string{"script", "style", "head", "noscript", "template"}
+
+// HTMLText returns the visible text of an HTML document: comments and the
+// contents of script, style, head, noscript, and template elements are
+// dropped, every other tag is replaced by a space, and character references
+// are unescaped. A '<' that does not start a tag, as in "a < b", is text, and
+// a '>' inside a quoted attribute value does not end its tag.
+func HTMLText(doc string) string {
+	var text strings.Builder
+	lower := asciiLower(doc)
+	for i := 0; i < len(doc); {
+		next := nextTag(doc, i)
+		if next < 0 {
+			text.WriteString(html.UnescapeString(doc[i:]))
+			break
+		}
+		text.WriteString(html.UnescapeString(doc[i:next]))
+		i = next
+
+		if strings.HasPrefix(doc[i:], "<!--") {
+			end := strings.Index(doc[i+4:], "-->")
+			if end < 0 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		end := tagEnd(doc, i)
+		if end < 0 {
+			break
+		}
+		if name := tagName(lower[i+1 : end]); slices.Contains(hiddenElements, name) {
+			closing := strings.Index(lower[end:], "</"+name)
+			if closing < 0 {
+				break
+			}
+			i = end + closing
+			closeEnd := strings.IndexByte(doc[i:], '>')
+			if closeEnd < 0 {
+				break
+			}
+			i += closeEnd + 1
+		} else {
+			i = end + 1
+		}
+		text.WriteByte(' ')
+	}
+	return text.String()
+}
+
+// nextTag returns the index of the first '<' at or after doc[i] that opens a
+// tag, comment, or declaration, or -1 when there is none. Any other '<' is
+// text.
+func nextTag(doc string, i int) int {
+	for {
+		j := strings.IndexByte(doc[i:], '<')
+		if j < 0 {
+			return -1
+		}
+		i += j + 1
+		if i < len(doc) && isTagStart(doc[i]) {
+			return i - 1
+		}
+	}
+}
+
+// isTagStart reports whether c, following '<', opens markup.
+func isTagStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '/' || c == '!' || c == '?'
+}
+
+// tagEnd returns the index of the '>' closing the tag that starts at doc[start],
+// skipping quoted attribute values, or -1 when the tag is not closed.
+func tagEnd(doc string, start int) int {
+	afterEquals := false
+	for i := start + 1; i < len(doc); i++ {
+		switch c := doc[i]; {
+		case c == '>':
+			return i
+		case afterEquals && (c == '"' || c == '\''):
+			end := strings.IndexByte(doc[i+1:], c)
+			if end < 0 {
+				return -1
+			}
+			i += 1 + end
+			afterEquals = false
+		case c == '=':
+			afterEquals = true
+		case !isHTMLSpace(c):
+			afterEquals = false
+		}
+	}
+	return -1
+}
+
+// isHTMLSpace reports whether c is HTML whitespace.
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// tagName returns the element name of an opening tag's contents, or "" for
+// closing tags, declarations, and processing instructions.
+func tagName(tag string) string {
+	end := strings.IndexAny(tag, " \t\r\n/")
+	if end < 0 {
+		end = len(tag)
+	}
+	return tag[:end]
+}
+
+// asciiLower lowercases ASCII letters only, so byte offsets into the result
+// match offsets into s.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,134 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestHTMLText verifies tags, hidden elements, comments, and entities are handled.
+func TestHTMLText(t *testing.T) {
+	doc := `<html><head><title>Ignored</title><style>p{color:red}</style></head>
+<body><p>Cheap&nbsp;<b>PILLS</b> &amp; more</p><!-- hidden --><SCRIPT>var x = "<b>";</SCRIPT><div>Click İmage</div></body></html>`
+	got := strings.Join(strings.Fields(HTMLText(doc)), " ")
+	if want := "Cheap  PILLS & more Click İmage"; got != strings.Join(strings.Fields(want), " ") {
+		t.Fatalf("unexpected text: %q", got)
+	}
+
+	for doc, want := range map[string]string{
+		`<a title="a>b" href='x>y'>link</a> text`:   "link text",
+		`<img alt = "x > y">after`:                  "after",
+		`<script type="a>b">var x;</script>shown`:   "shown",
+		`1 < 2 and 3<4 <b>bold</b>`:                 "1 < 2 and 3<4 bold",
+		`a <= b &lt; c`:                             "a <= b < c",
+		`trailing <`:                                "trailing <",
+		`<p class=x'y>unquoted</p>`:                 "unquoted",
+		`<a title="never closed>hidden</a>`:         "",
+		`<p>one</p><!-- > --><p data-x='>'>two</p>`: "one two",
+	} {
+		if got := strings.Join(strings.Fields(HTMLText(doc)), " "); got != want {
+			t.Fatalf("HTMLText(%q) = %q, want %q", doc, got, want)
+		}
+	}
+
+	for _, doc := range []string{"<p>unterminated", "text <b", "<!-- open", "<script>never closed", "<script>x</script"} {
+		_ = HTMLText(doc) // must not panic
+	}
+}
+
+const multipartEmail = "From: \"Deals\" <promo@Deals.Example.com>\r\n" +
+	"Reply-To: replies@other.example\r\n" +
+	"Subject: =?UTF-8?B?V2luIGEgcHJpemU=?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Caf=C3=A9 discount =\r\ntoday\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>HTML duplicate</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/html; charset=iso-8859-1\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PHA+RnLpZQ==\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf\r\n" +
+	"Content-Disposition: attachment; filename=x.pdf\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQ=\r\n" +
+	"--outer--\r\n"
+
+// TestEmail verifies subject decoding, sender domains, and part decoding.
+func TestEmail(t *testing.T) {
+	msg, err := Email(multipartEmail)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if msg.Subject != "Win a prize" {
+		t.Fatalf("unexpected subject: %q", msg.Subject)
+	}
+	if msg.Senders["from"] != "deals.example.com" || msg.Senders["reply-to"] != "other.example" || len(msg.Senders) != 2 {
+		t.Fatalf("unexpected senders: %v", msg.Senders)
+	}
+	text := strings.Join(strings.Fields(msg.Text), " ")
+	if text != "Café discount today Frée" {
+		t.Fatalf("unexpected text: %q", text)
+	}
+
+	plain, err := Email("Subject: hi\r\n\r\nplain body")
+	if err != nil || strings.TrimSpace(plain.Text) != "plain body" || len(plain.Senders) != 0 {
+		t.Fatalf("unexpected plain message: %+v, %v", plain, err)
+	}
+
+	if _, err := Email("not an email"); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("expected ErrInvalidMessage, got %v", err)
+	}
+}
+
+// TestEmailEdgeCases verifies undecodable headers and charsets fall back to
+// the raw text, skipped parts, and malformed bodies.
+func TestEmailEdgeCases(t *testing.T) {
+	for raw, want := range map[string]Message{
+		"Subject: =?windows-1252?Q?Fr=E9e?=\r\n\r\nbody":          {Subject: "Frée", Text: "body\n"},
+		"Subject: =?x-unknown?Q?Sale?=\r\n\r\nbody":               {Subject: "=?x-unknown?Q?Sale?=", Text: "body\n"},
+		"Content-Type: text/plain; charset=x-unknown\r\n\r\nbody": {Text: "body\n"},
+		"Content-Type: image/png\r\n\r\nbinary":                   {},
+		"Content-Type: multipart/mixed\r\n\r\nno boundary":        {},
+	} {
+		msg, err := Email(raw)
+		if err != nil || msg.Subject != want.Subject || msg.Text != want.Text {
+			t.Fatalf("Email(%q) = %+v, %v; want %+v", raw, msg, err, want)
+		}
+	}
+
+	nested := "Content-Type: text/plain\r\n\r\ndeep"
+	for depth := range maxPartDepth + 1 {
+		boundary := fmt.Sprintf("b%d", depth)
+		nested = "Content-Type: multipart/mixed; boundary=" + boundary + "\r\n\r\n--" + boundary + "\r\n" + nested + "\r\n--" + boundary + "--\r\n"
+	}
+	if msg, err := Email(nested); err != nil || msg.Text != "" {
+		t.Fatalf("expected parts nested too deeply to be skipped, got %+v, %v", msg, err)
+	}
+
+	for _, raw := range []string{
+		"Content-Transfer-Encoding: base64\r\n\r\n!!!!",
+		"Content-Type: multipart/mixed; boundary=b\r\n\r\n--b\r\n\r\nunterminated",
+		"Content-Type: multipart/mixed; boundary=b\r\n\r\n--b\r\nbad header\r\n\r\nx\r\n--b--\r\n",
+		"Content-Type: multipart/mixed; boundary=b\r\n\r\n--b\r\nContent-Transfer-Encoding: base64\r\n\r\n!!!!\r\n--b--\r\n",
+	} {
+		if _, err := Email(raw); !errors.Is(err, ErrInvalidMessage) {
+			t.Fatalf("Email(%q): expected ErrInvalidMessage, got %v", raw, err)
+		}
+	}
+}
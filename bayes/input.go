@@ -0,0 +1,149 @@
+package bayes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hickeroar/gobayes/v4/bayes/extract"
+)
+
+// InputFormat selects how raw input is turned into text before tokenizing.
+type InputFormat string
+
+const (
+	// InputText tokenizes input as is. This is what Train and Classify do.
+	InputText InputFormat = "text"
+	// InputHTML tokenizes only the visible text of an HTML document.
+	InputHTML InputFormat = "html"
+	// InputEmail parses an RFC 5322 message, possibly multipart. The visible
+	// text of its text/plain and text/html parts is tokenized after
+	// quoted-printable or base64 decoding. The subject is tokenized with each
+	// token prefixed by "subject:", and the domains of the From, Reply-To,
+	// and Return-Path addresses become tokens such as "from:example.com".
+	InputEmail InputFormat = "email"
+)
+
+// ErrInvalidInputFormat indicates an unknown input format name.
+var ErrInvalidInputFormat = errors.New("invalid input format")
+
+// ErrInvalidInput indicates input that could not be parsed in its format.
+var ErrInvalidInput = errors.New("invalid input")
+
+// ParseInputFormat returns the InputFormat named by s (case-insensitive).
+// Empty input returns InputText.
+func ParseInputFormat(s string) (InputFormat, error) {
+	switch format := InputFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case "":
+		return InputText, nil
+	case InputText, InputHTML, InputEmail:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidInputFormat, s)
+	}
+}
+
+// TrainInput is Train for input in the given format.
+func (c *Classifier) TrainInput(category string, format InputFormat, input string) error {
+	return c.TrainInputWeighted(category, format, input, 1)
+}
+
+// UntrainInput is Untrain for input in the given format.
+func (c *Classifier) UntrainInput(category string, format InputFormat, input string) error {
+	return c.UntrainInputWeighted(category, format, input, 1)
+}
+
+// TrainInputWeighted is TrainWeighted for input in the given format.
+func (c *Classifier) TrainInputWeighted(category string, format InputFormat, input string, weight float64) error {
+	return c.updateInput(category, format, input, weight, c.trainUnlocked)
+}
+
+// UntrainInputWeighted is UntrainWeighted for input in the given format.
+func (c *Classifier) UntrainInputWeighted(category string, format InputFormat, input string, weight float64) error {
+	return c.updateInput(category, format, input, weight, c.untrainUnlocked)
+}
+
+// updateInput tokenizes input and applies it to category with apply under
+// the write lock.
+func (c *Classifier) updateInput(category string, format InputFormat, input string, weight float64, apply func(category string, occurrences map[string]int, weight float64)) error {
+	if !validWeight(weight) {
+		return fmt.Errorf("%w: %v", ErrInvalidWeight, weight)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !categoryNamePattern.MatchString(category) {
+		return ErrInvalidCategoryName
+	}
+
+	tokens, _, err := c.inputTokensUnlocked(format, input)
+	if err != nil {
+		return err
+	}
+	apply(category, c.countTokenOccurrences(tokens), weight)
+
+	c.categories.EnsureCategoryProbabilities()
+	c.revision++
+	return nil
+}
+
+// ClassifyInput is ClassifyWithOptions for input in the given format.
+func (c *Classifier) ClassifyInput(format InputFormat, input string, opts ClassifyOptions) (RankedClassification, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tokens, lang, err := c.inputTokensUnlocked(format, input)
+	if err != nil {
+		return RankedClassification{}, err
+	}
+	return c.classifyTokensUnlocked(tokens, lang, opts), nil
+}
+
+// ScoreInput is Score for input in the given format.
+func (c *Classifier) ScoreInput(format InputFormat, input string) (map[string]float64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tokens, _, err := c.inputTokensUnlocked(format, input)
+	if err != nil {
+		return nil, err
+	}
+	return c.scoreTokensUnlocked(tokens), nil
+}
+
+// inputTokensUnlocked tokenizes input in format. It also returns the language
+// detected for the extracted text, which every part of it is tokenized in.
+func (c *Classifier) inputTokensUnlocked(format InputFormat, input string) ([]string, string, error) {
+	switch format {
+	case InputText, "":
+		tokenize, lang := c.tokenizerForUnlocked(input)
+		return tokenize(input), lang, nil
+	case InputHTML:
+		text := extract.HTMLText(input)
+		tokenize, lang := c.tokenizerForUnlocked(text)
+		return tokenize(text), lang, nil
+	case InputEmail:
+		msg, err := extract.Email(input)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		tokenize, lang := c.tokenizerForUnlocked(msg.Subject + "\n" + msg.Text)
+		tokens := tokenize(msg.Text)
+		for _, token := range tokenize(msg.Subject) {
+			tokens = append(tokens, "subject:"+token)
+		}
+		headers := make([]string, 0, len(msg.Senders))
+		for header := range msg.Senders {
+			headers = append(headers, header)
+		}
+		sort.Strings(headers)
+		for _, header := range headers {
+			tokens = append(tokens, header+":"+msg.Senders[header])
+		}
+		return tokens, lang, nil
+	default:
+		return nil, "", fmt.Errorf("%w: %q", ErrInvalidInputFormat, format)
+	}
+}
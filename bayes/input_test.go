@@ -0,0 +1,108 @@
+package bayes
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const spamEmail = "From: Promo <promo@deals.example>\r\n" +
+	"Subject: Cheap pills\r\n" +
+	"Content-Type: text/html\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"<p>Buy <b>now</b></p><style>.x{}</style>\r\n"
+
+// TestParseInputFormat verifies input format parsing.
+func TestParseInputFormat(t *testing.T) {
+	for input, want := range map[string]InputFormat{"": InputText, " HTML ": InputHTML, "email": InputEmail, "text": InputText} {
+		if got, err := ParseInputFormat(input); err != nil || got != want {
+			t.Fatalf("ParseInputFormat(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseInputFormat("pdf"); !errors.Is(err, ErrInvalidInputFormat) {
+		t.Fatalf("expected ErrInvalidInputFormat, got %v", err)
+	}
+}
+
+// TestInputTokens verifies the tokens extracted for each input format.
+func TestInputTokens(t *testing.T) {
+	c := NewClassifier()
+
+	tokens, _, err := c.inputTokensUnlocked(InputHTML, "<p>Buy <b>now</b></p><script>track()</script>")
+	if err != nil || !slices.Equal(tokens, []string{"buy", "now"}) {
+		t.Fatalf("unexpected html tokens: %v, %v", tokens, err)
+	}
+
+	tokens, lang, err := c.inputTokensUnlocked(InputEmail, spamEmail)
+	if err != nil {
+		t.Fatalf("unexpected email error: %v", err)
+	}
+	want := []string{"buy", "now", "subject:cheap", "subject:pill", "from:deals.example"}
+	if !slices.Equal(tokens, want) {
+		t.Fatalf("expected %v, got %v", want, tokens)
+	}
+	if lang != "" {
+		t.Fatalf("expected no language without detection, got %q", lang)
+	}
+
+	// The subject is tokenized in the language detected for the whole message.
+	detecting := NewClassifierWithOptions("english", false, WithLanguageDetection())
+	email := "From: a@b.example\r\nSubject: Las ofertas\r\n\r\nLas ofertas de la semana para los clientes\r\n"
+	tokens, lang, err = detecting.inputTokensUnlocked(InputEmail, email)
+	if err != nil || lang != "spanish" || !slices.Contains(tokens, "subject:ofert") {
+		t.Fatalf("unexpected detected email tokens: %v, %q, %v", tokens, lang, err)
+	}
+
+	if _, _, err := c.inputTokensUnlocked(InputEmail, "no headers here"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+	if _, _, err := c.inputTokensUnlocked("pdf", "x"); !errors.Is(err, ErrInvalidInputFormat) {
+		t.Fatalf("expected ErrInvalidInputFormat, got %v", err)
+	}
+}
+
+// TestInputLifecycle verifies train, classify, score, and untrain with formatted input.
+func TestInputLifecycle(t *testing.T) {
+	c := NewClassifier()
+	if err := c.TrainInput("spam", InputEmail, spamEmail); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := c.TrainInput("ham", InputHTML, "<p>Team meeting at noon</p>"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if cat, _ := c.categories.LookupCategory("spam"); cat.GetTokenCount("from:deals.example") != 1 {
+		t.Fatal("expected sender domain token to be trained")
+	}
+
+	result, err := c.ClassifyInput(InputEmail, "From: a@deals.example\r\nSubject: pills\r\n\r\nhello", ClassifyOptions{N: 2})
+	if err != nil || result.Category != "spam" || len(result.Ranking) != 1 {
+		t.Fatalf("unexpected classification: %+v, %v", result, err)
+	}
+	scores, err := c.ScoreInput(InputHTML, "<i>meeting</i>")
+	if err != nil || len(scores) != 1 || scores["ham"] <= 0 {
+		t.Fatalf("unexpected scores: %v, %v", scores, err)
+	}
+
+	if err := c.UntrainInput("spam", InputEmail, spamEmail); err != nil {
+		t.Fatalf("unexpected untrain error: %v", err)
+	}
+	if _, ok := c.Summaries()["spam"]; ok {
+		t.Fatal("expected untraining the same email to remove the category")
+	}
+
+	if _, err := c.ClassifyInput(InputEmail, "bad", ClassifyOptions{}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput classifying, got %v", err)
+	}
+	if _, err := c.ScoreInput(InputEmail, "bad"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput scoring, got %v", err)
+	}
+
+	revision := c.Revision()
+	if err := c.TrainInput("spam", InputEmail, "bad"); !errors.Is(err, ErrInvalidInput) || c.Revision() != revision {
+		t.Fatalf("expected ErrInvalidInput without a model change, got %v", err)
+	}
+	if err := c.TrainInput("bad name", InputText, "x"); !errors.Is(err, ErrInvalidCategoryName) {
+		t.Fatalf("expected ErrInvalidCategoryName, got %v", err)
+	}
+}
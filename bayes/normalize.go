@@ -0,0 +1,192 @@
+package bayes
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Normalization stages for TokenizerOptions.Normalizers. Each stage replaces
+// matching text with tokens that survive word splitting and stemming, so
+// signals such as links and prices are not broken into meaningless pieces.
+const (
+	// NormalizeEmails maps email addresses to "__email__" plus the lowercased
+	// domain, e.g. "example.com".
+	NormalizeEmails = "emails"
+	// NormalizeURLs maps http(s) and www. links to "__url__" plus the
+	// lowercased host without "www.", e.g. "shop.example.com".
+	NormalizeURLs = "urls"
+	// NormalizeMoney maps amounts with a currency symbol, such as "$9.99" or
+	// "10 €", to "__money__".
+	NormalizeMoney = "money"
+	// NormalizeNumbers maps standalone numbers, such as "42" or "1,000.5", to
+	// "__num__".
+	NormalizeNumbers = "numbers"
+	// NormalizeEmoji keeps each emoji as its own token instead of dropping it.
+	NormalizeEmoji = "emoji"
+)
+
+// Placeholder tokens emitted by normalization stages.
+const (
+	emailToken = "__email__"
+	urlToken   = "__url__"
+	moneyToken = "__money__"
+	numToken   = "__num__"
+)
+
+// normalizerStages lists every stage in pipeline order. Earlier stages win
+// when matches start at the same position, so an email is not also read as a
+// link and a price is not read as a bare number.
+var normalizerStages = []string{NormalizeEmails, NormalizeURLs, NormalizeMoney, NormalizeNumbers, NormalizeEmoji}
+
+// normalizerPatterns holds the expression matched by each stage.
+var normalizerPatterns = map[string]string{
+	NormalizeEmails:  `[\p{L}\p{N}._%+\-]+@(?:[\p{L}\p{N}\-]+\.)+\p{L}{2,}`,
+	NormalizeURLs:    `(?:[a-z][a-z0-9+.\-]*://|www\.)[^\s<>"']+`,
+	NormalizeMoney:   `\p{Sc}\s?\d+(?:[.,]\d+)*|\d+(?:[.,]\d+)*\s?\p{Sc}`,
+	NormalizeNumbers: `\d+(?:[.,]\d+)*`,
+	NormalizeEmoji:   `[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}]`,
+}
+
+// ErrInvalidNormalizer indicates an unknown normalization stage name.
+var ErrInvalidNormalizer = errors.New("invalid normalizer")
+
+// ParseNormalizers parses a comma-separated list of normalization stage names
+// (case-insensitive) and returns them in pipeline order. Empty input returns
+// nil.
+func ParseNormalizers(s string) ([]string, error) {
+	var stages []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(normalizerStages, name) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidNormalizer, name)
+		}
+		stages = append(stages, name)
+	}
+	return normalizeStages(stages), nil
+}
+
+// normalizeStages returns the known stages among names, lowercased,
+// deduplicated, and in pipeline order, or nil when there are none.
+func normalizeStages(names []string) []string {
+	var stages []string
+	for _, stage := range normalizerStages {
+		for _, name := range names {
+			if strings.ToLower(strings.TrimSpace(name)) == stage {
+				stages = append(stages, stage)
+				break
+			}
+		}
+	}
+	return stages
+}
+
+// textNormalizer finds the spans matched by enabled normalization stages.
+type textNormalizer struct {
+	re     *regexp.Regexp
+	stages []string // stage of each capture group, in order
+}
+
+// newTextNormalizer compiles the given stages, which must be in pipeline
+// order. It returns nil when no stage is enabled.
+func newTextNormalizer(stages []string) *textNormalizer {
+	if len(stages) == 0 {
+		return nil
+	}
+	patterns := make([]string, len(stages))
+	for i, stage := range stages {
+		patterns[i] = "(" + normalizerPatterns[stage] + ")"
+	}
+	return &textNormalizer{
+		re:     regexp.MustCompile("(?i)" + strings.Join(patterns, "|")),
+		stages: stages,
+	}
+}
+
+// tokenize splits sample into normalized spans and the text between them.
+// Text is tokenized with segment; each span is replaced by its stage's
+// tokens. A nil normalizer tokenizes the whole sample with segment.
+func (n *textNormalizer) tokenize(sample string, segment func(string) []string) []string {
+	if n == nil {
+		return segment(sample)
+	}
+
+	var tokens []string
+	start := 0
+	for _, match := range n.re.FindAllStringSubmatchIndex(sample, -1) {
+		stage := n.matchedStage(match)
+		text := sample[match[0]:match[1]]
+		if (stage == NormalizeNumbers || stage == NormalizeMoney) && touchesLetter(sample, match[0], match[1]) {
+			// Digits inside words such as "mp3" are left to the tokenizer.
+			continue
+		}
+		tokens = append(tokens, segment(sample[start:match[0]])...)
+		tokens = append(tokens, spanTokens(stage, text)...)
+		start = match[1]
+	}
+	return append(tokens, segment(sample[start:])...)
+}
+
+// matchedStage returns the stage whose capture group produced match.
+func (n *textNormalizer) matchedStage(match []int) string {
+	i := 0
+	for match[2*i+2] < 0 {
+		i++
+	}
+	return n.stages[i]
+}
+
+// spanTokens returns the tokens that replace text matched by stage.
+func spanTokens(stage, text string) []string {
+	switch stage {
+	case NormalizeEmails:
+		_, domain, _ := strings.Cut(text, "@")
+		return []string{emailToken, strings.ToLower(domain)}
+	case NormalizeURLs:
+		if host := urlHost(text); host != "" {
+			return []string{urlToken, host}
+		}
+		return []string{urlToken}
+	case NormalizeMoney:
+		return []string{moneyToken}
+	case NormalizeNumbers:
+		return []string{numToken}
+	default:
+		return []string{text}
+	}
+}
+
+// urlHost returns the lowercased host of a link, without "www." or a port.
+func urlHost(link string) string {
+	if _, rest, ok := strings.Cut(link, "://"); ok {
+		link = rest
+	}
+	if i := strings.IndexAny(link, "/?#"); i >= 0 {
+		link = link[:i]
+	}
+	if i := strings.LastIndex(link, "@"); i >= 0 {
+		link = link[i+1:]
+	}
+	if i := strings.LastIndex(link, ":"); i >= 0 {
+		link = link[:i]
+	}
+	link = strings.ToLower(strings.TrimRight(link, ".,;!?)"))
+	return strings.TrimPrefix(link, "www.")
+}
+
+// touchesLetter reports whether the span sample[start:end] is directly
+// preceded or followed by a letter.
+func touchesLetter(sample string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(sample[:start]); unicode.IsLetter(before) {
+		return true
+	}
+	after, _ := utf8.DecodeRuneInString(sample[end:])
+	return unicode.IsLetter(after)
+}
@@ -5,14 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
-	"strings"
+	"slices"
+	"time"
 
-	"github.com/hickeroar/gobayes/v3/bayes/category"
+	"github.com/hickeroar/gobayes/v4/bayes/category"
 )
 
-const persistedModelVersion = 1
+// persistedModelVersion is the version written by Save. Version 3 allows
+// fractional counts (from Decay or weighted training), version 2 added per-category document
+// statistics, and older models are migrated on Load.
+const persistedModelVersion = 3
 const defaultModelFilePath = "/tmp/gobayes-model.json"
 
 type tempFile interface {
@@ -30,20 +35,41 @@ var (
 	errInvalidCategoryName  = errors.New("invalid category name in persisted model")
 	errInvalidTokenCount    = errors.New("invalid token count in persisted model")
 	errInvalidCategoryTally = errors.New("invalid category tally in persisted model")
+	errInvalidScoring       = errors.New("invalid scoring options in persisted model")
+	errInvalidDocumentCount = errors.New("invalid document count in persisted model")
+	errInvalidTokenizer     = errors.New("invalid tokenizer config in persisted model")
 	createTemp              = func(dir, pattern string) (tempFile, error) { return os.CreateTemp(dir, pattern) }
 	renameFile              = os.Rename
 	removeFile              = os.Remove
 )
 
 type persistedTokenizer struct {
-	Language        string `json:"language"`
-	RemoveStopWords bool   `json:"removeStopWords"`
+	Language          string          `json:"language,omitempty"`
+	RemoveStopWords   bool            `json:"removeStopWords"`
+	NGrams            int             `json:"ngrams,omitempty"`
+	Tokenizer         string          `json:"tokenizer,omitempty"` // built-in or registered tokenizer name
+	CharNGramMin      int             `json:"charNGramMin,omitempty"`
+	CharNGramMax      int             `json:"charNGramMax,omitempty"`
+	Config            json.RawMessage `json:"config,omitempty"` // registered tokenizer config
+	DetectLanguage    bool            `json:"detectLanguage,omitempty"`
+	ExtraStopWords    []string        `json:"extraStopWords,omitempty"`
+	ExcludedStopWords []string        `json:"excludedStopWords,omitempty"`
+	Normalizers       []string        `json:"normalizers,omitempty"`
+}
+
+type persistedScoring struct {
+	Mode           ScoringMode `json:"mode"`
+	Smoothing      float64     `json:"smoothing"`
+	VocabularySize int         `json:"vocabularySize,omitempty"`
+	Prior          PriorSource `json:"prior,omitempty"`
 }
 
 type modelState struct {
-	Version    int                                    `json:"version"`
+	Version    int                                   `json:"version"`
 	Categories map[string]category.PersistedCategory `json:"categories"`
 	Tokenizer  *persistedTokenizer                   `json:"tokenizer,omitempty"`
+	Scoring    *persistedScoring                     `json:"scoring,omitempty"`
+	DecayedAt  time.Time                             `json:"decayedAt,omitzero"`
 }
 
 // Save writes classifier model data to a writer using JSON encoding.
@@ -56,13 +82,40 @@ func (c *Classifier) Save(w io.Writer) error {
 	state := modelState{
 		Version:    persistedModelVersion,
 		Categories: c.categories.ExportStates(),
+		DecayedAt:  c.decayedAt,
 	}
-	if c.tokenizerLang != "" {
+	if opts := c.tokenizerOptions; isRegisteredTokenizerName(opts.Tokenizer) {
+		state.Tokenizer = &persistedTokenizer{
+			Tokenizer: opts.Tokenizer,
+			Config:    c.tokenizerConfig,
+		}
+	} else if opts.Language != "" {
 		state.Tokenizer = &persistedTokenizer{
-			Language:        c.tokenizerLang,
-			RemoveStopWords: c.tokenizerRemoveStopWords,
+			Language:          opts.Language,
+			RemoveStopWords:   opts.RemoveStopWords,
+			DetectLanguage:    opts.DetectLanguage,
+			ExtraStopWords:    opts.ExtraStopWords,
+			ExcludedStopWords: opts.ExcludedStopWords,
+			Normalizers:       opts.Normalizers,
+		}
+		if opts.NGrams > 1 {
+			state.Tokenizer.NGrams = opts.NGrams
+		}
+		if opts.Tokenizer != TokenizerWords {
+			state.Tokenizer.Tokenizer = opts.Tokenizer
+			state.Tokenizer.CharNGramMin = opts.CharNGramMin
+			state.Tokenizer.CharNGramMax = opts.CharNGramMax
 		}
 	}
+	// Scoring is always written, even when it is the default, so that the
+	// file alone determines scoring after Load.
+	opts := c.scoringUnlocked()
+	state.Scoring = &persistedScoring{
+		Mode:           opts.Mode,
+		Smoothing:      opts.Smoothing,
+		VocabularySize: opts.VocabularySize,
+		Prior:          opts.Prior,
+	}
 	c.mu.RUnlock()
 
 	if err := json.NewEncoder(w).Encode(state); err != nil {
@@ -73,6 +126,8 @@ func (c *Classifier) Save(w io.Writer) error {
 }
 
 // Load reads classifier model data from a JSON reader and replaces state.
+// The model's scoring options replace the classifier's; a model without
+// them, saved by an older version, loads with default scoring.
 func (c *Classifier) Load(r io.Reader) error {
 	if r == nil {
 		return errNilReader
@@ -88,22 +143,67 @@ func (c *Classifier) Load(r io.Reader) error {
 	if err := validateModelState(state); err != nil {
 		return err
 	}
+	migrateModelState(&state)
+
+	// Models saved before scoring was always written omit it when it was
+	// the default, so a missing block means default scoring.
+	var persisted persistedScoring
+	if state.Scoring != nil {
+		persisted = *state.Scoring
+	}
+	scoring, err := ScoringOptions{
+		Mode:           persisted.Mode,
+		Smoothing:      persisted.Smoothing,
+		VocabularySize: persisted.VocabularySize,
+		Prior:          persisted.Prior,
+	}.normalize()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidScoring, err)
+	}
+
+	var registered func(string) []string
+	if tok := state.Tokenizer; tok != nil && isRegisteredTokenizerName(tok.Tokenizer) {
+		tokenizer, err := buildRegisteredTokenizer(tok.Tokenizer, tok.Config)
+		if err != nil {
+			return err
+		}
+		registered = tokenizer
+	}
 
 	cats := category.NewCategories()
 	_ = cats.ReplaceStates(state.Categories)
-	cats.EnsureCategoryProbabilities()
 
 	c.mu.Lock()
 	c.categories = *cats
-	if state.Tokenizer != nil {
-		lang := strings.ToLower(strings.TrimSpace(state.Tokenizer.Language))
-		if lang == "" {
-			lang = "english"
-		}
-		c.Tokenizer = NewDefaultTokenizer(lang, state.Tokenizer.RemoveStopWords)
-		c.tokenizerLang = lang
-		c.tokenizerRemoveStopWords = state.Tokenizer.RemoveStopWords
+	if registered != nil {
+		c.Tokenizer = registered
+		c.languageTokenizers = nil
+		c.tokenizerOptions = TokenizerOptions{Tokenizer: state.Tokenizer.Tokenizer}
+		c.tokenizerConfig = cloneConfig(state.Tokenizer.Config)
+	} else if state.Tokenizer != nil {
+		opts := TokenizerOptions{
+			Language:          state.Tokenizer.Language,
+			RemoveStopWords:   state.Tokenizer.RemoveStopWords,
+			NGrams:            state.Tokenizer.NGrams,
+			Tokenizer:         state.Tokenizer.Tokenizer,
+			CharNGramMin:      state.Tokenizer.CharNGramMin,
+			CharNGramMax:      state.Tokenizer.CharNGramMax,
+			DetectLanguage:    state.Tokenizer.DetectLanguage,
+			ExtraStopWords:    state.Tokenizer.ExtraStopWords,
+			ExcludedStopWords: state.Tokenizer.ExcludedStopWords,
+			Normalizers:       state.Tokenizer.Normalizers,
+		}.normalize()
+		c.Tokenizer, c.languageTokenizers = newClassifierTokenizer(opts)
+		c.tokenizerOptions = opts
+		c.tokenizerConfig = nil
+	}
+	if registered != nil || state.Tokenizer != nil {
+		c.tokenizerEpoch++
 	}
+	c.scoring = scoring
+	c.applyPriorSource()
+	c.decayedAt = state.DecayedAt
+	c.revision++
 	c.mu.Unlock()
 
 	return nil
@@ -161,35 +261,82 @@ func (c *Classifier) LoadFromFile(path string) error {
 
 // validateModelState validates persisted classifier state before loading.
 func validateModelState(state modelState) error {
-	if state.Version != persistedModelVersion {
+	if state.Version < 1 || state.Version > persistedModelVersion {
 		return fmt.Errorf("%w: %d", errUnsupportedVersion, state.Version)
 	}
+	if tok := state.Tokenizer; tok != nil {
+		if tok.NGrams < 0 || tok.CharNGramMin < 0 || tok.CharNGramMax < 0 {
+			return fmt.Errorf("%w: ngrams=%d charNGramMin=%d charNGramMax=%d", errInvalidTokenizer, tok.NGrams, tok.CharNGramMin, tok.CharNGramMax)
+		}
+		for _, stage := range tok.Normalizers {
+			if !slices.Contains(normalizerStages, stage) {
+				return fmt.Errorf("%w: normalizer %q", errInvalidTokenizer, stage)
+			}
+		}
+		if !isRegisteredTokenizerName(tok.Tokenizer) && len(tok.Config) > 0 {
+			return fmt.Errorf("%w: config is only valid for registered tokenizers", errInvalidTokenizer)
+		}
+	}
 
 	for name, cat := range state.Categories {
 		if !categoryNamePattern.MatchString(name) {
 			return fmt.Errorf("%w: %q", errInvalidCategoryName, name)
 		}
 
-		if cat.Tally < 0 {
-			return fmt.Errorf("%w for %q: %d", errInvalidCategoryTally, name, cat.Tally)
+		// Counts only became fractional in version 3.
+		whole := func(count float64) bool { return state.Version >= 3 || count == math.Trunc(count) }
+
+		if !(cat.Tally >= 0) || !whole(cat.Tally) {
+			return fmt.Errorf("%w for %q: %v", errInvalidCategoryTally, name, cat.Tally)
 		}
 
-		sum := 0
+		sum := 0.0
 		for token, count := range cat.Tokens {
-			if token == "" || count <= 0 {
-				return fmt.Errorf("%w for %q token %q: %d", errInvalidTokenCount, name, token, count)
+			if token == "" || count <= 0 || !whole(count) {
+				return fmt.Errorf("%w for %q token %q: %v", errInvalidTokenCount, name, token, count)
 			}
 			sum += count
 		}
 
-		if sum != cat.Tally {
-			return fmt.Errorf("%w for %q: tally=%d sum=%d", errInvalidCategoryTally, name, cat.Tally, sum)
+		if !category.TallyMatches(cat.Tally, sum) {
+			return fmt.Errorf("%w for %q: tally=%v sum=%v", errInvalidCategoryTally, name, cat.Tally, sum)
+		}
+
+		if !(cat.Documents >= 0) || math.IsInf(cat.Documents, 0) || !whole(cat.Documents) {
+			return fmt.Errorf("%w for %q: %v", errInvalidDocumentCount, name, cat.Documents)
+		}
+		for token, documents := range cat.DocumentFrequencies {
+			if documents <= 0 || documents > cat.Tokens[token] || documents > cat.Documents || !whole(documents) {
+				return fmt.Errorf("%w for %q token %q: %v", errInvalidDocumentCount, name, token, documents)
+			}
 		}
 	}
 
 	return nil
 }
 
+// migrateModelState upgrades a validated state to the current model version.
+// Version 2 counts are whole numbers and load unchanged as fractional counts.
+func migrateModelState(state *modelState) {
+	if state.Version == 1 {
+		// Version 1 did not record documents. Approximate each token's
+		// document frequency by its count, as if every occurrence came from a
+		// different document, and the category's document count by the
+		// largest of those frequencies, the fewest documents consistent with
+		// them.
+		for name, cat := range state.Categories {
+			cat.DocumentFrequencies = make(map[string]float64, len(cat.Tokens))
+			cat.Documents = 0
+			for token, count := range cat.Tokens {
+				cat.DocumentFrequencies[token] = count
+				cat.Documents = max(cat.Documents, count)
+			}
+			state.Categories[name] = cat
+		}
+	}
+	state.Version = persistedModelVersion
+}
+
 // resolveModelPath returns the default model path when no path is provided.
 func resolveModelPath(path string) string {
 	if path == "" {
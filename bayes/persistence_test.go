@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
-	"github.com/hickeroar/gobayes/v3/bayes/category"
+	"github.com/hickeroar/gobayes/v4/bayes/category"
 )
 
 // TestPersistenceRoundTrip verifies persistence round trip.
@@ -74,10 +77,10 @@ func TestPersistenceRoundTripWithTokenizerConfig(t *testing.T) {
 		t.Fatalf("load failed: %v", err)
 	}
 
-	if loaded.tokenizerLang != "spanish" {
-		t.Fatalf("expected tokenizerLang spanish after load, got %q", loaded.tokenizerLang)
+	if loaded.tokenizerOptions.Language != "spanish" {
+		t.Fatalf("expected tokenizerLang spanish after load, got %q", loaded.tokenizerOptions.Language)
 	}
-	if !loaded.tokenizerRemoveStopWords {
+	if !loaded.tokenizerOptions.RemoveStopWords {
 		t.Fatal("expected tokenizerRemoveStopWords true after load")
 	}
 
@@ -87,12 +90,104 @@ func TestPersistenceRoundTripWithTokenizerConfig(t *testing.T) {
 	}
 }
 
+// TestPersistenceRoundTripWithNGrams verifies the n-gram setting is persisted and restored.
+func TestPersistenceRoundTripWithNGrams(t *testing.T) {
+	original := NewClassifierWithOptions("english", false, WithNGrams(2))
+	if err := original.Train("negative", "not good"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"ngrams":2`) {
+		t.Fatalf("expected ngrams in payload, got %s", buf.String())
+	}
+
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if loaded.TokenizerOptions().NGrams != 2 {
+		t.Fatalf("expected ngrams 2 after load, got %+v", loaded.TokenizerOptions())
+	}
+	if !slices.Equal(loaded.Tokenizer("not good"), original.Tokenizer("not good")) {
+		t.Fatalf("expected identical tokenization after load, got %v", loaded.Tokenizer("not good"))
+	}
+
+	var unigram bytes.Buffer
+	if err := NewClassifierWithOptions("english", false).Save(&unigram); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if strings.Contains(unigram.String(), "ngrams") {
+		t.Fatalf("expected default ngrams to be omitted, got %s", unigram.String())
+	}
+
+	payload := `{"version":2,"categories":{},"tokenizer":{"language":"english","removeStopWords":false,"ngrams":-1}}`
+	if err := NewClassifier().Load(strings.NewReader(payload)); !errors.Is(err, errInvalidTokenizer) {
+		t.Fatalf("expected errInvalidTokenizer, got %v", err)
+	}
+}
+
+// TestPersistenceRoundTripWithCharTokenizer verifies the character tokenizer is persisted and restored.
+func TestPersistenceRoundTripWithCharTokenizer(t *testing.T) {
+	original := NewClassifierWithOptions("japanese", false, WithCharNGrams(2, 3))
+	if err := original.Train("weather", "今日は雨が降る"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"tokenizer":"chars","charNGramMin":2,"charNGramMax":3`) {
+		t.Fatalf("expected char tokenizer in payload, got %s", buf.String())
+	}
+
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.TokenizerOptions(), original.TokenizerOptions()) {
+		t.Fatalf("expected %+v after load, got %+v", original.TokenizerOptions(), loaded.TokenizerOptions())
+	}
+	if !slices.Equal(loaded.Tokenizer("雨が降る"), original.Tokenizer("雨が降る")) {
+		t.Fatalf("expected identical tokenization after load, got %v", loaded.Tokenizer("雨が降る"))
+	}
+
+	payload := `{"version":2,"categories":{},"tokenizer":{"language":"english","removeStopWords":false,"tokenizer":"chars","charNGramMin":-1}}`
+	if err := NewClassifier().Load(strings.NewReader(payload)); !errors.Is(err, errInvalidTokenizer) {
+		t.Fatalf("expected errInvalidTokenizer, got %v", err)
+	}
+}
+
+// TestPersistenceRoundTripWithCustomStopWords verifies stop-word customizations are persisted and restored.
+func TestPersistenceRoundTripWithCustomStopWords(t *testing.T) {
+	original := NewClassifierWithOptions("english", true, WithStopWords([]string{"acme"}, []string{"not"}))
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"extraStopWords":["acme"],"excludedStopWords":["not"]`) {
+		t.Fatalf("expected stop-word customizations in payload, got %s", buf.String())
+	}
+
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := loaded.Tokenizer("acme is not here"); !slices.Equal(got, []string{"not"}) {
+		t.Fatalf("expected customized stop words after load, got %v", got)
+	}
+}
+
 // TestLoadWithTokenizerConfigNormalizesLanguage verifies loaded tokenizer language is normalized.
 func TestLoadWithTokenizerConfigNormalizesLanguage(t *testing.T) {
 	state := modelState{
 		Version: persistedModelVersion,
 		Categories: map[string]category.PersistedCategory{
-			"spam": {Tokens: map[string]int{"buy": 1}, Tally: 1},
+			"spam": {Tokens: map[string]float64{"buy": 1}, Tally: 1},
 		},
 		Tokenizer: &persistedTokenizer{Language: "  SPANISH  ", RemoveStopWords: false},
 	}
@@ -105,8 +200,8 @@ func TestLoadWithTokenizerConfigNormalizesLanguage(t *testing.T) {
 	if err := c.Load(&buf); err != nil {
 		t.Fatalf("load failed: %v", err)
 	}
-	if c.tokenizerLang != "spanish" {
-		t.Fatalf("expected normalized lang spanish, got %q", c.tokenizerLang)
+	if c.tokenizerOptions.Language != "spanish" {
+		t.Fatalf("expected normalized lang spanish, got %q", c.tokenizerOptions.Language)
 	}
 }
 
@@ -115,7 +210,7 @@ func TestLoadWithTokenizerEmptyLanguageDefaultsToEnglish(t *testing.T) {
 	state := modelState{
 		Version: persistedModelVersion,
 		Categories: map[string]category.PersistedCategory{
-			"spam": {Tokens: map[string]int{"buy": 1}, Tally: 1},
+			"spam": {Tokens: map[string]float64{"buy": 1}, Tally: 1},
 		},
 		Tokenizer: &persistedTokenizer{Language: "", RemoveStopWords: false},
 	}
@@ -128,8 +223,8 @@ func TestLoadWithTokenizerEmptyLanguageDefaultsToEnglish(t *testing.T) {
 	if err := c.Load(&buf); err != nil {
 		t.Fatalf("load failed: %v", err)
 	}
-	if c.tokenizerLang != "english" {
-		t.Fatalf("expected default lang english for empty, got %q", c.tokenizerLang)
+	if c.tokenizerOptions.Language != "english" {
+		t.Fatalf("expected default lang english for empty, got %q", c.tokenizerOptions.Language)
 	}
 }
 
@@ -176,7 +271,7 @@ func TestLoadRejectsInvalidPersistedState(t *testing.T) {
 			state: modelState{
 				Version: persistedModelVersion,
 				Categories: map[string]category.PersistedCategory{
-					"spam!": {Tokens: map[string]int{"buy": 1}, Tally: 1},
+					"spam!": {Tokens: map[string]float64{"buy": 1}, Tally: 1},
 				},
 			},
 		},
@@ -185,7 +280,7 @@ func TestLoadRejectsInvalidPersistedState(t *testing.T) {
 			state: modelState{
 				Version: persistedModelVersion,
 				Categories: map[string]category.PersistedCategory{
-					"spam": {Tokens: map[string]int{"buy": -1}, Tally: 0},
+					"spam": {Tokens: map[string]float64{"buy": -1}, Tally: 0},
 				},
 			},
 		},
@@ -194,7 +289,7 @@ func TestLoadRejectsInvalidPersistedState(t *testing.T) {
 			state: modelState{
 				Version: persistedModelVersion,
 				Categories: map[string]category.PersistedCategory{
-					"spam": {Tokens: map[string]int{"buy": 1}, Tally: -1},
+					"spam": {Tokens: map[string]float64{"buy": 1}, Tally: -1},
 				},
 			},
 		},
@@ -203,7 +298,7 @@ func TestLoadRejectsInvalidPersistedState(t *testing.T) {
 			state: modelState{
 				Version: persistedModelVersion,
 				Categories: map[string]category.PersistedCategory{
-					"spam": {Tokens: map[string]int{"": 1}, Tally: 1},
+					"spam": {Tokens: map[string]float64{"": 1}, Tally: 1},
 				},
 			},
 		},
@@ -212,7 +307,70 @@ func TestLoadRejectsInvalidPersistedState(t *testing.T) {
 			state: modelState{
 				Version: persistedModelVersion,
 				Categories: map[string]category.PersistedCategory{
-					"spam": {Tokens: map[string]int{"buy": 2}, Tally: 1},
+					"spam": {Tokens: map[string]float64{"buy": 2}, Tally: 1},
+				},
+			},
+		},
+		{
+			name: "negative document count",
+			state: modelState{
+				Version: persistedModelVersion,
+				Categories: map[string]category.PersistedCategory{
+					"spam": {Tokens: map[string]float64{"buy": 1}, Tally: 1, Documents: -1},
+				},
+			},
+		},
+		{
+			name: "document frequency exceeds token count",
+			state: modelState{
+				Version: persistedModelVersion,
+				Categories: map[string]category.PersistedCategory{
+					"spam": {Tokens: map[string]float64{"buy": 1}, Tally: 1, Documents: 2, DocumentFrequencies: map[string]float64{"buy": 2}},
+				},
+			},
+		},
+		{
+			name: "document frequency exceeds document count",
+			state: modelState{
+				Version: persistedModelVersion,
+				Categories: map[string]category.PersistedCategory{
+					"spam": {Tokens: map[string]float64{"buy": 3}, Tally: 3, Documents: 1, DocumentFrequencies: map[string]float64{"buy": 2}},
+				},
+			},
+		},
+		{
+			name: "document frequency for unknown token",
+			state: modelState{
+				Version: persistedModelVersion,
+				Categories: map[string]category.PersistedCategory{
+					"spam": {Tokens: map[string]float64{"buy": 1}, Tally: 1, Documents: 1, DocumentFrequencies: map[string]float64{"sell": 1}},
+				},
+			},
+		},
+		{
+			name: "fractional token count before version 3",
+			state: modelState{
+				Version: 2,
+				Categories: map[string]category.PersistedCategory{
+					"spam": {Tokens: map[string]float64{"buy": 1.5}, Tally: 1.5, Documents: 1, DocumentFrequencies: map[string]float64{"buy": 1}},
+				},
+			},
+		},
+		{
+			name: "fractional document count before version 3",
+			state: modelState{
+				Version: 2,
+				Categories: map[string]category.PersistedCategory{
+					"spam": {Tokens: map[string]float64{"buy": 1}, Tally: 1, Documents: 0.5},
+				},
+			},
+		},
+		{
+			name: "version zero",
+			state: modelState{
+				Version: 0,
+				Categories: map[string]category.PersistedCategory{
+					"spam": {Tokens: map[string]float64{"buy": 1}, Tally: 1},
 				},
 			},
 		},
@@ -221,7 +379,7 @@ func TestLoadRejectsInvalidPersistedState(t *testing.T) {
 			state: modelState{
 				Version: persistedModelVersion + 1,
 				Categories: map[string]category.PersistedCategory{
-					"spam": {Tokens: map[string]int{"buy": 1}, Tally: 1},
+					"spam": {Tokens: map[string]float64{"buy": 1}, Tally: 1},
 				},
 			},
 		},
@@ -242,6 +400,21 @@ func TestLoadRejectsInvalidPersistedState(t *testing.T) {
 	}
 }
 
+// TestValidateModelStateRejectsNonFiniteCounts verifies NaN and infinite
+// tallies and document counts are rejected.
+func TestValidateModelStateRejectsNonFiniteCounts(t *testing.T) {
+	for _, cat := range []category.PersistedCategory{
+		{Tally: math.NaN()},
+		{Documents: math.NaN()},
+		{Documents: math.Inf(1)},
+	} {
+		state := modelState{Version: persistedModelVersion, Categories: map[string]category.PersistedCategory{"spam": cat}}
+		if err := validateModelState(state); err == nil {
+			t.Fatalf("expected error for %+v", cat)
+		}
+	}
+}
+
 // TestEmptyModelRoundTrip verifies empty model round trip.
 func TestEmptyModelRoundTrip(t *testing.T) {
 	classifier := NewClassifier()
@@ -485,3 +658,78 @@ func TestSaveToFileSyncCloseAndRenameErrors(t *testing.T) {
 		t.Fatal("expected rename error from SaveToFile")
 	}
 }
+
+// TestLoadMigratesVersionOneModel verifies version 1 models gain approximate document statistics.
+func TestLoadMigratesVersionOneModel(t *testing.T) {
+	payload := `{"version":1,"categories":{"spam":{"Tokens":{"free":3,"prize":1},"Tally":4}}}`
+
+	classifier := NewClassifier()
+	if err := classifier.Load(strings.NewReader(payload)); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	spam, ok := classifier.categories.LookupCategory("spam")
+	if !ok {
+		t.Fatal("expected spam category after migration")
+	}
+	if spam.GetDocumentCount() != 3 || spam.GetTokenDocumentCount("free") != 3 || spam.GetTokenDocumentCount("prize") != 1 {
+		t.Fatalf("unexpected migrated document stats: docs=%v free=%v prize=%v", spam.GetDocumentCount(), spam.GetTokenDocumentCount("free"), spam.GetTokenDocumentCount("prize"))
+	}
+
+	var buf bytes.Buffer
+	if err := classifier.Save(&buf); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	var state modelState
+	if err := json.Unmarshal(buf.Bytes(), &state); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if state.Version != persistedModelVersion || state.Categories["spam"].Documents != 3 {
+		t.Fatalf("expected migrated model to save as version %d with documents, got %+v", persistedModelVersion, state)
+	}
+}
+
+// TestDocumentStatisticsRoundTrip verifies document statistics survive Save and Load.
+func TestDocumentStatisticsRoundTrip(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.Train("spam", "free prize free"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := classifier.Train("spam", "free offer"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := classifier.Save(&buf); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	spam, _ := loaded.categories.LookupCategory("spam")
+	if spam.GetDocumentCount() != 2 || spam.GetTokenDocumentCount("free") != 2 || spam.GetTokenDocumentCount("prize") != 1 {
+		t.Fatalf("unexpected document stats after round trip: docs=%v free=%v prize=%v", spam.GetDocumentCount(), spam.GetTokenDocumentCount("free"), spam.GetTokenDocumentCount("prize"))
+	}
+}
+
+// TestWeightedCountsRoundTrip verifies non-unit counts from weighted training
+// survive Save and Load.
+func TestWeightedCountsRoundTrip(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.TrainWeighted("spam", "free prize free", 0.5); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := classifier.Save(&buf); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	spam, _ := loaded.categories.LookupCategory("spam")
+	if spam.GetTokenCount("free") != 1 || spam.GetTally() != 1.5 || spam.GetDocumentCount() != 0.5 || spam.GetTokenDocumentCount("prize") != 0.5 {
+		t.Fatalf("unexpected weighted counts after round trip: free=%v tally=%v docs=%v prize=%v", spam.GetTokenCount("free"), spam.GetTally(), spam.GetDocumentCount(), spam.GetTokenDocumentCount("prize"))
+	}
+}
@@ -0,0 +1,254 @@
+package bayes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hickeroar/gobayes/v4/bayes/category"
+)
+
+// PruneMethod selects how Prune ranks tokens when keeping only the top K.
+type PruneMethod string
+
+const (
+	// PruneChiSquare ranks each token by its highest chi-square statistic for
+	// any one category, computed from per-category document frequencies. This
+	// is the default.
+	PruneChiSquare PruneMethod = "chi2"
+	// PruneMutualInformation ranks each token by the mutual information
+	// between the token appearing in a document and the document's category.
+	PruneMutualInformation PruneMethod = "mi"
+)
+
+// ErrInvalidPruneOptions indicates a negative threshold, an unknown ranking
+// method, or options that select nothing to prune.
+var ErrInvalidPruneOptions = errors.New("invalid prune options")
+
+// PruneOptions configures Prune. At least one of MinCount and TopK must be set.
+type PruneOptions struct {
+	// MinCount removes tokens whose total count across all categories is
+	// below it. Zero disables the check.
+	MinCount int
+	// TopK keeps only the K best-ranked tokens among those MinCount keeps.
+	// Zero keeps them all.
+	TopK int
+	// Method ranks tokens for TopK. Empty means PruneChiSquare.
+	Method PruneMethod
+	// DryRun reports what would be removed without changing the model.
+	DryRun bool
+}
+
+// PruneReport describes the tokens Prune removed, or would remove in a dry run.
+type PruneReport struct {
+	DryRun             bool     `json:"dryRun"`
+	VocabularyBefore   int      `json:"vocabularyBefore"`   // distinct tokens before pruning
+	VocabularyAfter    int      `json:"vocabularyAfter"`    // distinct tokens after pruning
+	RemovedTokenCount  int      `json:"removedTokenCount"`  // distinct tokens removed
+	RemovedOccurrences float64  `json:"removedOccurrences"` // token occurrences removed across categories
+	RemovedTokens      []string `json:"removedTokens"`      // removed tokens, sorted
+	// RemovedCategories lists categories left without tokens, which are
+	// deleted along with them.
+	RemovedCategories []string `json:"removedCategories,omitempty"`
+}
+
+// ParsePruneMethod returns the PruneMethod named by s (case-insensitive).
+// Empty input returns PruneChiSquare.
+func ParsePruneMethod(s string) (PruneMethod, error) {
+	method := PruneMethod(strings.ToLower(strings.TrimSpace(s)))
+	switch method {
+	case "":
+		return PruneChiSquare, nil
+	case PruneChiSquare, PruneMutualInformation:
+		return method, nil
+	default:
+		return "", fmt.Errorf("%w: unknown method %q", ErrInvalidPruneOptions, s)
+	}
+}
+
+// normalize validates opts and fills in defaults.
+func (opts PruneOptions) normalize() (PruneOptions, error) {
+	if opts.MinCount < 0 || opts.TopK < 0 {
+		return PruneOptions{}, fmt.Errorf("%w: minimum count and top K must not be negative", ErrInvalidPruneOptions)
+	}
+	if opts.MinCount == 0 && opts.TopK == 0 {
+		return PruneOptions{}, fmt.Errorf("%w: set a minimum count or top K", ErrInvalidPruneOptions)
+	}
+	method, err := ParsePruneMethod(string(opts.Method))
+	if err != nil {
+		return PruneOptions{}, err
+	}
+	opts.Method = method
+	return opts, nil
+}
+
+// Prune removes rarely seen or uninformative tokens from every category to
+// bound memory and reduce noise. Tokens whose total count is below
+// opts.MinCount are removed first; with opts.TopK set, only the TopK
+// remaining tokens ranked by opts.Method are kept, with ties going to the
+// more frequent token. Categories left without tokens are deleted. With
+// opts.DryRun the model is not changed and the report describes what would
+// be removed.
+func (c *Classifier) Prune(opts PruneOptions) (PruneReport, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return PruneReport{}, err
+	}
+
+	if opts.DryRun {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.planPruneUnlocked(opts), nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := c.planPruneUnlocked(opts)
+	if report.RemovedTokenCount == 0 {
+		return report, nil
+	}
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		for _, token := range report.RemovedTokens {
+			if count := cat.GetTokenCount(token); count > 0 {
+				_ = c.categories.UntrainToken(cat, token, count)
+			}
+		}
+		c.cleanUpCategory(cat)
+	}
+	c.categories.EnsureCategoryProbabilities()
+	c.revision++
+	return report, nil
+}
+
+// planPruneUnlocked selects the tokens opts removes while the classifier
+// lock is held.
+func (c *Classifier) planPruneUnlocked(opts PruneOptions) PruneReport {
+	report := PruneReport{DryRun: opts.DryRun, VocabularyBefore: c.categories.VocabularySize()}
+
+	var kept []string
+	removed := make(map[string]bool)
+	for token, total := range c.categories.Vocabulary() {
+		if total < float64(opts.MinCount) {
+			removed[token] = true
+			continue
+		}
+		kept = append(kept, token)
+	}
+
+	if opts.TopK > 0 && len(kept) > opts.TopK {
+		scores := c.featureScores(kept, opts.Method)
+		sort.Slice(kept, func(i, j int) bool {
+			a, b := kept[i], kept[j]
+			if scores[a] != scores[b] {
+				return scores[a] > scores[b]
+			}
+			if totalA, totalB := c.categories.TokenTotal(a), c.categories.TokenTotal(b); totalA != totalB {
+				return totalA > totalB
+			}
+			return a < b
+		})
+		for _, token := range kept[opts.TopK:] {
+			removed[token] = true
+		}
+	}
+
+	report.RemovedTokens = make([]string, 0, len(removed))
+	for token := range removed {
+		report.RemovedTokens = append(report.RemovedTokens, token)
+		report.RemovedOccurrences += c.categories.TokenTotal(token)
+	}
+	sort.Strings(report.RemovedTokens)
+	report.RemovedTokenCount = len(report.RemovedTokens)
+	report.VocabularyAfter = report.VocabularyBefore - report.RemovedTokenCount
+
+	// A category is deleted when every token it has is removed, which does
+	// not depend on its fractional counts summing back to zero exactly.
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		emptied := true
+		for token := range cat.Tokens() {
+			if !removed[token] {
+				emptied = false
+				break
+			}
+		}
+		if emptied {
+			report.RemovedCategories = append(report.RemovedCategories, name)
+		}
+	}
+	sort.Strings(report.RemovedCategories)
+	return report
+}
+
+// featureScores ranks tokens by how well their presence in a document
+// predicts its category, using per-category document frequencies.
+func (c *Classifier) featureScores(tokens []string, method PruneMethod) map[string]float64 {
+	names := c.categories.Names()
+	cats := make([]*category.Category, len(names))
+	documents := make([]float64, len(names))
+	total := 0.0
+	for i, name := range names {
+		cats[i], _ = c.categories.LookupCategory(name)
+		documents[i] = cats[i].GetDocumentCount()
+		total += documents[i]
+	}
+
+	scores := make(map[string]float64, len(tokens))
+	if total == 0 {
+		return scores
+	}
+	containing := make([]float64, len(cats))
+	for _, token := range tokens {
+		withToken := 0.0
+		for i, cat := range cats {
+			// Untraining can leave frequencies above a category's document count.
+			containing[i] = math.Min(cat.GetTokenDocumentCount(token), documents[i])
+			withToken += containing[i]
+		}
+		if method == PruneMutualInformation {
+			scores[token] = mutualInformation(containing, documents, withToken, total)
+		} else {
+			scores[token] = maxChiSquare(containing, documents, withToken, total)
+		}
+	}
+	return scores
+}
+
+// maxChiSquare returns the largest chi-square statistic of the 2x2
+// token/category document contingency tables over all categories.
+func maxChiSquare(containing, documents []float64, withToken, total float64) float64 {
+	best := 0.0
+	for i := range containing {
+		a := containing[i]            // in category, with token
+		b := withToken - a            // other categories, with token
+		cc := documents[i] - a        // in category, without token
+		d := total - documents[i] - b // other categories, without token
+		denominator := (a + b) * (cc + d) * (a + cc) * (b + d)
+		if denominator == 0 {
+			continue
+		}
+		diff := a*d - b*cc
+		best = math.Max(best, total*diff*diff/denominator)
+	}
+	return best
+}
+
+// mutualInformation returns the mutual information, in nats, between a
+// token's presence in a document and the document's category.
+func mutualInformation(containing, documents []float64, withToken, total float64) float64 {
+	withoutToken := total - withToken
+	info := 0.0
+	for i := range containing {
+		for _, cell := range [][2]float64{{containing[i], withToken}, {documents[i] - containing[i], withoutToken}} {
+			joint, marginal := cell[0], cell[1]
+			if joint > 0 {
+				info += joint / total * math.Log(total*joint/(marginal*documents[i]))
+			}
+		}
+	}
+	return info
+}
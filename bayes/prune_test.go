@@ -0,0 +1,148 @@
+package bayes
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newPruneClassifier returns a classifier where "cheap" and "meet" separate
+// the categories, "the" appears everywhere, and "zyx" was seen once.
+func newPruneClassifier() *Classifier {
+	c := NewClassifierWithTokenizer(strings.Fields)
+	_ = c.Train("spam", "cheap the")
+	_ = c.Train("spam", "cheap the")
+	_ = c.Train("spam", "cheap zyx")
+	_ = c.Train("ham", "meet the")
+	_ = c.Train("ham", "meet the")
+	return c
+}
+
+// TestPruneOptionsValidation verifies invalid prune options are rejected.
+func TestPruneOptionsValidation(t *testing.T) {
+	c := newPruneClassifier()
+	for _, opts := range []PruneOptions{
+		{},
+		{MinCount: -1},
+		{TopK: -1},
+		{TopK: 1, Method: "entropy"},
+	} {
+		if _, err := c.Prune(opts); !errors.Is(err, ErrInvalidPruneOptions) {
+			t.Fatalf("Prune(%+v): expected ErrInvalidPruneOptions, got %v", opts, err)
+		}
+	}
+	if method, err := ParsePruneMethod(" MI "); err != nil || method != PruneMutualInformation {
+		t.Fatalf("unexpected method: %q, %v", method, err)
+	}
+}
+
+// TestPruneMinCount verifies tokens below the minimum total count are removed.
+func TestPruneMinCount(t *testing.T) {
+	c := newPruneClassifier()
+	revision := c.Revision()
+
+	report, err := c.Prune(PruneOptions{MinCount: 2, DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := PruneReport{DryRun: true, VocabularyBefore: 4, VocabularyAfter: 3, RemovedTokenCount: 1, RemovedOccurrences: 1, RemovedTokens: []string{"zyx"}}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("expected %+v, got %+v", want, report)
+	}
+	if c.Revision() != revision || c.categories.TokenTotal("zyx") != 1 {
+		t.Fatal("expected dry run to leave the model unchanged")
+	}
+
+	if _, err := c.Prune(PruneOptions{MinCount: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Revision() == revision || c.categories.TokenTotal("zyx") != 0 || c.Summaries()["spam"].TokenTally != 5 {
+		t.Fatalf("expected zyx to be pruned, got %+v", c.Summaries())
+	}
+
+	revision = c.Revision()
+	if report, _ := c.Prune(PruneOptions{MinCount: 2}); report.RemovedTokenCount != 0 || c.Revision() != revision {
+		t.Fatalf("expected a no-op prune to leave the revision unchanged, got %+v", report)
+	}
+}
+
+// TestPruneTopK verifies both ranking methods keep the most discriminative tokens.
+func TestPruneTopK(t *testing.T) {
+	for _, method := range []PruneMethod{PruneChiSquare, PruneMutualInformation} {
+		t.Run(string(method), func(t *testing.T) {
+			c := newPruneClassifier()
+			report, err := c.Prune(PruneOptions{TopK: 2, Method: method})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(report.RemovedTokens, []string{"the", "zyx"}) {
+				t.Fatalf("expected the and zyx to be removed, got %v", report.RemovedTokens)
+			}
+			if c.categories.VocabularySize() != 2 || c.Classify("cheap").Category != "spam" || c.Classify("meet").Category != "ham" {
+				t.Fatalf("unexpected model after pruning: %+v", c.Summaries())
+			}
+		})
+	}
+}
+
+// TestPruneRemovesEmptiedCategories verifies categories left without tokens are deleted.
+func TestPruneRemovesEmptiedCategories(t *testing.T) {
+	c := newPruneClassifier()
+	_ = c.Train("rare", "once")
+
+	report, err := c.Prune(PruneOptions{MinCount: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(report.RemovedCategories, []string{"rare"}) {
+		t.Fatalf("expected rare to be reported, got %v", report.RemovedCategories)
+	}
+	if _, ok := c.Summaries()["rare"]; ok {
+		t.Fatal("expected rare to be deleted")
+	}
+}
+
+// TestPruneRemovesEmptiedFractionalCategories verifies a category is reported
+// as deleted even when its fractional counts do not sum back to zero exactly.
+func TestPruneRemovesEmptiedFractionalCategories(t *testing.T) {
+	c := newPruneClassifier()
+	_ = c.TrainWeighted("rare", "once", 0.1)
+	_ = c.TrainWeighted("rare", "twice", 0.2)
+
+	report, err := c.Prune(PruneOptions{MinCount: 1, DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(report.RemovedCategories, []string{"rare"}) {
+		t.Fatalf("expected rare to be reported, got %v", report.RemovedCategories)
+	}
+}
+
+// TestPruneTopKTies verifies tokens that score the same are ranked by total
+// count and then by name, including when no documents were recorded.
+func TestPruneTopKTies(t *testing.T) {
+	c := NewClassifierWithTokenizer(strings.Fields)
+	_ = c.Train("spam", "b a a")
+	_ = c.Train("spam", "c b a")
+	report, err := c.Prune(PruneOptions{TopK: 2, DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(report.RemovedTokens, []string{"c"}) {
+		t.Fatalf("expected the least frequent token to be removed, got %v", report.RemovedTokens)
+	}
+
+	model := `{"version":3,"categories":{"spam":{"Tally":2,"Tokens":{"a":1,"b":1},"Documents":0,"DocumentFrequencies":{}}}}`
+	if err := c.Load(strings.NewReader(model)); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	report, err = c.Prune(PruneOptions{TopK: 1, DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(report.RemovedTokens, []string{"b"}) {
+		t.Fatalf("expected ties to be broken by name, got %v", report.RemovedTokens)
+	}
+}
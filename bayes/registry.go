@@ -0,0 +1,123 @@
+package bayes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TokenizerFactory builds a tokenizer from its JSON config. config is nil when
+// the tokenizer was created without one.
+type TokenizerFactory func(config json.RawMessage) (func(string) []string, error)
+
+// ErrTokenizerNotRegistered indicates a tokenizer name with no registered factory.
+var ErrTokenizerNotRegistered = errors.New("tokenizer not registered")
+
+var (
+	tokenizerRegistryMu sync.RWMutex
+	tokenizerRegistry   = make(map[string]TokenizerFactory)
+)
+
+// RegisterTokenizer makes a custom tokenizer available by name to
+// NewClassifierWithRegisteredTokenizer and to Load, which rebuilds it from the
+// name and config stored in the model. Register tokenizers before loading
+// models that use them, typically from an init function. The built-in names
+// TokenizerWords and TokenizerChars are reserved, and a name can only be
+// registered once.
+func RegisterTokenizer(name string, factory TokenizerFactory) error {
+	if strings.TrimSpace(name) == "" || name != strings.TrimSpace(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidTokenizer, name)
+	}
+	if _, err := ParseTokenizer(name); err == nil {
+		return fmt.Errorf("%w: %q is a built-in tokenizer", ErrInvalidTokenizer, name)
+	}
+	if factory == nil {
+		return fmt.Errorf("%w: nil factory for %q", ErrInvalidTokenizer, name)
+	}
+
+	tokenizerRegistryMu.Lock()
+	defer tokenizerRegistryMu.Unlock()
+	if _, exists := tokenizerRegistry[name]; exists {
+		return fmt.Errorf("%w: %q is already registered", ErrInvalidTokenizer, name)
+	}
+	tokenizerRegistry[name] = factory
+	return nil
+}
+
+// RegisteredTokenizers returns the names of all registered tokenizers in no
+// particular order.
+func RegisteredTokenizers() []string {
+	tokenizerRegistryMu.RLock()
+	defer tokenizerRegistryMu.RUnlock()
+
+	names := make([]string, 0, len(tokenizerRegistry))
+	for name := range tokenizerRegistry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// buildRegisteredTokenizer returns the tokenizer the factory registered as
+// name builds from config.
+func buildRegisteredTokenizer(name string, config json.RawMessage) (func(string) []string, error) {
+	tokenizerRegistryMu.RLock()
+	factory, ok := tokenizerRegistry[name]
+	tokenizerRegistryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrTokenizerNotRegistered, name)
+	}
+	if len(config) > 0 && !json.Valid(config) {
+		return nil, fmt.Errorf("%w: config for %q is not valid JSON", ErrInvalidTokenizer, name)
+	}
+
+	tokenizer, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidTokenizer, name, err)
+	}
+	if tokenizer == nil {
+		return nil, fmt.Errorf("%w: factory for %q returned nil", ErrInvalidTokenizer, name)
+	}
+	return tokenizer, nil
+}
+
+// NewClassifierWithRegisteredTokenizer returns a Classifier that uses the
+// tokenizer registered as name, built from config (which may be nil). The name
+// and config are persisted on Save, and Load rebuilds the tokenizer through
+// the registry.
+func NewClassifierWithRegisteredTokenizer(name string, config json.RawMessage) (*Classifier, error) {
+	tokenizer, err := buildRegisteredTokenizer(name, config)
+	if err != nil {
+		return nil, err
+	}
+	c := NewClassifierWithTokenizer(tokenizer)
+	c.tokenizerOptions = TokenizerOptions{Tokenizer: name}
+	c.tokenizerConfig = cloneConfig(config)
+	return c, nil
+}
+
+// TokenizerConfig returns the JSON config of a registered tokenizer, or nil.
+func (c *Classifier) TokenizerConfig() json.RawMessage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return cloneConfig(c.tokenizerConfig)
+}
+
+// cloneConfig copies config so callers cannot modify stored bytes.
+func cloneConfig(config json.RawMessage) json.RawMessage {
+	if len(config) == 0 {
+		return nil
+	}
+	return append(json.RawMessage(nil), config...)
+}
+
+// isRegisteredTokenizerName reports whether name refers to the registry
+// rather than a built-in tokenizer.
+func isRegisteredTokenizerName(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, err := ParseTokenizer(name)
+	return err != nil
+}
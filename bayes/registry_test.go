@@ -0,0 +1,151 @@
+package bayes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// splitTokenizerFactory builds a tokenizer that splits on a configured separator.
+func splitTokenizerFactory(config json.RawMessage) (func(string) []string, error) {
+	cfg := struct {
+		Separator string `json:"separator"`
+	}{Separator: ","}
+	if config != nil {
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Separator == "" {
+		return nil, errors.New("separator must not be empty")
+	}
+	return func(text string) []string {
+		return strings.Split(text, cfg.Separator)
+	}, nil
+}
+
+// TestRegisterTokenizer verifies registration rules.
+func TestRegisterTokenizer(t *testing.T) {
+	if err := RegisterTokenizer("test-register", splitTokenizerFactory); err != nil {
+		t.Fatalf("unexpected register error: %v", err)
+	}
+	if !slices.Contains(RegisteredTokenizers(), "test-register") {
+		t.Fatalf("expected test-register in %v", RegisteredTokenizers())
+	}
+
+	for _, name := range []string{"test-register", "", " padded", "words", "Chars"} {
+		if err := RegisterTokenizer(name, splitTokenizerFactory); !errors.Is(err, ErrInvalidTokenizer) {
+			t.Fatalf("expected ErrInvalidTokenizer registering %q, got %v", name, err)
+		}
+	}
+	if err := RegisterTokenizer("test-nil-factory", nil); !errors.Is(err, ErrInvalidTokenizer) {
+		t.Fatalf("expected ErrInvalidTokenizer for nil factory, got %v", err)
+	}
+}
+
+// TestNewClassifierWithRegisteredTokenizer verifies construction from the registry.
+func TestNewClassifierWithRegisteredTokenizer(t *testing.T) {
+	if err := RegisterTokenizer("test-construct", splitTokenizerFactory); err != nil {
+		t.Fatalf("unexpected register error: %v", err)
+	}
+
+	c, err := NewClassifierWithRegisteredTokenizer("test-construct", json.RawMessage(`{"separator":"|"}`))
+	if err != nil {
+		t.Fatalf("unexpected constructor error: %v", err)
+	}
+	if got := c.Tokenizer("a|b"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("expected configured separator, got %v", got)
+	}
+	if opts := c.TokenizerOptions(); !reflect.DeepEqual(opts, TokenizerOptions{Tokenizer: "test-construct"}) {
+		t.Fatalf("unexpected tokenizer options: %+v", opts)
+	}
+	if string(c.TokenizerConfig()) != `{"separator":"|"}` {
+		t.Fatalf("unexpected tokenizer config: %s", c.TokenizerConfig())
+	}
+
+	if _, err := NewClassifierWithRegisteredTokenizer("test-missing", nil); !errors.Is(err, ErrTokenizerNotRegistered) {
+		t.Fatalf("expected ErrTokenizerNotRegistered, got %v", err)
+	}
+	for _, config := range []string{`{"separator":""}`, `{"separator":`} {
+		if _, err := NewClassifierWithRegisteredTokenizer("test-construct", json.RawMessage(config)); !errors.Is(err, ErrInvalidTokenizer) {
+			t.Fatalf("expected ErrInvalidTokenizer for config %s, got %v", config, err)
+		}
+	}
+	nilFactory := func(json.RawMessage) (func(string) []string, error) { return nil, nil }
+	if err := RegisterTokenizer("test-nil-tokenizer", nilFactory); err != nil {
+		t.Fatalf("unexpected register error: %v", err)
+	}
+	if _, err := NewClassifierWithRegisteredTokenizer("test-nil-tokenizer", nil); !errors.Is(err, ErrInvalidTokenizer) {
+		t.Fatalf("expected ErrInvalidTokenizer for a nil tokenizer, got %v", err)
+	}
+}
+
+// TestRegisteredTokenizerRoundTrip verifies Save stores the name and config and Load rebuilds the tokenizer.
+func TestRegisteredTokenizerRoundTrip(t *testing.T) {
+	if err := RegisterTokenizer("test-roundtrip", splitTokenizerFactory); err != nil {
+		t.Fatalf("unexpected register error: %v", err)
+	}
+	original, err := NewClassifierWithRegisteredTokenizer("test-roundtrip", json.RawMessage(`{"separator":";"}`))
+	if err != nil {
+		t.Fatalf("unexpected constructor error: %v", err)
+	}
+	if err := original.Train("spam", "buy now;cheap pills"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"tokenizer":{"removeStopWords":false,"tokenizer":"test-roundtrip","config":{"separator":";"}}`) {
+		t.Fatalf("expected registered tokenizer in payload, got %s", buf.String())
+	}
+	payload := buf.String()
+
+	loaded := NewClassifierWithOptions("spanish", true)
+	if err := loaded.Load(strings.NewReader(payload)); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := loaded.Tokenizer("buy now;cheap pills"); !slices.Equal(got, []string{"buy now", "cheap pills"}) {
+		t.Fatalf("expected registered tokenizer after load, got %v", got)
+	}
+	if !reflect.DeepEqual(loaded.TokenizerOptions(), original.TokenizerOptions()) || string(loaded.TokenizerConfig()) != `{"separator":";"}` {
+		t.Fatalf("unexpected tokenizer after load: %+v %s", loaded.TokenizerOptions(), loaded.TokenizerConfig())
+	}
+	if got := loaded.Classify("cheap pills").Category; got != "spam" {
+		t.Fatalf("expected spam, got %q", got)
+	}
+
+	var resaved bytes.Buffer
+	if err := loaded.Save(&resaved); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if resaved.String() != payload {
+		t.Fatalf("expected identical payload after round trip, got %s", resaved.String())
+	}
+}
+
+// TestLoadUnregisteredTokenizerFails verifies Load rejects models whose tokenizer is not registered.
+func TestLoadUnregisteredTokenizerFails(t *testing.T) {
+	c := NewClassifier()
+	if err := c.Train("spam", "buy now"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	payload := `{"version":2,"categories":{},"tokenizer":{"tokenizer":"test-unregistered","config":{"separator":";"}}}`
+	if err := c.Load(strings.NewReader(payload)); !errors.Is(err, ErrTokenizerNotRegistered) {
+		t.Fatalf("expected ErrTokenizerNotRegistered, got %v", err)
+	}
+	if len(c.Summaries()) != 1 {
+		t.Fatal("expected a failed load to leave the classifier unchanged")
+	}
+
+	payload = `{"version":2,"categories":{},"tokenizer":{"language":"english","removeStopWords":false,"config":{"separator":";"}}}`
+	if err := c.Load(strings.NewReader(payload)); !errors.Is(err, errInvalidTokenizer) {
+		t.Fatalf("expected errInvalidTokenizer for config on a built-in tokenizer, got %v", err)
+	}
+}
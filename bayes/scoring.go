@@ -0,0 +1,404 @@
+package bayes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hickeroar/gobayes/v4/bayes/category"
+)
+
+// ScoringMode selects how Score and Classify compute category scores.
+type ScoringMode string
+
+const (
+	// ScoringClassic sums per-token Bayesian probabilities. Scores are positive,
+	// unbounded, and grow with the number of matching tokens. This is the default.
+	ScoringClassic ScoringMode = "classic"
+	// ScoringMultinomial computes the log-space multinomial Naive Bayes score
+	// log P(category) + Σ count·log P(token|category) with additive smoothing.
+	// Scores are log-likelihoods (typically negative); higher is better.
+	ScoringMultinomial ScoringMode = "multinomial"
+	// ScoringComplement computes Complement Naive Bayes scores: token weights
+	// are estimated from every other category's counts, normalized per
+	// category, and priors are ignored, which keeps minority categories
+	// reachable on imbalanced data. Scores are small positive values; higher
+	// is better.
+	ScoringComplement ScoringMode = "complement"
+	// ScoringBernoulli computes log-space Bernoulli Naive Bayes scores from
+	// per-category document frequencies. Each token counts once regardless of
+	// how often it repeats, and trained tokens absent from the text contribute
+	// negative evidence. Scores are log-likelihoods; higher is better.
+	ScoringBernoulli ScoringMode = "bernoulli"
+)
+
+// PriorSource selects what category priors are computed from.
+type PriorSource string
+
+const (
+	// PriorTokens weights each category by its share of trained tokens. This is the default.
+	PriorTokens PriorSource = "tokens"
+	// PriorDocuments weights each category by its share of trained documents.
+	PriorDocuments PriorSource = "documents"
+)
+
+// defaultSmoothing is the additive (Laplace) smoothing used when none is configured.
+const defaultSmoothing = 1.0
+
+var (
+	// ErrInvalidScoringMode indicates an unknown ScoringMode.
+	ErrInvalidScoringMode = errors.New("invalid scoring mode")
+	// ErrInvalidScoringOptions indicates smoothing, vocabulary size, or prior source is invalid.
+	ErrInvalidScoringOptions = errors.New("invalid scoring options")
+)
+
+// ScoringOptions configures the scoring model used by a Classifier.
+type ScoringOptions struct {
+	// Mode selects the scoring model. Empty means ScoringClassic.
+	Mode ScoringMode
+	// Smoothing is the additive smoothing constant (alpha) used by
+	// probabilistic modes. Zero means 1.0 (Laplace smoothing).
+	Smoothing float64
+	// VocabularySize overrides the vocabulary size used for smoothing. Zero
+	// means the number of distinct tokens the classifier has been trained on.
+	VocabularySize int
+	// Prior selects what category priors are computed from. Empty means
+	// PriorTokens. Priors are also reported as probInCat in summaries.
+	Prior PriorSource
+}
+
+// ParseScoringMode returns the ScoringMode named by s (case-insensitive).
+// Empty input returns ScoringClassic.
+func ParseScoringMode(s string) (ScoringMode, error) {
+	mode := ScoringMode(strings.ToLower(strings.TrimSpace(s)))
+	switch mode {
+	case "":
+		return ScoringClassic, nil
+	case ScoringClassic, ScoringMultinomial, ScoringComplement, ScoringBernoulli:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidScoringMode, s)
+	}
+}
+
+// normalize validates opts and fills in defaults.
+func (opts ScoringOptions) normalize() (ScoringOptions, error) {
+	mode, err := ParseScoringMode(string(opts.Mode))
+	if err != nil {
+		return ScoringOptions{}, err
+	}
+	opts.Mode = mode
+
+	if opts.Smoothing == 0 {
+		opts.Smoothing = defaultSmoothing
+	}
+	if opts.Smoothing < 0 || math.IsNaN(opts.Smoothing) || math.IsInf(opts.Smoothing, 0) {
+		return ScoringOptions{}, fmt.Errorf("%w: smoothing must be positive, got %v", ErrInvalidScoringOptions, opts.Smoothing)
+	}
+	if opts.VocabularySize < 0 {
+		return ScoringOptions{}, fmt.Errorf("%w: vocabulary size must not be negative, got %d", ErrInvalidScoringOptions, opts.VocabularySize)
+	}
+	prior, err := ParsePriorSource(string(opts.Prior))
+	if err != nil {
+		return ScoringOptions{}, err
+	}
+	opts.Prior = prior
+	return opts, nil
+}
+
+// ParsePriorSource returns the PriorSource named by s (case-insensitive).
+// Empty input returns PriorTokens.
+func ParsePriorSource(s string) (PriorSource, error) {
+	prior := PriorSource(strings.ToLower(strings.TrimSpace(s)))
+	switch prior {
+	case "":
+		return PriorTokens, nil
+	case PriorTokens, PriorDocuments:
+		return prior, nil
+	default:
+		return "", fmt.Errorf("%w: unknown prior source %q", ErrInvalidScoringOptions, s)
+	}
+}
+
+// SetScoringOptions selects the scoring model. Scoring options are persisted
+// on Save and restored on Load.
+func (c *Classifier) SetScoringOptions(opts ScoringOptions) error {
+	opts, err := opts.normalize()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scoring = opts
+	c.applyPriorSource()
+	c.revision++
+	return nil
+}
+
+// applyPriorSource configures the categories' prior computation from the
+// scoring options and refreshes priors. Callers must hold the write lock.
+func (c *Classifier) applyPriorSource() {
+	c.categories.SetDocumentPriors(c.scoringUnlocked().Prior == PriorDocuments)
+	c.categories.EnsureCategoryProbabilities()
+}
+
+// ScoringOptions returns the active scoring options with defaults filled in.
+func (c *Classifier) ScoringOptions() ScoringOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.scoringUnlocked()
+}
+
+// scoringUnlocked returns normalized scoring options while the lock is held.
+func (c *Classifier) scoringUnlocked() ScoringOptions {
+	opts := c.scoring
+	if opts.Mode == "" {
+		opts.Mode = ScoringClassic
+	}
+	if opts.Smoothing == 0 {
+		opts.Smoothing = defaultSmoothing
+	}
+	if opts.Prior == "" {
+		opts.Prior = PriorTokens
+	}
+	return opts
+}
+
+// multinomialScores computes log-space multinomial Naive Bayes scores for
+// every category. Tokens unseen in a category still contribute the smoothed
+// probability, so they penalize categories rather than being ignored.
+func (c *Classifier) multinomialScores(occurrences map[string]int, opts ScoringOptions) map[string]float64 {
+	scores := make(map[string]float64)
+	if len(occurrences) == 0 {
+		return scores
+	}
+
+	vocabularySize := c.vocabularySize(opts)
+	alpha := opts.Smoothing
+
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		if cat.GetProbInCat() <= 0 {
+			continue
+		}
+		scores[name] = c.multinomialCategoryScore(cat, occurrences, alpha, float64(vocabularySize))
+	}
+	return scores
+}
+
+// vocabularySize returns the vocabulary size used for smoothing: the
+// configured override, else the number of distinct trained tokens, at least 1.
+func (c *Classifier) vocabularySize(opts ScoringOptions) int {
+	size := opts.VocabularySize
+	if size == 0 {
+		size = c.categories.VocabularySize()
+	}
+	return max(size, 1)
+}
+
+// multinomialCategoryScore returns log P(cat) + Σ count·log P(token|cat).
+func (c *Classifier) multinomialCategoryScore(cat *category.Category, occurrences map[string]int, alpha, vocabularySize float64) float64 {
+	denominator := cat.GetTally() + alpha*vocabularySize
+	score := math.Log(cat.GetProbInCat())
+	for token, count := range occurrences {
+		likelihood := (cat.GetTokenCount(token) + alpha) / denominator
+		score += float64(count) * math.Log(likelihood)
+	}
+	return score
+}
+
+// categoryTerms caches a per-category value derived from the whole
+// vocabulary for one model revision and set of scoring options.
+type categoryTerms struct {
+	revision uint64
+	opts     ScoringOptions
+	values   map[string]float64
+}
+
+// cachedCategoryTerms returns the cached per-category terms for opts, calling
+// compute only when the model or scoring options changed. Callers must hold
+// at least the read lock, which keeps the revision stable.
+func (c *Classifier) cachedCategoryTerms(opts ScoringOptions, compute func() map[string]float64) map[string]float64 {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if cache := c.scoringCache; cache != nil && cache.revision == c.revision && cache.opts == opts {
+		return cache.values
+	}
+	values := compute()
+	c.scoringCache = &categoryTerms{revision: c.revision, opts: opts, values: values}
+	return values
+}
+
+// complementScores computes Complement Naive Bayes scores. For category c the
+// weight of token t is w = log((N~ct + α) / (N~c + α·V)), where N~ct and N~c
+// count t and all tokens in every category except c. Weights are divided by
+// Σ|w| over the vocabulary, and the score is -Σ count·ŵ, so tokens common
+// outside c lower its score.
+func (c *Classifier) complementScores(occurrences map[string]int, opts ScoringOptions) map[string]float64 {
+	scores := make(map[string]float64)
+	if len(occurrences) == 0 {
+		return scores
+	}
+
+	names := c.categories.Names()
+	totalTally := 0.0
+	for _, name := range names {
+		cat, _ := c.categories.LookupCategory(name)
+		totalTally += cat.GetTally()
+	}
+	vocabularySize := c.vocabularySize(opts)
+	normalizers := c.complementNormalizers(opts, vocabularySize, totalTally)
+	alpha := opts.Smoothing
+
+	for _, name := range names {
+		cat, _ := c.categories.LookupCategory(name)
+		denominator := totalTally - cat.GetTally() + alpha*float64(vocabularySize)
+		score := 0.0
+		for token, count := range occurrences {
+			complementCount := c.categories.TokenTotal(token) - cat.GetTokenCount(token)
+			weight := math.Log((complementCount + alpha) / denominator)
+			score -= float64(count) * weight / normalizers[name]
+		}
+		scores[name] = score
+	}
+	return scores
+}
+
+// complementNormalizers returns Σ|w| over the vocabulary for every category.
+func (c *Classifier) complementNormalizers(opts ScoringOptions, vocabularySize int, totalTally float64) map[string]float64 {
+	return c.cachedCategoryTerms(opts, func() map[string]float64 {
+		return c.computeComplementNormalizers(opts, vocabularySize, totalTally)
+	})
+}
+
+// computeComplementNormalizers computes Σ|w| over the vocabulary for every category.
+func (c *Classifier) computeComplementNormalizers(opts ScoringOptions, vocabularySize int, totalTally float64) map[string]float64 {
+	alpha := opts.Smoothing
+
+	// Σ log(total_t + α) over the vocabulary is shared by every category; each
+	// category then swaps in log(total_t - own_t + α) for its own tokens.
+	sharedLogSum := 0.0
+	distinct := 0
+	for _, total := range c.categories.Vocabulary() {
+		sharedLogSum += math.Log(total + alpha)
+		distinct++
+	}
+	// Tokens counted by a larger vocabulary override have a complement count of zero.
+	unseen := max(vocabularySize-distinct, 0)
+	sharedLogSum += float64(unseen) * math.Log(alpha)
+	terms := float64(distinct + unseen)
+
+	normalizers := make(map[string]float64)
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		logSum := sharedLogSum
+		for token, count := range cat.Tokens() {
+			total := c.categories.TokenTotal(token)
+			logSum += math.Log(total-count+alpha) - math.Log(total+alpha)
+		}
+
+		// Every weight is non-positive, so Σ|w| = Σ(log denominator - log(N~ct + α)).
+		denominator := totalTally - cat.GetTally() + alpha*float64(vocabularySize)
+		normalizer := terms*math.Log(denominator) - logSum
+		if normalizer <= 0 {
+			normalizer = 1
+		}
+		normalizers[name] = normalizer
+	}
+	return normalizers
+}
+
+// bernoulliScores computes log-space Bernoulli Naive Bayes scores:
+// log P(c) + Σ over the vocabulary of log p or log(1-p), depending on whether
+// the token is present in the text, where p = (df + α) / (docs + 2α). Tokens
+// outside the trained vocabulary are not features and are ignored.
+func (c *Classifier) bernoulliScores(occurrences map[string]int, opts ScoringOptions) map[string]float64 {
+	scores := make(map[string]float64)
+	if len(occurrences) == 0 {
+		return scores
+	}
+
+	vocabularySize := c.vocabularySize(opts)
+	absentLogSums := c.cachedCategoryTerms(opts, func() map[string]float64 {
+		return c.computeBernoulliAbsentLogSums(opts, vocabularySize)
+	})
+	alpha := opts.Smoothing
+
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		if cat.GetProbInCat() <= 0 {
+			continue
+		}
+		score := math.Log(cat.GetProbInCat()) + absentLogSums[name]
+		for token := range occurrences {
+			if c.categories.TokenTotal(token) == 0 {
+				continue
+			}
+			// Swap the token's absent term for its present term.
+			p := bernoulliProbability(cat, token, alpha)
+			score += math.Log(p) - math.Log1p(-p)
+		}
+		scores[name] = score
+	}
+	return scores
+}
+
+// computeBernoulliAbsentLogSums returns, per category, Σ log(1-p) over the
+// vocabulary: the score of a text containing none of the vocabulary tokens.
+func (c *Classifier) computeBernoulliAbsentLogSums(opts ScoringOptions, vocabularySize int) map[string]float64 {
+	alpha := opts.Smoothing
+	sums := make(map[string]float64)
+	for _, name := range c.categories.Names() {
+		cat, _ := c.categories.LookupCategory(name)
+		documents := cat.GetDocumentCount()
+
+		// Tokens never seen in this category's documents share one term.
+		seen := 0
+		sum := 0.0
+		for token := range cat.TokenDocumentCounts() {
+			sum += math.Log1p(-bernoulliProbability(cat, token, alpha))
+			seen++
+		}
+		unseen := max(vocabularySize-seen, 0)
+		sum += float64(unseen) * math.Log1p(-alpha/(documents+2*alpha))
+		sums[name] = sum
+	}
+	return sums
+}
+
+// bernoulliProbability returns the smoothed probability that a document in
+// cat contains token. Document frequencies are capped at the document count,
+// which untraining can leave lower than a token's frequency.
+func bernoulliProbability(cat *category.Category, token string, alpha float64) float64 {
+	documents := cat.GetDocumentCount()
+	frequency := math.Min(cat.GetTokenDocumentCount(token), documents)
+	return (frequency + alpha) / (documents + 2*alpha)
+}
+
+// normalizeScores applies a softmax to scores using the log-sum-exp trick so
+// large or very negative scores do not overflow or underflow.
+func normalizeScores(scores map[string]float64) map[string]float64 {
+	probabilities := make(map[string]float64, len(scores))
+	if len(scores) == 0 {
+		return probabilities
+	}
+
+	maxScore := math.Inf(-1)
+	for _, score := range scores {
+		maxScore = math.Max(maxScore, score)
+	}
+
+	sum := 0.0
+	for name, score := range scores {
+		weight := math.Exp(score - maxScore)
+		probabilities[name] = weight
+		sum += weight
+	}
+	for name := range probabilities {
+		probabilities[name] /= sum
+	}
+	return probabilities
+}
@@ -0,0 +1,565 @@
+package bayes
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// newMultinomialClassifier returns a classifier trained on a small spam/ham corpus.
+func newMultinomialClassifier(t *testing.T, opts ScoringOptions) *Classifier {
+	t.Helper()
+	classifier := NewClassifier()
+	if err := classifier.SetScoringOptions(opts); err != nil {
+		t.Fatalf("unexpected scoring options error: %v", err)
+	}
+	if err := classifier.Train("spam", "buy cheap pills buy now"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := classifier.Train("ham", "team meeting schedule project meeting notes"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	return classifier
+}
+
+// TestScoringOptionsDefaults verifies the classic mode and Laplace smoothing are the defaults.
+func TestScoringOptionsDefaults(t *testing.T) {
+	opts := NewClassifier().ScoringOptions()
+	if opts.Mode != ScoringClassic || opts.Smoothing != 1.0 || opts.VocabularySize != 0 || opts.Prior != PriorTokens {
+		t.Fatalf("unexpected default scoring options: %+v", opts)
+	}
+}
+
+// TestSetScoringOptionsValidation verifies invalid scoring options are rejected.
+func TestSetScoringOptionsValidation(t *testing.T) {
+	classifier := NewClassifier()
+	tests := []struct {
+		name string
+		opts ScoringOptions
+		want error
+	}{
+		{name: "unknown mode", opts: ScoringOptions{Mode: "fancy"}, want: ErrInvalidScoringMode},
+		{name: "negative smoothing", opts: ScoringOptions{Mode: ScoringMultinomial, Smoothing: -1}, want: ErrInvalidScoringOptions},
+		{name: "nan smoothing", opts: ScoringOptions{Mode: ScoringMultinomial, Smoothing: math.NaN()}, want: ErrInvalidScoringOptions},
+		{name: "negative vocabulary", opts: ScoringOptions{Mode: ScoringMultinomial, VocabularySize: -1}, want: ErrInvalidScoringOptions},
+		{name: "unknown prior", opts: ScoringOptions{Mode: ScoringMultinomial, Prior: "uniform"}, want: ErrInvalidScoringOptions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := classifier.SetScoringOptions(tt.opts); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+	if classifier.ScoringOptions().Mode != ScoringClassic {
+		t.Fatal("expected rejected options to leave scoring unchanged")
+	}
+}
+
+// TestParseScoringMode verifies mode parsing is case-insensitive and defaults to classic.
+func TestParseScoringMode(t *testing.T) {
+	for input, want := range map[string]ScoringMode{"": ScoringClassic, " Classic ": ScoringClassic, "MULTINOMIAL": ScoringMultinomial, "complement": ScoringComplement, "Bernoulli": ScoringBernoulli} {
+		got, err := ParseScoringMode(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseScoringMode(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseScoringMode("bernoulli?"); !errors.Is(err, ErrInvalidScoringMode) {
+		t.Fatalf("expected ErrInvalidScoringMode, got %v", err)
+	}
+}
+
+// TestDocumentPriors verifies priors can be computed from document counts and persist.
+func TestDocumentPriors(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.Train("spam", "buy cheap pills buy now"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	for _, sample := range []string{"team meeting", "project notes", "schedule"} {
+		if err := classifier.Train("ham", sample); err != nil {
+			t.Fatalf("unexpected train error: %v", err)
+		}
+	}
+	if got := classifier.Summaries()["spam"].ProbInCat; got != 0.5 {
+		t.Fatalf("expected token prior 0.5, got %v", got)
+	}
+
+	if err := classifier.SetScoringOptions(ScoringOptions{Prior: PriorDocuments}); err != nil {
+		t.Fatalf("unexpected scoring options error: %v", err)
+	}
+	if got := classifier.Summaries()["spam"].ProbInCat; got != 0.25 {
+		t.Fatalf("expected document prior 0.25, got %v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := classifier.Save(&buf); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"prior":"documents"`) {
+		t.Fatalf("expected prior in payload, got %s", buf.String())
+	}
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	if loaded.ScoringOptions().Prior != PriorDocuments || loaded.Summaries()["spam"].ProbInCat != 0.25 {
+		t.Fatalf("expected document priors after load, got %+v", loaded.Summaries())
+	}
+
+	loaded.Flush()
+	if loaded.ScoringOptions().Prior != PriorDocuments {
+		t.Fatal("expected Flush to keep the prior source")
+	}
+}
+
+// TestMultinomialScoresMatchFormula verifies scores equal log prior plus smoothed log likelihoods.
+func TestMultinomialScoresMatchFormula(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringMultinomial})
+
+	scores := classifier.Score("buy meeting unseen")
+	if len(scores) != 2 {
+		t.Fatalf("expected scores for both categories, got %v", scores)
+	}
+
+	// spam: 5 tokens (buy x2), ham: 6 tokens, vocabulary: 9 distinct tokens.
+	vocab := 9.0
+	wantSpam := math.Log(5.0/11.0) + math.Log(3.0/(5+vocab)) + math.Log(1.0/(5+vocab)) + math.Log(1.0/(5+vocab))
+	wantHam := math.Log(6.0/11.0) + math.Log(1.0/(6+vocab)) + math.Log(3.0/(6+vocab)) + math.Log(1.0/(6+vocab))
+	if math.Abs(scores["spam"]-wantSpam) > 1e-9 || math.Abs(scores["ham"]-wantHam) > 1e-9 {
+		t.Fatalf("unexpected scores: got spam=%f ham=%f, want spam=%f ham=%f", scores["spam"], scores["ham"], wantSpam, wantHam)
+	}
+}
+
+// TestMultinomialClassify verifies classification picks the highest log score.
+func TestMultinomialClassify(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringMultinomial})
+
+	if got := classifier.Classify("cheap pills").Category; got != "spam" {
+		t.Fatalf("expected spam, got %q", got)
+	}
+	result := classifier.Classify("meeting notes")
+	if result.Category != "ham" {
+		t.Fatalf("expected ham, got %q", result.Category)
+	}
+	if result.Score >= 0 {
+		t.Fatalf("expected negative log score, got %f", result.Score)
+	}
+}
+
+// TestMultinomialUnseenTokensPenalize verifies unseen tokens still lower scores.
+func TestMultinomialUnseenTokensPenalize(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringMultinomial})
+
+	short := classifier.Score("buy")
+	long := classifier.Score("buy zzz")
+	if long["spam"] >= short["spam"] {
+		t.Fatalf("expected unseen token to lower spam score: short=%f long=%f", short["spam"], long["spam"])
+	}
+}
+
+// TestMultinomialEmptyInput verifies text without tokens yields no scores.
+func TestMultinomialEmptyInput(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringMultinomial})
+	if scores := classifier.Score("   "); len(scores) != 0 {
+		t.Fatalf("expected no scores for empty input, got %v", scores)
+	}
+	if result := classifier.Classify(""); result.Category != "" {
+		t.Fatalf("expected no category for empty input, got %q", result.Category)
+	}
+	if scores := NewClassifier().Score("anything"); len(scores) != 0 {
+		t.Fatalf("expected no scores for untrained classifier, got %v", scores)
+	}
+}
+
+// loadWithEmptyCategory returns a classifier loaded from a model with an
+// empty category, whose prior is zero, next to a trained one.
+func loadWithEmptyCategory(t *testing.T, opts ScoringOptions) *Classifier {
+	t.Helper()
+	classifier := NewClassifier()
+	model := `{"version":3,"categories":{` +
+		`"spam":{"Tally":2,"Tokens":{"buy":1,"now":1},"Documents":1,"DocumentFrequencies":{"buy":1,"now":1}},` +
+		`"empty":{"Tally":0,"Tokens":{},"Documents":0,"DocumentFrequencies":{}}}}`
+	if err := classifier.Load(strings.NewReader(model)); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	if err := classifier.SetScoringOptions(opts); err != nil {
+		t.Fatalf("unexpected scoring options error: %v", err)
+	}
+	return classifier
+}
+
+// TestMultinomialSkipsEmptyCategories verifies a category without a prior is not scored.
+func TestMultinomialSkipsEmptyCategories(t *testing.T) {
+	classifier := loadWithEmptyCategory(t, ScoringOptions{Mode: ScoringMultinomial})
+	if scores := classifier.Score("buy"); len(scores) != 1 || scores["spam"] == 0 {
+		t.Fatalf("expected only spam to be scored, got %v", scores)
+	}
+}
+
+// TestMultinomialSmoothingAndVocabularyOverride verifies configured smoothing and vocabulary are used.
+func TestMultinomialSmoothingAndVocabularyOverride(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringMultinomial, Smoothing: 0.5, VocabularySize: 100})
+
+	scores := classifier.Score("pills")
+	want := math.Log(5.0/11.0) + math.Log(1.5/(5+0.5*100))
+	if math.Abs(scores["spam"]-want) > 1e-9 {
+		t.Fatalf("unexpected spam score: got %f, want %f", scores["spam"], want)
+	}
+}
+
+// TestScoringOptionsPersisted verifies scoring options survive Save and Load.
+func TestScoringOptionsPersisted(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringMultinomial, Smoothing: 0.25})
+
+	var buf bytes.Buffer
+	if err := classifier.Save(&buf); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"scoring":{"mode":"multinomial","smoothing":0.25,"prior":"tokens"}`) {
+		t.Fatalf("expected scoring options in payload, got %s", buf.String())
+	}
+
+	loaded := NewClassifier()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	if opts := loaded.ScoringOptions(); opts.Mode != ScoringMultinomial || opts.Smoothing != 0.25 {
+		t.Fatalf("unexpected loaded scoring options: %+v", opts)
+	}
+
+	var classic bytes.Buffer
+	if err := NewClassifier().Save(&classic); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+	if !strings.Contains(classic.String(), `"scoring":{"mode":"classic","smoothing":1,"prior":"tokens"}`) {
+		t.Fatalf("expected default scoring to be written, got %s", classic.String())
+	}
+
+	// The file's scoring always replaces the classifier's, including default
+	// scoring written explicitly or omitted by older versions.
+	for _, payload := range []string{classic.String(), `{"version":2,"categories":{}}`} {
+		configured := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringBernoulli, Prior: PriorDocuments})
+		if err := configured.Load(strings.NewReader(payload)); err != nil {
+			t.Fatalf("unexpected load error: %v", err)
+		}
+		if opts := configured.ScoringOptions(); opts != (ScoringOptions{Mode: ScoringClassic, Smoothing: 1, Prior: PriorTokens}) {
+			t.Fatalf("expected the model file's default scoring to win, got %+v", opts)
+		}
+	}
+}
+
+// TestLoadRejectsInvalidScoring verifies invalid persisted scoring options are rejected.
+func TestLoadRejectsInvalidScoring(t *testing.T) {
+	payload := `{"version":1,"categories":{},"scoring":{"mode":"nope","smoothing":1}}`
+	if err := NewClassifier().Load(strings.NewReader(payload)); !errors.Is(err, errInvalidScoring) {
+		t.Fatalf("expected errInvalidScoring, got %v", err)
+	}
+}
+
+// TestNormalizeScoresIsStable verifies softmax handles extreme scores without overflow.
+func TestNormalizeScoresIsStable(t *testing.T) {
+	probabilities := normalizeScores(map[string]float64{"a": -1000, "b": -1001, "c": 1e6})
+	if math.Abs(probabilities["c"]-1) > 1e-12 || probabilities["a"] != 0 {
+		t.Fatalf("unexpected probabilities for extreme scores: %v", probabilities)
+	}
+
+	probabilities = normalizeScores(map[string]float64{"a": -1000, "b": -1000 - math.Log(3)})
+	if math.Abs(probabilities["a"]-0.75) > 1e-9 || math.Abs(probabilities["b"]-0.25) > 1e-9 {
+		t.Fatalf("unexpected probabilities for log-space scores: %v", probabilities)
+	}
+
+	if got := normalizeScores(nil); len(got) != 0 {
+		t.Fatalf("expected empty probabilities, got %v", got)
+	}
+}
+
+// TestProbabilitiesSumToOne verifies Probabilities returns a distribution in every mode.
+func TestProbabilitiesSumToOne(t *testing.T) {
+	for _, mode := range []ScoringMode{ScoringClassic, ScoringMultinomial, ScoringComplement, ScoringBernoulli} {
+		t.Run(string(mode), func(t *testing.T) {
+			classifier := newMultinomialClassifier(t, ScoringOptions{Mode: mode})
+
+			probabilities := classifier.Probabilities("buy cheap meeting")
+			if len(probabilities) != 2 {
+				t.Fatalf("expected two probabilities, got %v", probabilities)
+			}
+			sum := 0.0
+			for _, p := range probabilities {
+				if p < 0 || p > 1 {
+					t.Fatalf("probability out of range: %v", probabilities)
+				}
+				sum += p
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Fatalf("expected probabilities to sum to 1, got %f", sum)
+			}
+
+			result := classifier.Classify("cheap pills")
+			if result.Category != "spam" {
+				t.Fatalf("expected spam, got %q", result.Category)
+			}
+			if want := classifier.Probabilities("cheap pills")["spam"]; result.Probability != want || want <= 0.5 {
+				t.Fatalf("unexpected classification probability: got %f, want %f > 0.5", result.Probability, want)
+			}
+		})
+	}
+}
+
+// TestProbabilitiesEmpty verifies untrained classifiers and empty input yield no probabilities.
+func TestProbabilitiesEmpty(t *testing.T) {
+	if got := NewClassifier().Probabilities("anything"); len(got) != 0 {
+		t.Fatalf("expected no probabilities, got %v", got)
+	}
+	if result := NewClassifier().Classify("anything"); result.Probability != 0 {
+		t.Fatalf("expected zero probability without a category, got %f", result.Probability)
+	}
+}
+
+// bruteForceComplementScores recomputes Complement NB scores directly from the definition.
+func bruteForceComplementScores(classifier *Classifier, text string, alpha float64) map[string]float64 {
+	occurrences := classifier.countTokenOccurrences(classifier.getTokenizer()(text))
+	totals := map[string]float64{}
+	totalTally := 0.0
+	for _, name := range classifier.categories.Names() {
+		cat, _ := classifier.categories.LookupCategory(name)
+		totalTally += cat.GetTally()
+		for token, count := range cat.Tokens() {
+			totals[token] += count
+		}
+	}
+	vocab := float64(len(totals))
+
+	scores := map[string]float64{}
+	for _, name := range classifier.categories.Names() {
+		cat, _ := classifier.categories.LookupCategory(name)
+		denominator := totalTally - cat.GetTally() + alpha*vocab
+		weight := func(token string) float64 {
+			return math.Log((totals[token] - cat.GetTokenCount(token) + alpha) / denominator)
+		}
+		normalizer := 0.0
+		for token := range totals {
+			normalizer += math.Abs(weight(token))
+		}
+		score := 0.0
+		for token, count := range occurrences {
+			score -= float64(count) * weight(token) / normalizer
+		}
+		scores[name] = score
+	}
+	return scores
+}
+
+// TestComplementScoresMatchDefinition verifies complement scores against a direct computation.
+func TestComplementScoresMatchDefinition(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringComplement, Smoothing: 0.5})
+	if err := classifier.Train("news", "meeting about cheap energy project"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	for _, text := range []string{"buy cheap pills", "meeting notes project", "unknown words entirely"} {
+		got := classifier.Score(text)
+		want := bruteForceComplementScores(classifier, text, 0.5)
+		if len(got) != len(want) {
+			t.Fatalf("unexpected categories for %q: got %v want %v", text, got, want)
+		}
+		for name, score := range want {
+			if math.Abs(got[name]-score) > 1e-9 {
+				t.Fatalf("unexpected %s score for %q: got %f want %f", name, text, got[name], score)
+			}
+		}
+	}
+}
+
+// TestComplementKeepsMinorityReachable verifies complement scoring ignores the skewed prior.
+func TestComplementKeepsMinorityReachable(t *testing.T) {
+	build := func(mode ScoringMode) *Classifier {
+		classifier := NewClassifier()
+		if err := classifier.SetScoringOptions(ScoringOptions{Mode: mode}); err != nil {
+			t.Fatalf("unexpected scoring options error: %v", err)
+		}
+		if err := classifier.Train("spam", "prize winner claim"); err != nil {
+			t.Fatalf("unexpected train error: %v", err)
+		}
+		for i := 0; i < 20; i++ {
+			if err := classifier.Train("ham", "team meeting schedule project update notes review plan"); err != nil {
+				t.Fatalf("unexpected train error: %v", err)
+			}
+		}
+		return classifier
+	}
+
+	text := "prize team meeting"
+	if got := build(ScoringMultinomial).Classify(text).Category; got != "ham" {
+		t.Fatalf("expected the token-weighted prior to pull multinomial toward ham, got %q", got)
+	}
+	if got := build(ScoringComplement).Classify(text).Category; got != "spam" {
+		t.Fatalf("expected complement scoring to reach the minority category, got %q", got)
+	}
+}
+
+// TestComplementNormalizersRefreshAfterTraining verifies cached normalizers follow model changes.
+func TestComplementNormalizersRefreshAfterTraining(t *testing.T) {
+	classifier := newMultinomialClassifier(t, ScoringOptions{Mode: ScoringComplement})
+	_ = classifier.Score("buy")
+
+	if err := classifier.Train("spam", "more spam words to shift the weights"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	got := classifier.Score("buy meeting")
+	want := bruteForceComplementScores(classifier, "buy meeting", 1.0)
+	for name, score := range want {
+		if math.Abs(got[name]-score) > 1e-9 {
+			t.Fatalf("stale %s score after training: got %f want %f", name, got[name], score)
+		}
+	}
+}
+
+// TestComplementEmptyInputAndSingleCategory verifies degenerate complement inputs.
+func TestComplementEmptyInputAndSingleCategory(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.SetScoringOptions(ScoringOptions{Mode: ScoringComplement, VocabularySize: 10}); err != nil {
+		t.Fatalf("unexpected scoring options error: %v", err)
+	}
+	if scores := classifier.Score("anything"); len(scores) != 0 {
+		t.Fatalf("expected no scores for untrained classifier, got %v", scores)
+	}
+	if err := classifier.Train("only", "lonely words"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if scores := classifier.Score(""); len(scores) != 0 {
+		t.Fatalf("expected no scores for empty input, got %v", scores)
+	}
+	scores := classifier.Score("lonely")
+	if score, ok := scores["only"]; !ok || math.IsNaN(score) || math.IsInf(score, 0) {
+		t.Fatalf("expected a finite score for a single category, got %v", scores)
+	}
+}
+
+// TestComplementSingleTokenVocabulary verifies a category whose weights sum
+// to zero still gets a finite score.
+func TestComplementSingleTokenVocabulary(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.SetScoringOptions(ScoringOptions{Mode: ScoringComplement}); err != nil {
+		t.Fatalf("unexpected scoring options error: %v", err)
+	}
+	if err := classifier.Train("only", "lonely"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if score, ok := classifier.Score("lonely")["only"]; !ok || math.IsNaN(score) || math.IsInf(score, 0) {
+		t.Fatalf("expected a finite score, got %v", score)
+	}
+}
+
+// bruteForceBernoulliScores recomputes Bernoulli scores by iterating the whole vocabulary.
+func bruteForceBernoulliScores(classifier *Classifier, text string, alpha float64) map[string]float64 {
+	present := classifier.countTokenOccurrences(classifier.getTokenizer()(text))
+	scores := map[string]float64{}
+	for _, name := range classifier.categories.Names() {
+		cat, _ := classifier.categories.LookupCategory(name)
+		documents := float64(cat.GetDocumentCount())
+		score := math.Log(cat.GetProbInCat())
+		for token := range classifier.categories.Vocabulary() {
+			p := (float64(cat.GetTokenDocumentCount(token)) + alpha) / (documents + 2*alpha)
+			if _, ok := present[token]; ok {
+				score += math.Log(p)
+			} else {
+				score += math.Log(1 - p)
+			}
+		}
+		scores[name] = score
+	}
+	return scores
+}
+
+// TestBernoulliScoresMatchDefinition verifies Bernoulli scores against a direct computation.
+func TestBernoulliScoresMatchDefinition(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.SetScoringOptions(ScoringOptions{Mode: ScoringBernoulli, Smoothing: 0.5}); err != nil {
+		t.Fatalf("unexpected scoring options error: %v", err)
+	}
+	for _, sample := range []struct{ category, text string }{
+		{"spam", "free prize free"},
+		{"spam", "claim prize now"},
+		{"ham", "team meeting now"},
+		{"ham", "project meeting notes"},
+	} {
+		if err := classifier.Train(sample.category, sample.text); err != nil {
+			t.Fatalf("unexpected train error: %v", err)
+		}
+	}
+
+	for _, text := range []string{"free prize", "meeting notes unknown", "now"} {
+		got := classifier.Score(text)
+		want := bruteForceBernoulliScores(classifier, text, 0.5)
+		for name, score := range want {
+			if math.Abs(got[name]-score) > 1e-9 {
+				t.Fatalf("unexpected %s score for %q: got %f want %f", name, text, got[name], score)
+			}
+		}
+	}
+}
+
+// TestBernoulliIgnoresRepetition verifies repeated tokens count once.
+func TestBernoulliIgnoresRepetition(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.SetScoringOptions(ScoringOptions{Mode: ScoringBernoulli}); err != nil {
+		t.Fatalf("unexpected scoring options error: %v", err)
+	}
+	if err := classifier.Train("spam", "free offer"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := classifier.Train("ham", "meeting agenda project"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+	if err := classifier.Train("ham", "project meeting"); err != nil {
+		t.Fatalf("unexpected train error: %v", err)
+	}
+
+	once := classifier.Score("free meeting project")
+	repeated := classifier.Score(strings.Repeat("free ", 40) + "meeting project")
+	for name, score := range once {
+		if math.Abs(repeated[name]-score) > 1e-12 {
+			t.Fatalf("expected repetition not to change %s score: once=%f repeated=%f", name, score, repeated[name])
+		}
+	}
+	if got := classifier.Classify(strings.Repeat("free ", 40) + "meeting project").Category; got != "ham" {
+		t.Fatalf("expected repeated spam token not to overwhelm, got %q", got)
+	}
+}
+
+// TestBernoulliAbsentTokensAreEvidence verifies missing vocabulary tokens lower a category's score.
+func TestBernoulliAbsentTokensAreEvidence(t *testing.T) {
+	classifier := NewClassifier()
+	if err := classifier.SetScoringOptions(ScoringOptions{Mode: ScoringBernoulli}); err != nil {
+		t.Fatalf("unexpected scoring options error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := classifier.Train("greeting", "hello"); err != nil {
+			t.Fatalf("unexpected train error: %v", err)
+		}
+		if err := classifier.Train("farewell", "hello goodbye"); err != nil {
+			t.Fatalf("unexpected train error: %v", err)
+		}
+	}
+
+	// Both categories always contain "hello"; the absence of "goodbye" favors greeting.
+	if got := classifier.Classify("hello").Category; got != "greeting" {
+		t.Fatalf("expected absent goodbye to favor greeting, got %q", got)
+	}
+	if scores := classifier.Score("nothing known"); len(scores) != 2 {
+		t.Fatalf("expected every category to be scored, got %v", scores)
+	}
+	if scores := classifier.Score(""); len(scores) != 0 {
+		t.Fatalf("expected no scores for empty input, got %v", scores)
+	}
+}
+
+// TestBernoulliSkipsEmptyCategories verifies a category without a prior is not scored.
+func TestBernoulliSkipsEmptyCategories(t *testing.T) {
+	classifier := loadWithEmptyCategory(t, ScoringOptions{Mode: ScoringBernoulli})
+	if scores := classifier.Score("buy"); len(scores) != 1 || scores["spam"] == 0 {
+		t.Fatalf("expected only spam to be scored, got %v", scores)
+	}
+}
@@ -0,0 +1,35 @@
+package stopwords
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Parse reads a stop-word customization list: one word per line, where a
+// line starting with "-" names a built-in stop word to keep instead of
+// filtering. Blank lines and lines starting with "#" are ignored. Words are
+// trimmed and lowercased.
+func Parse(r io.Reader) (add, remove []string, err error) {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(word, "-"); ok {
+			rest = strings.TrimSpace(rest)
+			if rest == "" {
+				return nil, nil, fmt.Errorf("line %d: missing word after \"-\"", line)
+			}
+			remove = append(remove, rest)
+			continue
+		}
+		add = append(add, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return add, remove, nil
+}
@@ -1,7 +1,12 @@
 package stopwords
 
 import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestGetAndSupported(t *testing.T) {
@@ -29,3 +34,24 @@ func TestGetAndSupported(t *testing.T) {
 		t.Fatalf("expected nil for unsupported language, got %v", got)
 	}
 }
+
+func TestParse(t *testing.T) {
+	input := "# domain words\nUnsubscribe\n\n  acme  \n- The\n-not\n"
+	add, remove, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if !slices.Equal(add, []string{"unsubscribe", "acme"}) {
+		t.Fatalf("unexpected added words: %v", add)
+	}
+	if !slices.Equal(remove, []string{"the", "not"}) {
+		t.Fatalf("unexpected removed words: %v", remove)
+	}
+
+	if _, _, err := Parse(strings.NewReader("ok\n-\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected line 2 error, got %v", err)
+	}
+	if _, _, err := Parse(iotest.ErrReader(io.ErrUnexpectedEOF)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
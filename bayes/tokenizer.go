@@ -1,10 +1,13 @@
 package bayes
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"unicode"
 
-	"github.com/hickeroar/gobayes/v3/bayes/stopwords"
+	"github.com/hickeroar/gobayes/v4/bayes/stopwords"
 	"github.com/kljensen/snowball"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -33,6 +36,167 @@ var languageTag = map[string]language.Tag{
 	"hungarian": language.Hungarian,
 }
 
+// Built-in tokenizer names for TokenizerOptions.Tokenizer.
+const (
+	// TokenizerWords splits text into stemmed words. This is the default.
+	TokenizerWords = "words"
+	// TokenizerChars emits character n-grams for scripts written without
+	// spaces between words (Chinese, Japanese, Korean, Thai, ...) and whole
+	// lowercased words for other scripts. Words are not stemmed.
+	TokenizerChars = "chars"
+)
+
+// Default character n-gram range for TokenizerChars.
+const (
+	defaultCharNGramMin = 1
+	defaultCharNGramMax = 2
+)
+
+// ErrInvalidTokenizer indicates an unknown tokenizer name.
+var ErrInvalidTokenizer = errors.New("invalid tokenizer")
+
+// charLanguages are languages that select TokenizerChars when no tokenizer is named.
+var charLanguages = map[string]bool{
+	"chinese":  true,
+	"japanese": true,
+	"korean":   true,
+	"thai":     true,
+}
+
+// TokenizerOptions configures the default tokenizer.
+type TokenizerOptions struct {
+	// Language selects stemming and stop words; see NewDefaultTokenizer.
+	// chinese, japanese, korean, and thai select TokenizerChars.
+	Language string
+	// RemoveStopWords filters stop words for Language before n-grams are built.
+	RemoveStopWords bool
+	// NGrams is the longest word n-gram emitted in addition to single words:
+	// 2 adds bigrams, 3 adds bigrams and trigrams. Values below 2 emit single
+	// words only. Ignored by TokenizerChars.
+	NGrams int
+	// Tokenizer names the tokenizer: TokenizerWords or TokenizerChars. Empty
+	// selects TokenizerChars for the languages listed above and
+	// TokenizerWords otherwise.
+	Tokenizer string
+	// CharNGramMin and CharNGramMax bound the character n-gram lengths
+	// emitted by TokenizerChars. Zero means 1 and 2 respectively.
+	CharNGramMin int
+	CharNGramMax int
+	// DetectLanguage makes TokenizerWords detect each sample's language with
+	// DetectLanguage and stem and filter stop words for it, falling back to
+	// Language when detection finds no stop words. Ignored by TokenizerChars.
+	DetectLanguage bool
+	// ExtraStopWords are filtered in addition to the built-in list, even when
+	// RemoveStopWords is false. They match both as written and when stemmed.
+	ExtraStopWords []string
+	// ExcludedStopWords are built-in stop words kept as tokens when
+	// RemoveStopWords is set.
+	ExcludedStopWords []string
+	// Normalizers lists the normalization stages applied before splitting
+	// text into words; see NormalizeURLs and the other Normalize constants.
+	Normalizers []string
+}
+
+// TokenizerOption customizes TokenizerOptions in NewClassifierWithOptions.
+type TokenizerOption func(*TokenizerOptions)
+
+// WithNGrams makes the tokenizer also emit word n-grams up to length n.
+func WithNGrams(n int) TokenizerOption {
+	return func(opts *TokenizerOptions) {
+		opts.NGrams = n
+	}
+}
+
+// WithTokenizer selects a tokenizer by name (TokenizerWords or
+// TokenizerChars). Unknown names select TokenizerWords.
+func WithTokenizer(name string) TokenizerOption {
+	return func(opts *TokenizerOptions) {
+		opts.Tokenizer = name
+	}
+}
+
+// WithCharNGrams sets the character n-gram lengths emitted by TokenizerChars.
+func WithCharNGrams(minN, maxN int) TokenizerOption {
+	return func(opts *TokenizerOptions) {
+		opts.CharNGramMin = minN
+		opts.CharNGramMax = maxN
+	}
+}
+
+// WithLanguageDetection makes the tokenizer detect each sample's language
+// instead of always using the classifier's language; see
+// TokenizerOptions.DetectLanguage.
+func WithLanguageDetection() TokenizerOption {
+	return func(opts *TokenizerOptions) {
+		opts.DetectLanguage = true
+	}
+}
+
+// WithStopWords customizes stop-word filtering: extra words are always
+// filtered and excluded words are kept even when they are built-in stop words.
+func WithStopWords(extra, excluded []string) TokenizerOption {
+	return func(opts *TokenizerOptions) {
+		opts.ExtraStopWords = extra
+		opts.ExcludedStopWords = excluded
+	}
+}
+
+// WithNormalizers enables normalization stages such as NormalizeURLs and
+// NormalizeMoney. Unknown stage names are ignored.
+func WithNormalizers(stages ...string) TokenizerOption {
+	return func(opts *TokenizerOptions) {
+		opts.Normalizers = stages
+	}
+}
+
+// ParseTokenizer returns the tokenizer named by s (case-insensitive). Empty
+// input returns an empty name, which selects a tokenizer by language.
+func ParseTokenizer(s string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch name {
+	case "", TokenizerWords, TokenizerChars:
+		return name, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidTokenizer, s)
+	}
+}
+
+// normalize returns opts with the language lowercased and defaulted to
+// english, NGrams clamped to at least 1, stop-word customizations lowercased,
+// deduplicated, and sorted, normalizers in pipeline order, the tokenizer
+// resolved, and the
+// character n-gram range filled in for TokenizerChars. Unknown tokenizer
+// names fall back to TokenizerWords.
+func (opts TokenizerOptions) normalize() TokenizerOptions {
+	opts.Language = strings.ToLower(strings.TrimSpace(opts.Language))
+	if opts.Language == "" {
+		opts.Language = "english"
+	}
+	opts.NGrams = max(opts.NGrams, 1)
+	opts.ExtraStopWords = normalizeWords(opts.ExtraStopWords)
+	opts.ExcludedStopWords = normalizeWords(opts.ExcludedStopWords)
+	opts.Normalizers = normalizeStages(opts.Normalizers)
+
+	opts.Tokenizer, _ = ParseTokenizer(opts.Tokenizer)
+	if opts.Tokenizer == "" && charLanguages[opts.Language] {
+		opts.Tokenizer = TokenizerChars
+	}
+	if opts.Tokenizer != TokenizerChars {
+		opts.Tokenizer = TokenizerWords
+		opts.CharNGramMin, opts.CharNGramMax = 0, 0
+		return opts
+	}
+	opts.DetectLanguage = false
+	if opts.CharNGramMin < 1 {
+		opts.CharNGramMin = defaultCharNGramMin
+	}
+	if opts.CharNGramMax < 1 {
+		opts.CharNGramMax = defaultCharNGramMax
+	}
+	opts.CharNGramMax = max(opts.CharNGramMax, opts.CharNGramMin)
+	return opts
+}
+
 // NewDefaultTokenizer returns a tokenizer that normalizes (NFKC), lowercases
 // with locale-aware case folding, splits on non-alphanumeric runes, stems with
 // the given language, and optionally filters stop words. Language must be one of
@@ -44,34 +208,48 @@ var languageTag = map[string]language.Tag{
 //
 // When snowball.Stem fails or returns empty, the original token is kept.
 func NewDefaultTokenizer(lang string, removeStopWords bool) func(string) []string {
-	lang = strings.ToLower(strings.TrimSpace(lang))
-	if lang == "" {
-		lang = "english"
+	return NewTokenizer(TokenizerOptions{Language: lang, RemoveStopWords: removeStopWords})
+}
+
+// NewTokenizer returns the tokenizer configured by opts. For TokenizerWords, when
+// opts.NGrams is 2 or more, each run of consecutive stems (after stop-word
+// filtering) up to that length is also emitted, joined by single spaces, so
+// "not good" yields "not", "good", and "not good". When opts.DetectLanguage is
+// set, each sample is stemmed with the rules of its detected language.
+func NewTokenizer(opts TokenizerOptions) func(string) []string {
+	opts = opts.normalize()
+	if opts.Tokenizer == TokenizerChars {
+		return newCharTokenizer(opts)
 	}
+	if opts.DetectLanguage {
+		return newDetectingTokenizer(opts)
+	}
+	lang := opts.Language
 	if _, ok := snowballLang[lang]; !ok {
 		lang = "english"
 	}
 	stemLang := snowballLang[lang]
 	tag := languageTag[lang]
 	lower := cases.Lower(tag)
-	var stopSet map[string]struct{}
-	if removeStopWords {
-		stopSet = stopwords.Get(lang)
+	stem := func(word string) string {
+		if stemmed, err := snowball.Stem(word, stemLang, true); err == nil && stemmed != "" {
+			word = stemmed
+		}
+		return word
 	}
+	stopSet := stopWordSet(lang, opts, stem)
+	ngrams := opts.NGrams
+	normalizer := newTextNormalizer(opts.Normalizers)
 
-	return func(sample string) []string {
-		sample = norm.NFKC.String(sample)
-		sample = lower.String(sample)
-		rawTokens := strings.FieldsFunc(sample, func(r rune) bool {
+	words := func(segment string) []string {
+		segment = lower.String(segment)
+		rawTokens := strings.FieldsFunc(segment, func(r rune) bool {
 			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
 		})
 
 		tokens := make([]string, 0, len(rawTokens))
 		for _, token := range rawTokens {
-			stemmed, err := snowball.Stem(token, stemLang, true)
-			if err == nil && stemmed != "" {
-				token = stemmed
-			}
+			token = stem(token)
 			if stopSet != nil {
 				if _, ok := stopSet[token]; ok {
 					continue
@@ -83,4 +261,185 @@ func NewDefaultTokenizer(lang string, removeStopWords bool) func(string) []strin
 		}
 		return tokens
 	}
+
+	return func(sample string) []string {
+		sample = norm.NFKC.String(sample)
+		return appendNGrams(normalizer.tokenize(sample, words), ngrams)
+	}
+}
+
+// stopWordSet returns the stop words filtered for lang: the built-in list when
+// opts.RemoveStopWords is set, plus opts.ExtraStopWords, minus
+// opts.ExcludedStopWords. Custom words are matched as written and, when stem
+// is not nil, in stemmed form. It returns nil when nothing is filtered.
+func stopWordSet(lang string, opts TokenizerOptions, stem func(string) string) map[string]struct{} {
+	var builtin map[string]struct{}
+	if opts.RemoveStopWords {
+		builtin = stopwords.Get(lang)
+	}
+	if len(opts.ExtraStopWords) == 0 && len(opts.ExcludedStopWords) == 0 {
+		return builtin
+	}
+
+	forms := func(word string) []string {
+		if stem == nil {
+			return []string{word}
+		}
+		return []string{word, stem(word)}
+	}
+	set := make(map[string]struct{}, len(builtin)+2*len(opts.ExtraStopWords))
+	for word := range builtin {
+		set[word] = struct{}{}
+	}
+	for _, word := range opts.ExcludedStopWords {
+		for _, form := range forms(word) {
+			delete(set, form)
+		}
+	}
+	for _, word := range opts.ExtraStopWords {
+		for _, form := range forms(word) {
+			set[form] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
+
+// normalizeWords returns words lowercased, trimmed, deduplicated, and sorted,
+// or nil when none remain.
+func normalizeWords(words []string) []string {
+	var out []string
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			out = append(out, word)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// appendNGrams appends every run of 2 to n consecutive tokens, joined by a
+// space, after the single tokens.
+func appendNGrams(tokens []string, n int) []string {
+	words := len(tokens)
+	for size := 2; size <= n; size++ {
+		for start := 0; start+size <= words; start++ {
+			tokens = append(tokens, strings.Join(tokens[start:start+size], " "))
+		}
+	}
+	return tokens
+}
+
+// Script classes used by the character tokenizer. Runes of different classes
+// never share a token.
+const (
+	scriptWords = iota // scripts that separate words with spaces
+	scriptCJK
+	scriptHangul
+	scriptThai
+	scriptLao
+	scriptKhmer
+	scriptMyanmar
+)
+
+// scriptClass returns the script class of a letter or digit.
+func scriptClass(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Bopomofo):
+		return scriptCJK
+	case unicode.Is(unicode.Hangul, r):
+		return scriptHangul
+	case unicode.Is(unicode.Thai, r):
+		return scriptThai
+	case unicode.Is(unicode.Lao, r):
+		return scriptLao
+	case unicode.Is(unicode.Khmer, r):
+		return scriptKhmer
+	case unicode.Is(unicode.Myanmar, r):
+		return scriptMyanmar
+	default:
+		return scriptWords
+	}
+}
+
+// newCharTokenizer returns a TokenizerChars tokenizer. Text is NFKC
+// normalized, lowercased, and split into runs of letters, digits, and marks
+// of one script class. Runs in space-separated scripts become whole-word
+// tokens (stop words removed when configured and available for the
+// language); other runs emit every character n-gram in the configured range,
+// or the whole run when it is shorter than the minimum.
+func newCharTokenizer(opts TokenizerOptions) func(string) []string {
+	tag, ok := languageTag[opts.Language]
+	if !ok {
+		tag = language.Und
+	}
+	lower := cases.Lower(tag)
+	stopSet := stopWordSet(opts.Language, opts, nil)
+	minN, maxN := opts.CharNGramMin, opts.CharNGramMax
+	normalizer := newTextNormalizer(opts.Normalizers)
+
+	chars := func(segment string) []string {
+		segment = lower.String(segment)
+
+		var tokens []string
+		var run []rune
+		class := scriptWords
+		flush := func() {
+			if len(run) == 0 {
+				return
+			}
+			if class == scriptWords {
+				word := string(run)
+				if _, stop := stopSet[word]; !stop {
+					tokens = append(tokens, word)
+				}
+			} else {
+				tokens = appendCharNGrams(tokens, run, minN, maxN)
+			}
+			run = run[:0]
+		}
+
+		for _, r := range segment {
+			switch {
+			case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r):
+				// Combining marks (e.g. Thai vowels) extend the current run.
+				if len(run) > 0 {
+					run = append(run, r)
+				}
+			case unicode.Is(unicode.Lm, r) && len(run) > 0 && class != scriptWords:
+				// Modifier letters such as the katakana prolonged sound mark.
+				run = append(run, r)
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				if next := scriptClass(r); next != class {
+					flush()
+					class = next
+				}
+				run = append(run, r)
+			default:
+				flush()
+			}
+		}
+		flush()
+		return tokens
+	}
+
+	return func(sample string) []string {
+		return normalizer.tokenize(norm.NFKC.String(sample), chars)
+	}
+}
+
+// appendCharNGrams appends every n-gram of run with minN <= n <= maxN, or the
+// whole run when it is shorter than minN.
+func appendCharNGrams(tokens []string, run []rune, minN, maxN int) []string {
+	if len(run) < minN {
+		return append(tokens, string(run))
+	}
+	for size := minN; size <= maxN && size <= len(run); size++ {
+		for start := 0; start+size <= len(run); start++ {
+			tokens = append(tokens, string(run[start:start+size]))
+		}
+	}
+	return tokens
 }
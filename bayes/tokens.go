@@ -0,0 +1,205 @@
+package bayes
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// TokenSort selects the order of CategoryTokens results.
+type TokenSort string
+
+const (
+	// TokenSortCount orders tokens by count, most frequent first, with ties
+	// in alphabetical order. This is the default.
+	TokenSortCount TokenSort = "count"
+	// TokenSortToken orders tokens alphabetically.
+	TokenSortToken TokenSort = "token"
+)
+
+// DefaultTokenPageLimit is the page size CategoryTokens uses when
+// TokenPageOptions.Limit is zero.
+const DefaultTokenPageLimit = 100
+
+// ErrInvalidTokenQuery indicates a negative offset or limit, an unknown sort
+// order, or a non-positive number of tokens to rank.
+var ErrInvalidTokenQuery = errors.New("invalid token query")
+
+// TokenPageOptions selects a page of CategoryTokens results.
+type TokenPageOptions struct {
+	// Offset is the number of sorted tokens to skip.
+	Offset int
+	// Limit is the largest number of tokens returned. Zero means
+	// DefaultTokenPageLimit.
+	Limit int
+	// Sort orders the tokens. Empty means TokenSortCount.
+	Sort TokenSort
+	// Reverse reverses the sort order, e.g. rarest tokens first.
+	Reverse bool
+}
+
+// TokenCount is a token's trained count in one category.
+type TokenCount struct {
+	Token     string  `json:"token"`
+	Count     float64 `json:"count"`     // occurrences, fractional after Decay or weighted training
+	Documents float64 `json:"documents"` // trained documents that contained the token
+}
+
+// TokenPage is one page of a category's tokens.
+type TokenPage struct {
+	Category string       `json:"category"`
+	Total    int          `json:"total"` // distinct tokens in the category
+	Offset   int          `json:"offset"`
+	Tokens   []TokenCount `json:"tokens"`
+}
+
+// TokenLookup reports how often a token was trained into each category.
+type TokenLookup struct {
+	Token      string             `json:"token"`
+	Total      float64            `json:"total"`      // occurrences across all categories
+	Categories map[string]float64 `json:"categories"` // occurrences per category, including zeros
+}
+
+// TokenScore is a token ranked by how strongly it indicates a category.
+type TokenScore struct {
+	Token string  `json:"token"`
+	Count float64 `json:"count"` // occurrences in the category
+	// Score is the smoothed log-odds ratio of the token's rate in the
+	// category versus all other categories; positive values favor the
+	// category.
+	Score float64 `json:"score"`
+}
+
+// ParseTokenSort returns the TokenSort named by s (case-insensitive). Empty
+// input returns TokenSortCount.
+func ParseTokenSort(s string) (TokenSort, error) {
+	sort := TokenSort(strings.ToLower(strings.TrimSpace(s)))
+	switch sort {
+	case "":
+		return TokenSortCount, nil
+	case TokenSortCount, TokenSortToken:
+		return sort, nil
+	default:
+		return "", fmt.Errorf("%w: sort %q (want count or token)", ErrInvalidTokenQuery, s)
+	}
+}
+
+// CategoryTokens returns one page of a category's tokens with their counts,
+// in the order opts selects.
+func (c *Classifier) CategoryTokens(name string, opts TokenPageOptions) (TokenPage, error) {
+	sort, err := ParseTokenSort(string(opts.Sort))
+	if err != nil {
+		return TokenPage{}, err
+	}
+	if opts.Offset < 0 || opts.Limit < 0 {
+		return TokenPage{}, fmt.Errorf("%w: offset %d, limit %d", ErrInvalidTokenQuery, opts.Offset, opts.Limit)
+	}
+	limit := cmp.Or(opts.Limit, DefaultTokenPageLimit)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cat, err := c.lookupCategoryUnlocked(name)
+	if err != nil {
+		return TokenPage{}, err
+	}
+
+	tokens := make([]TokenCount, 0, cat.VocabularySize())
+	for token, count := range cat.Tokens() {
+		tokens = append(tokens, TokenCount{Token: token, Count: count, Documents: cat.GetTokenDocumentCount(token)})
+	}
+	slices.SortFunc(tokens, func(a, b TokenCount) int {
+		if sort == TokenSortCount && a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
+		}
+		return strings.Compare(a.Token, b.Token)
+	})
+	if opts.Reverse {
+		slices.Reverse(tokens)
+	}
+
+	start := min(opts.Offset, len(tokens))
+	end := start + min(limit, len(tokens)-start)
+	return TokenPage{
+		Category: name,
+		Total:    len(tokens),
+		Offset:   opts.Offset,
+		Tokens:   slices.Clip(tokens[start:end]),
+	}, nil
+}
+
+// LookupToken runs text through the classifier's tokenizer and reports the
+// trained counts of every distinct token it produces, in order of first
+// appearance. Text the tokenizer drops entirely, such as a stop word,
+// returns no results.
+func (c *Classifier) LookupToken(text string) []TokenLookup {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := c.categories.Names()
+	seen := make(map[string]bool)
+	lookups := make([]TokenLookup, 0)
+	for _, token := range c.getTokenizer()(text) {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+
+		lookup := TokenLookup{
+			Token:      token,
+			Total:      c.categories.TokenTotal(token),
+			Categories: make(map[string]float64, len(names)),
+		}
+		for _, name := range names {
+			cat, _ := c.categories.LookupCategory(name)
+			lookup.Categories[name] = cat.GetTokenCount(token)
+		}
+		lookups = append(lookups, lookup)
+	}
+	return lookups
+}
+
+// DiscriminativeTokens returns the n tokens of a category that most favor it
+// over the other categories, highest score first. Tokens are scored by the
+// log-odds ratio of their add-one smoothed rates inside and outside the
+// category, so frequent tokens shared evenly by every category rank low.
+func (c *Classifier) DiscriminativeTokens(name string, n int) ([]TokenScore, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w: n %d", ErrInvalidTokenQuery, n)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cat, err := c.lookupCategoryUnlocked(name)
+	if err != nil {
+		return nil, err
+	}
+
+	vocabulary := float64(c.categories.VocabularySize())
+	otherTally := -cat.GetTally()
+	for _, other := range c.categories.Names() {
+		otherCat, _ := c.categories.LookupCategory(other)
+		otherTally += otherCat.GetTally()
+	}
+	otherTally = max(otherTally, 0)
+	inDenominator := math.Log(cat.GetTally() + vocabulary)
+	outDenominator := math.Log(otherTally + vocabulary)
+
+	scores := make([]TokenScore, 0, cat.VocabularySize())
+	for token, count := range cat.Tokens() {
+		otherCount := max(c.categories.TokenTotal(token)-count, 0)
+		score := math.Log(count+1) - inDenominator - math.Log(otherCount+1) + outDenominator
+		scores = append(scores, TokenScore{Token: token, Count: count, Score: score})
+	}
+	slices.SortFunc(scores, func(a, b TokenScore) int {
+		if a.Score != b.Score {
+			return cmp.Compare(b.Score, a.Score)
+		}
+		return strings.Compare(a.Token, b.Token)
+	})
+	return slices.Clip(scores[:min(n, len(scores))]), nil
+}
@@ -0,0 +1,106 @@
+package bayes
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestCategoryTokens verifies sorting and paging a category's tokens.
+func TestCategoryTokens(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "buy buy buy cheap cheap pills")
+	_ = c.Train("spam", "buy now")
+
+	page, err := c.CategoryTokens("spam", TokenPageOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TokenCount{{"buy", 4, 2}, {"cheap", 2, 1}, {"now", 1, 1}, {"pill", 1, 1}}
+	if page.Category != "spam" || page.Total != 4 || len(page.Tokens) != len(want) {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	for i, token := range want {
+		if page.Tokens[i] != token {
+			t.Fatalf("token %d: got %+v want %+v", i, page.Tokens[i], token)
+		}
+	}
+
+	page, _ = c.CategoryTokens("spam", TokenPageOptions{Offset: 1, Limit: 2, Reverse: true})
+	if page.Offset != 1 || len(page.Tokens) != 2 || page.Tokens[0].Token != "now" || page.Tokens[1].Token != "cheap" {
+		t.Fatalf("unexpected reversed page: %+v", page)
+	}
+	page, _ = c.CategoryTokens("spam", TokenPageOptions{Sort: TokenSortToken, Limit: 1})
+	if len(page.Tokens) != 1 || page.Tokens[0].Token != "buy" || page.Total != 4 {
+		t.Fatalf("unexpected alphabetical page: %+v", page)
+	}
+	if page, _ := c.CategoryTokens("spam", TokenPageOptions{Offset: 10}); page.Tokens == nil || len(page.Tokens) != 0 {
+		t.Fatalf("expected an empty page past the end, got %+v", page)
+	}
+
+	for _, opts := range []TokenPageOptions{{Offset: -1}, {Limit: -1}, {Sort: "rank"}} {
+		if _, err := c.CategoryTokens("spam", opts); !errors.Is(err, ErrInvalidTokenQuery) {
+			t.Fatalf("%+v: expected ErrInvalidTokenQuery, got %v", opts, err)
+		}
+	}
+	if _, err := c.CategoryTokens("ham", TokenPageOptions{}); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("expected ErrCategoryNotFound, got %v", err)
+	}
+}
+
+// TestLookupToken verifies tokens are looked up after tokenization.
+func TestLookupToken(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "buying pills")
+	_ = c.Train("ham", "buy groceries")
+
+	lookups := c.LookupToken("Buying buy")
+	if len(lookups) != 1 {
+		t.Fatalf("expected one distinct stemmed token, got %+v", lookups)
+	}
+	lookup := lookups[0]
+	if lookup.Token != "buy" || lookup.Total != 2 || lookup.Categories["spam"] != 1 || lookup.Categories["ham"] != 1 {
+		t.Fatalf("unexpected lookup: %+v", lookup)
+	}
+
+	lookups = c.LookupToken("unseen")
+	if len(lookups) != 1 || lookups[0].Total != 0 || len(lookups[0].Categories) != 2 || lookups[0].Categories["spam"] != 0 {
+		t.Fatalf("expected zero counts in every category, got %+v", lookups)
+	}
+	if lookups := c.LookupToken("  "); len(lookups) != 0 {
+		t.Fatalf("expected no lookups for blank text, got %+v", lookups)
+	}
+}
+
+// TestDiscriminativeTokens verifies tokens unique to a category outrank shared ones.
+func TestDiscriminativeTokens(t *testing.T) {
+	c := NewClassifier()
+	_ = c.Train("spam", "free free free offer offer hello hello hello")
+	_ = c.Train("ham", "meeting meeting hello hello hello")
+
+	scores, err := c.DiscriminativeTokens("spam", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scores) != 3 || scores[0].Token != "free" || scores[1].Token != "offer" || scores[2].Token != "hello" {
+		t.Fatalf("unexpected ranking: %+v", scores)
+	}
+	if scores[0].Count != 3 || scores[0].Score <= 0 || scores[2].Score >= scores[1].Score {
+		t.Fatalf("unexpected scores: %+v", scores)
+	}
+
+	if scores, _ := c.DiscriminativeTokens("ham", 1); len(scores) != 1 || scores[0].Token != "meet" {
+		t.Fatalf("unexpected top ham token: %+v", scores)
+	}
+	if _, err := c.DiscriminativeTokens("spam", 0); !errors.Is(err, ErrInvalidTokenQuery) {
+		t.Fatalf("expected ErrInvalidTokenQuery, got %v", err)
+	}
+	if _, err := c.DiscriminativeTokens("bad name", 5); !errors.Is(err, ErrInvalidCategoryName) {
+		t.Fatalf("expected ErrInvalidCategoryName, got %v", err)
+	}
+
+	c = NewClassifier()
+	_ = c.Train("spam", "offer free")
+	if scores, _ := c.DiscriminativeTokens("spam", 2); len(scores) != 2 || scores[0].Token != "free" || scores[1].Token != "offer" {
+		t.Fatalf("expected ties to be ranked by token, got %+v", scores)
+	}
+}
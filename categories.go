@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// maxTokenPageLimit caps the tokens returned by one /categories/{name}/tokens
+// or /categories/{name}/discriminative request.
+const maxTokenPageLimit = 1000
+
+// defaultDiscriminativeTokens is the number of tokens
+// /categories/{name}/discriminative returns without n.
+const defaultDiscriminativeTokens = 20
+
+// renameCategoryRequest is the body of POST /categories/{name}/rename.
+type renameCategoryRequest struct {
+	Name string `json:"name"`
+}
+
+// mergeCategoriesRequest is the body of POST /categories/{name}/merge.
+type mergeCategoriesRequest struct {
+	Sources []string `json:"sources"`
+}
+
+// CategoryHandler administers and inspects trained categories:
+// DELETE /categories/{name} deletes one, POST /categories/{name}/rename with
+// {"name": "..."} renames it, POST /categories/{name}/merge with
+// {"sources": [...]} folds the source categories into it,
+// GET /categories/{name}/tokens pages through its tokens, and
+// GET /categories/{name}/discriminative ranks the tokens that most favor it.
+func (c *ClassifierAPI) CategoryHandler(w http.ResponseWriter, req *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/categories/"), "/")
+	if !categoryPathPattern.MatchString(name) {
+		writeError(w, http.StatusNotFound, "invalid category route")
+		return
+	}
+
+	switch action {
+	case "":
+		if !requireMethod(w, req, http.MethodDelete) {
+			return
+		}
+		c.writeCategoryChange(w, c.classifier.DeleteCategory(name))
+	case "rename":
+		if !requireMethod(w, req, http.MethodPost) {
+			return
+		}
+		var rename renameCategoryRequest
+		if !readCategoryRequest(w, req, &rename) {
+			return
+		}
+		c.writeCategoryChange(w, c.classifier.RenameCategory(name, rename.Name))
+	case "merge":
+		if !requireMethod(w, req, http.MethodPost) {
+			return
+		}
+		var merge mergeCategoriesRequest
+		if !readCategoryRequest(w, req, &merge) {
+			return
+		}
+		if len(merge.Sources) == 0 {
+			writeError(w, http.StatusBadRequest, "invalid merge request: sources must not be empty")
+			return
+		}
+		c.writeCategoryChange(w, c.classifier.MergeCategories(name, merge.Sources...))
+	case "tokens":
+		if !requireMethod(w, req, http.MethodGet) {
+			return
+		}
+		c.categoryTokens(w, req, name)
+	case "discriminative":
+		if !requireMethod(w, req, http.MethodGet) {
+			return
+		}
+		c.discriminativeTokens(w, req, name)
+	default:
+		writeError(w, http.StatusNotFound, "invalid category route")
+	}
+}
+
+// categoryTokens handles GET /categories/{name}/tokens.
+func (c *ClassifierAPI) categoryTokens(w http.ResponseWriter, req *http.Request, name string) {
+	opts, err := tokenPageOptionsFromQuery(req.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := c.classifier.CategoryTokens(name, opts)
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// discriminativeTokens handles GET /categories/{name}/discriminative.
+func (c *ClassifierAPI) discriminativeTokens(w http.ResponseWriter, req *http.Request, name string) {
+	n := defaultDiscriminativeTokens
+	if raw := req.URL.Query().Get("n"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxTokenPageLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid n: %q", raw))
+			return
+		}
+		n = parsed
+	}
+
+	tokens, err := c.classifier.DiscriminativeTokens(name, n)
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &DiscriminativeTokensResponse{Category: name, Tokens: tokens})
+}
+
+// tokenPageOptionsFromQuery parses the optional offset, limit, sort, and
+// reverse query parameters accepted by /categories/{name}/tokens.
+func tokenPageOptionsFromQuery(query url.Values) (bayes.TokenPageOptions, error) {
+	var opts bayes.TokenPageOptions
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return opts, fmt.Errorf("invalid offset: %q", raw)
+		}
+		opts.Offset = offset
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxTokenPageLimit {
+			return opts, fmt.Errorf("invalid limit: %q", raw)
+		}
+		opts.Limit = limit
+	}
+	sort, err := bayes.ParseTokenSort(query.Get("sort"))
+	if err != nil {
+		return opts, fmt.Errorf("invalid sort: %q", query.Get("sort"))
+	}
+	opts.Sort = sort
+	if raw := query.Get("reverse"); raw != "" {
+		reverse, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid reverse: %q", raw)
+		}
+		opts.Reverse = reverse
+	}
+	return opts, nil
+}
+
+// writeCategoryChange responds to a category change with the remaining
+// categories, or with the error that prevented it.
+func (c *ClassifierAPI) writeCategoryChange(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewTrainingClassifierResponse(c, true))
+}
+
+// writeCategoryError maps a category error to 404 for missing categories,
+// 409 for name conflicts, and 400 otherwise.
+func writeCategoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bayes.ErrCategoryNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, bayes.ErrCategoryExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+// readCategoryRequest reads and strictly decodes a JSON request body into
+// value, writing an error response on failure.
+func readCategoryRequest(w http.ResponseWriter, req *http.Request, value any) bool {
+	body, ok := readBody(w, req)
+	if !ok {
+		return false
+	}
+	if err := decodeStrict(body, value); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
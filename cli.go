@@ -0,0 +1,480 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+	"github.com/hickeroar/gobayes/v4/bayes/corpus"
+	"github.com/hickeroar/gobayes/v4/bayes/eval"
+)
+
+// command runs a subcommand. Subcommands accept the server's flags and
+// GOBAYES_ environment variables (see loadServerConfig) plus their own flags,
+// followed by input paths.
+type command func(fs *flag.FlagSet, args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer) error
+
+// commands maps subcommand names to their implementations.
+var commands = map[string]command{
+	"serve": func(fs *flag.FlagSet, args []string, getenv func(string) string, _ io.Reader, _ io.Writer) error {
+		return runServer(fs, args, getenv)
+	},
+	"train":    trainCommand,
+	"import":   importCommand,
+	"classify": classifyCommand,
+	"eval":     evalCommand,
+	"inspect":  inspectCommand,
+}
+
+// cliInput is one sample read by a subcommand, named for error messages and
+// classify output.
+type cliInput struct {
+	Name   string
+	Sample bayes.Sample
+}
+
+// sampleFileExtensions are the extensions of files read as JSON arrays or
+// NDJSON of {"category", "text"} samples instead of as one text.
+var sampleFileExtensions = []string{".ndjson", ".jsonl", ".json"}
+
+// readInputs reads samples from paths, or from stdin when paths is empty or
+// a path is "-". A plain file is one sample. A directory contributes every
+// file below it, labeled with the name of the top-level subdirectory it is
+// in (dir/spam/1.txt is spam). Files with a sampleFileExtensions extension,
+// and stdin when samples is set, hold labeled samples. category, when set,
+// labels every sample that has no label of its own.
+func readInputs(paths []string, category string, samples bool, stdin io.Reader) ([]cliInput, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var inputs []cliInput
+	for _, path := range paths {
+		if path == "-" {
+			raw, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("read stdin: %w", err)
+			}
+			read, err := parseInput("-", string(raw), "", samples)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, read...)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			read, err := readInputFile(path, "")
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, read...)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(file string, entry os.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			// file is below path, so Rel cannot fail.
+			rel, _ := filepath.Rel(path, file)
+			label, _, nested := strings.Cut(filepath.ToSlash(rel), "/")
+			if !nested {
+				label = ""
+			}
+			read, err := readInputFile(file, label)
+			if err != nil {
+				return err
+			}
+			inputs = append(inputs, read...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range inputs {
+		if inputs[i].Sample.Category == "" {
+			inputs[i].Sample.Category = category
+		}
+	}
+	return inputs, nil
+}
+
+// readInputFile reads the samples in one file, labeling a plain text file
+// with label.
+func readInputFile(path, label string) ([]cliInput, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, sampleExt := range sampleFileExtensions {
+		if ext == sampleExt {
+			return parseInput(path, string(raw), label, true)
+		}
+	}
+	return parseInput(path, string(raw), label, false)
+}
+
+// parseInput turns the contents of name into samples: decoded with
+// decodeBatch when samples is set, otherwise one sample labeled label.
+func parseInput(name, raw, label string, samples bool) ([]cliInput, error) {
+	if !samples {
+		return []cliInput{{Name: name, Sample: bayes.Sample{Category: label, Text: raw}}}, nil
+	}
+
+	decoded, err := decodeBatch(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	inputs := make([]cliInput, len(decoded))
+	for i, sample := range decoded {
+		if sample.Category == "" {
+			sample.Category = label
+		}
+		inputs[i] = cliInput{Name: fmt.Sprintf("%s:%d", name, i+1), Sample: sample}
+	}
+	return inputs, nil
+}
+
+// labeledSamples returns the samples of inputs, checking that every one has a
+// valid category.
+func labeledSamples(inputs []cliInput) ([]bayes.Sample, error) {
+	samples := make([]bayes.Sample, len(inputs))
+	for i, input := range inputs {
+		if input.Sample.Category == "" {
+			return nil, fmt.Errorf("%s: no category (use --category or a category subdirectory)", input.Name)
+		}
+		if !bayes.ValidCategoryName(input.Sample.Category) {
+			return nil, fmt.Errorf("%s: invalid category %q", input.Name, input.Sample.Category)
+		}
+		samples[i] = input.Sample
+	}
+	return samples, nil
+}
+
+// openModel returns a classifier configured by cfg with cfg.ModelFile loaded
+// into it; settings stored in the file take precedence. A missing or unset
+// model file yields an untrained classifier unless mustExist is set.
+func openModel(cfg *serverConfig, mustExist bool) (*bayes.Classifier, error) {
+	if cfg.ModelFile == "" && mustExist {
+		return nil, errors.New("--model-file or GOBAYES_MODEL_FILE is required")
+	}
+	classifier, err := newConfiguredClassifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ModelFile == "" {
+		return classifier, nil
+	}
+	if err := classifier.LoadFromFile(cfg.ModelFile); err != nil && (mustExist || !errors.Is(err, os.ErrNotExist)) {
+		return nil, fmt.Errorf("load model: %w", err)
+	}
+	return classifier, nil
+}
+
+// writeIndentedJSON writes value to w as indented JSON.
+func writeIndentedJSON(w io.Writer, value any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
+
+// inputFlags registers the flags shared by subcommands that read labeled
+// inputs.
+func inputFlags(fs *flag.FlagSet) (category *string, samples *bool) {
+	category = fs.String("category", "", "Category of inputs without one of their own.")
+	return category, samplesFlag(fs)
+}
+
+// samplesFlag registers the flag shared by subcommands that read inputs.
+func samplesFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("samples", false, "Read stdin as a JSON array or NDJSON of {\"category\", \"text\"} samples.")
+}
+
+// cliTrainResponse is printed by the train subcommand.
+type cliTrainResponse struct {
+	Trained    int                      `json:"trained"`
+	Categories map[string]*CategoryInfo `json:"categories"`
+}
+
+// trainCommand trains the inputs into the model file, creating it if needed.
+func trainCommand(fs *flag.FlagSet, args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer) error {
+	category, samples := inputFlags(fs)
+	formatFlag := fs.String("format", "text", "Input format: text, html, or email.")
+	weightFlag := fs.Float64("weight", 1, "How many times each sample counts.")
+	untrainFlag := fs.Bool("untrain", false, "Remove the inputs from the model instead of adding them.")
+	cfg, err := loadServerConfig(fs, args, getenv)
+	if err != nil {
+		return err
+	}
+	format, err := bayes.ParseInputFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+	if !(*weightFlag > 0) || math.IsInf(*weightFlag, 0) {
+		return fmt.Errorf("invalid weight: %v", *weightFlag)
+	}
+	if cfg.ModelFile == "" {
+		return errors.New("--model-file or GOBAYES_MODEL_FILE is required")
+	}
+
+	inputs, err := readInputs(fs.Args(), *category, *samples, stdin)
+	if err != nil {
+		return err
+	}
+	if _, err := labeledSamples(inputs); err != nil {
+		return err
+	}
+	classifier, err := openModel(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	update := classifier.TrainInputWeighted
+	if *untrainFlag {
+		update = classifier.UntrainInputWeighted
+	}
+	for _, input := range inputs {
+		if err := update(input.Sample.Category, format, input.Sample.Text, *weightFlag); err != nil {
+			return fmt.Errorf("%s: %w", input.Name, err)
+		}
+	}
+	if err := classifier.SaveToFile(cfg.ModelFile); err != nil {
+		return fmt.Errorf("save model: %w", err)
+	}
+
+	return writeIndentedJSON(stdout, &cliTrainResponse{
+		Trained:    len(inputs),
+		Categories: getCategoryList(&ClassifierAPI{classifier: classifier}),
+	})
+}
+
+// cliImportResult reports the import of one corpus by the import subcommand.
+type cliImportResult struct {
+	Source string `json:"source"`
+	corpus.Report
+}
+
+// cliImportResponse is printed by the import subcommand.
+type cliImportResponse struct {
+	Imports    []cliImportResult        `json:"imports"`
+	Categories map[string]*CategoryInfo `json:"categories"`
+}
+
+// importCommand streams corpora into the model file, creating it if needed.
+// Directories are imported with corpus.ImportDir, .csv and .tsv files with
+// corpus.ImportCSV, and .ndjson and .jsonl files and stdin with
+// corpus.ImportNDJSON (or ImportCSV with --csv). The model is saved only when
+// every corpus could be read.
+func importCommand(fs *flag.FlagSet, args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer) error {
+	formatFlag := fs.String("format", "text", "Input format of files in directories: text, html, or email.")
+	labelColumnFlag := fs.String("label-column", "category", "CSV header column holding each row's category.")
+	textColumnFlag := fs.String("text-column", "text", "CSV header column holding each row's text.")
+	csvFlag := fs.Bool("csv", false, "Read stdin as CSV instead of NDJSON.")
+	cfg, err := loadServerConfig(fs, args, getenv)
+	if err != nil {
+		return err
+	}
+	format, err := bayes.ParseInputFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+	if cfg.ModelFile == "" {
+		return errors.New("--model-file or GOBAYES_MODEL_FILE is required")
+	}
+	classifier, err := openModel(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	csvOpts := corpus.CSVOptions{LabelColumn: *labelColumnFlag, TextColumn: *textColumnFlag}
+	importCSV := func(r io.Reader, comma rune) (corpus.Report, error) {
+		opts := csvOpts
+		opts.Comma = comma
+		return corpus.ImportCSV(classifier, r, opts)
+	}
+	importFile := func(path string) (corpus.Report, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return corpus.Report{}, err
+		}
+		if info.IsDir() {
+			return corpus.ImportDir(classifier, path, format)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return corpus.Report{}, err
+		}
+		defer f.Close()
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			return importCSV(f, ',')
+		case ".tsv":
+			return importCSV(f, '\t')
+		case ".ndjson", ".jsonl":
+			return corpus.ImportNDJSON(classifier, f)
+		default:
+			return corpus.Report{}, errors.New("unsupported corpus file (want a directory, .csv, .tsv, .ndjson, or .jsonl)")
+		}
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	resp := &cliImportResponse{Imports: make([]cliImportResult, 0, len(paths))}
+	for _, path := range paths {
+		var report corpus.Report
+		switch {
+		case path == "-" && *csvFlag:
+			report, err = importCSV(stdin, ',')
+		case path == "-":
+			report, err = corpus.ImportNDJSON(classifier, stdin)
+		default:
+			report, err = importFile(path)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		resp.Imports = append(resp.Imports, cliImportResult{Source: path, Report: report})
+	}
+	if err := classifier.SaveToFile(cfg.ModelFile); err != nil {
+		return fmt.Errorf("save model: %w", err)
+	}
+
+	resp.Categories = getCategoryList(&ClassifierAPI{classifier: classifier})
+	return writeIndentedJSON(stdout, resp)
+}
+
+// cliClassifyResult is one line of classify subcommand output.
+type cliClassifyResult struct {
+	Input string `json:"input"`
+	bayes.RankedClassification
+}
+
+// classifyCommand prints one NDJSON classification per input.
+func classifyCommand(fs *flag.FlagSet, args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer) error {
+	samples := samplesFlag(fs)
+	formatFlag := fs.String("format", "text", "Input format: text, html, or email.")
+	nFlag := fs.Int("n", 0, "Also list the top n categories in each result.")
+	cfg, err := loadServerConfig(fs, args, getenv)
+	if err != nil {
+		return err
+	}
+	format, err := bayes.ParseInputFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+	if *nFlag < 0 {
+		return fmt.Errorf("invalid n: %d", *nFlag)
+	}
+
+	inputs, err := readInputs(fs.Args(), "", *samples, stdin)
+	if err != nil {
+		return err
+	}
+	classifier, err := openModel(cfg, true)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(stdout)
+	for _, input := range inputs {
+		result, err := classifier.ClassifyInput(format, input.Sample.Text, bayes.ClassifyOptions{N: *nFlag})
+		if err != nil {
+			return fmt.Errorf("%s: %w", input.Name, err)
+		}
+		if err := enc.Encode(&cliClassifyResult{Input: input.Name, RankedClassification: result}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evalCommand evaluates labeled inputs against the model file, or with
+// --folds cross-validates them on copies of the configured classifier.
+func evalCommand(fs *flag.FlagSet, args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer) error {
+	category, samples := inputFlags(fs)
+	foldsFlag := fs.Int("folds", 0, "Cross-validate with this many folds instead of evaluating the model file.")
+	seedFlag := fs.Uint64("seed", 0, "Seed for assigning samples to cross-validation folds.")
+	cfg, err := loadServerConfig(fs, args, getenv)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := readInputs(fs.Args(), *category, *samples, stdin)
+	if err != nil {
+		return err
+	}
+	labeled, err := labeledSamples(inputs)
+	if err != nil {
+		return err
+	}
+
+	// Cross-validation only borrows the model file's settings, if any.
+	classifier, err := openModel(cfg, *foldsFlag == 0)
+	if err != nil {
+		return err
+	}
+	var report eval.Report
+	if *foldsFlag == 0 {
+		report, err = eval.Evaluate(classifier, labeled)
+	} else {
+		report, err = eval.CrossValidate(classifier, labeled, eval.Options{Folds: *foldsFlag, Seed: *seedFlag})
+	}
+	if err != nil {
+		return err
+	}
+	return writeIndentedJSON(stdout, report)
+}
+
+// cliInspectResponse is printed by the inspect subcommand.
+type cliInspectResponse struct {
+	ModelFile   string                   `json:"modelFile"`
+	Classifier  *ClassifierInfo          `json:"classifier"`
+	ScoringMode bayes.ScoringMode        `json:"scoringMode"`
+	Smoothing   float64                  `json:"smoothing"`
+	Prior       bayes.PriorSource        `json:"prior"`
+	Categories  map[string]*CategoryInfo `json:"categories"`
+}
+
+// inspectCommand prints the model file's settings and category summaries.
+func inspectCommand(fs *flag.FlagSet, args []string, getenv func(string) string, _ io.Reader, stdout io.Writer) error {
+	cfg, err := loadServerConfig(fs, args, getenv)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	classifier, err := openModel(cfg, true)
+	if err != nil {
+		return err
+	}
+
+	api := &ClassifierAPI{classifier: classifier}
+	scoring := classifier.ScoringOptions()
+	return writeIndentedJSON(stdout, &cliInspectResponse{
+		ModelFile:   cfg.ModelFile,
+		Classifier:  newClassifierInfo(api),
+		ScoringMode: scoring.Mode,
+		Smoothing:   scoring.Smoothing,
+		Prior:       scoring.Prior,
+		Categories:  getCategoryList(api),
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// decayModels decays the default and named classifiers at startup and then
+// every interval, so their trained counts halve every halfLife, until stop is
+// closed. Each model file records when its model was last decayed, so the
+// first decay also covers the time the server was not running. Modified
+// models are picked up by autosave like any other change.
+func (c *ClassifierAPI) decayModels(halfLife, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	c.decayAll(time.Now(), halfLife)
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			c.decayAll(now, halfLife)
+		}
+	}
+}
+
+// decayAll decays the default and every named classifier for the time
+// elapsed since each was last decayed.
+func (c *ClassifierAPI) decayAll(now time.Time, halfLife time.Duration) {
+	classifiers := []*bayes.Classifier{c.classifier}
+	c.tenantsMu.RLock()
+	for _, t := range c.tenants {
+		classifiers = append(classifiers, t.api.classifier)
+	}
+	c.tenantsMu.RUnlock()
+
+	for _, classifier := range classifiers {
+		classifier.DecayUntil(now, halfLife)
+	}
+}
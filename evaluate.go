@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/hickeroar/gobayes/v4/bayes/eval"
+)
+
+// EvaluateHandler classifies every labeled sample in a JSON array or NDJSON
+// request body against the live model, without training on them, and reports
+// accuracy, per-category precision/recall/F1, and a confusion matrix.
+func (c *ClassifierAPI) EvaluateHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	samples, ok := readBatch(w, req)
+	if !ok {
+		return
+	}
+
+	report, err := eval.Evaluate(c.classifier, samples)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -1,4 +1,4 @@
-module github.com/hickeroar/gobayes/v3
+module github.com/hickeroar/gobayes/v4
 
 go 1.26
 
@@ -9,31 +9,64 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"mime"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/hickeroar/gobayes/v3/bayes"
+	"github.com/hickeroar/gobayes/v4/bayes"
+	"github.com/hickeroar/gobayes/v4/bayes/stopwords"
 )
 
 const maxRequestBodyBytes = 1 << 20 // 1 MiB
 
+// bulkRequestTimeout bounds reading and answering batch and import requests,
+// whose bodies are too large for the server's default timeouts.
+const bulkRequestTimeout = 10 * time.Minute
+
+// maxNGrams is the longest word n-gram the server lets classifiers emit.
+const maxNGrams = 3
+
+// maxCharNGrams is the longest character n-gram the server lets classifiers emit.
+const maxCharNGrams = 5
+
 var categoryPathPattern = regexp.MustCompile(`^[-_A-Za-z0-9]+$`)
 
 // serverConfig holds server and classifier configuration loaded from env and flags.
 type serverConfig struct {
-	Host             string
-	Port             string
-	AuthToken        string
-	Language         string
-	RemoveStopWords  bool
-	Verbose          bool
+	Host              string
+	Port              string
+	AuthToken         string
+	Language          string
+	RemoveStopWords   bool
+	NGrams            int
+	Tokenizer         string
+	CharNGramMin      int
+	CharNGramMax      int
+	DetectLanguage    bool
+	StopWordsFile     string
+	Normalizers       []string
+	ExtraStopWords    []string // words added to the stop list by StopWordsFile
+	ExcludedStopWords []string // built-in stop words removed from the stop list by StopWordsFile
+	Verbose           bool
+	ModelFile         string
+	AutosaveInterval  time.Duration
+	DecayHalfLife     time.Duration // zero disables scheduled decay
+	DecayInterval     time.Duration
+	ScoringMode       bayes.ScoringMode
+	Smoothing         float64
+	Prior             bayes.PriorSource
 }
 
 // envOrDefault returns getenv(key) trimmed; if empty, returns def. Used for string env vars.
@@ -59,6 +92,45 @@ func envBool(getenv func(string) string, key string, def bool) bool {
 	}
 }
 
+// envDuration parses getenv(key) trimmed as a time.Duration. Empty uses def.
+func envDuration(getenv func(string) string, key string, def time.Duration) (time.Duration, error) {
+	val := strings.TrimSpace(getenv(key))
+	if val == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// envInt parses getenv(key) trimmed as an int. Empty uses def.
+func envInt(getenv func(string) string, key string, def int) (int, error) {
+	val := strings.TrimSpace(getenv(key))
+	if val == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// envFloat parses getenv(key) trimmed as a float64. Empty uses def.
+func envFloat(getenv func(string) string, key string, def float64) (float64, error) {
+	val := strings.TrimSpace(getenv(key))
+	if val == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
+
 // setUsageDoubleDash sets fs.Usage so that PrintDefaults shows --flag instead of -flag.
 func setUsageDoubleDash(fs *flag.FlagSet) {
 	fs.Usage = func() {
@@ -77,14 +149,63 @@ func loadServerConfig(fs *flag.FlagSet, args []string, getenv func(string) strin
 	authDefault := envOrDefault(getenv, "GOBAYES_AUTH_TOKEN", "")
 	langDefault := envOrDefault(getenv, "GOBAYES_LANGUAGE", "english")
 	removeStopDefault := envBool(getenv, "GOBAYES_REMOVE_STOP_WORDS", false)
+	ngramsDefault, err := envInt(getenv, "GOBAYES_NGRAMS", 1)
+	if err != nil {
+		return nil, err
+	}
+	tokenizerDefault := envOrDefault(getenv, "GOBAYES_TOKENIZER", "")
+	charMinDefault, err := envInt(getenv, "GOBAYES_CHAR_NGRAM_MIN", 1)
+	if err != nil {
+		return nil, err
+	}
+	charMaxDefault, err := envInt(getenv, "GOBAYES_CHAR_NGRAM_MAX", 2)
+	if err != nil {
+		return nil, err
+	}
+	detectDefault := envBool(getenv, "GOBAYES_DETECT_LANGUAGE", false)
+	stopWordsFileDefault := envOrDefault(getenv, "GOBAYES_STOP_WORDS_FILE", "")
+	normalizeDefault := envOrDefault(getenv, "GOBAYES_NORMALIZE", "")
 	verboseDefault := envBool(getenv, "GOBAYES_VERBOSE", false)
+	modelFileDefault := envOrDefault(getenv, "GOBAYES_MODEL_FILE", "")
+	autosaveDefault, err := envDuration(getenv, "GOBAYES_AUTOSAVE_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	decayHalfLifeDefault, err := envDuration(getenv, "GOBAYES_DECAY_HALF_LIFE", 0)
+	if err != nil {
+		return nil, err
+	}
+	decayIntervalDefault, err := envDuration(getenv, "GOBAYES_DECAY_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	scoringDefault := envOrDefault(getenv, "GOBAYES_SCORING_MODE", string(bayes.ScoringClassic))
+	smoothingDefault, err := envFloat(getenv, "GOBAYES_SMOOTHING", 1.0)
+	if err != nil {
+		return nil, err
+	}
+	priorDefault := envOrDefault(getenv, "GOBAYES_PRIOR", string(bayes.PriorTokens))
 
 	hostFlag := fs.String("host", hostDefault, "Host interface to bind. (default: 0.0.0.0)")
 	portFlag := fs.String("port", portDefault, "Port to bind. (default: 8000)")
 	authFlag := fs.String("auth-token", authDefault, "Optional bearer token for non-probe endpoints.")
 	languageFlag := fs.String("language", langDefault, "Language code for stemmer and stop words. (default: english)")
 	removeStopFlag := fs.Bool("remove-stop-words", removeStopDefault, "Filter common stop words (the, is, and, etc.).")
+	ngramsFlag := fs.Int("ngrams", ngramsDefault, "Longest word n-gram added to tokens: 1 (words only), 2 (bigrams), or 3 (trigrams). (default: 1)")
+	tokenizerFlag := fs.String("tokenizer", tokenizerDefault, "Tokenizer: words or chars. Empty selects chars for chinese, japanese, korean, and thai, and words otherwise.")
+	charMinFlag := fs.Int("char-ngram-min", charMinDefault, "Shortest character n-gram emitted by the chars tokenizer. (default: 1)")
+	charMaxFlag := fs.Int("char-ngram-max", charMaxDefault, "Longest character n-gram emitted by the chars tokenizer. (default: 2)")
+	detectFlag := fs.Bool("detect-language", detectDefault, "Detect each sample's language and stem it accordingly; --language is the fallback.")
+	stopWordsFileFlag := fs.String("stop-words-file", stopWordsFileDefault, "File of extra stop words, one per line; lines starting with - keep a built-in stop word.")
+	normalizeFlag := fs.String("normalize", normalizeDefault, "Comma-separated normalization stages: urls, emails, numbers, money, emoji.")
 	verboseFlag := fs.Bool("verbose", verboseDefault, "Log requests, responses, and classifier operations to stderr.")
+	modelFileFlag := fs.String("model-file", modelFileDefault, "JSON model file loaded at startup and saved when the model changes.")
+	scoringFlag := fs.String("scoring-mode", scoringDefault, "Scoring model: classic, multinomial, complement, or bernoulli. (default: classic)")
+	smoothingFlag := fs.Float64("smoothing", smoothingDefault, "Additive smoothing for probabilistic scoring modes. (default: 1)")
+	priorFlag := fs.String("prior", priorDefault, "Category priors computed from: tokens or documents. (default: tokens)")
+	autosaveFlag := fs.Duration("autosave-interval", autosaveDefault, "How often a modified model is saved to --model-file; 0 saves only on shutdown. (default: 1m)")
+	decayHalfLifeFlag := fs.Duration("decay-half-life", decayHalfLifeDefault, "Decay trained counts so they halve every half-life, e.g. 168h; 0 disables decay. (default: 0)")
+	decayIntervalFlag := fs.Duration("decay-interval", decayIntervalDefault, "How often decay is applied when --decay-half-life is set. (default: 1h)")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
@@ -102,17 +223,106 @@ func loadServerConfig(fs *flag.FlagSet, args []string, getenv func(string) strin
 	if port == "" {
 		port = "8000"
 	}
+	if *ngramsFlag < 1 || *ngramsFlag > maxNGrams {
+		return nil, fmt.Errorf("invalid ngrams: %d", *ngramsFlag)
+	}
+	tokenizer, err := bayes.ParseTokenizer(*tokenizerFlag)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCharNGrams(*charMinFlag, *charMaxFlag); err != nil {
+		return nil, err
+	}
+	if *autosaveFlag < 0 {
+		return nil, fmt.Errorf("invalid autosave interval: %s", *autosaveFlag)
+	}
+	if *decayHalfLifeFlag < 0 {
+		return nil, fmt.Errorf("invalid decay half-life: %s", *decayHalfLifeFlag)
+	}
+	if *decayIntervalFlag <= 0 {
+		return nil, fmt.Errorf("invalid decay interval: %s", *decayIntervalFlag)
+	}
+	scoringMode, err := bayes.ParseScoringMode(*scoringFlag)
+	if err != nil {
+		return nil, err
+	}
+	if *smoothingFlag <= 0 {
+		return nil, fmt.Errorf("invalid smoothing: %v", *smoothingFlag)
+	}
+	prior, err := bayes.ParsePriorSource(*priorFlag)
+	if err != nil {
+		return nil, err
+	}
+	normalizers, err := bayes.ParseNormalizers(*normalizeFlag)
+	if err != nil {
+		return nil, err
+	}
+	stopWordsFile := strings.TrimSpace(*stopWordsFileFlag)
+	var extraStopWords, excludedStopWords []string
+	if stopWordsFile != "" {
+		extraStopWords, excludedStopWords, err = readStopWordsFile(stopWordsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	modelFile := strings.TrimSpace(*modelFileFlag)
+	if modelFile != "" {
+		absPath, err := filepath.Abs(modelFile)
+		if err != nil {
+			return nil, fmt.Errorf("resolve model file: %w", err)
+		}
+		modelFile = absPath
+	}
 
 	return &serverConfig{
-		Host:            host,
-		Port:            port,
-		AuthToken:       strings.TrimSpace(*authFlag),
-		Language:        language,
-		RemoveStopWords: *removeStopFlag,
-		Verbose:         *verboseFlag,
+		Host:              host,
+		Port:              port,
+		AuthToken:         strings.TrimSpace(*authFlag),
+		Language:          language,
+		RemoveStopWords:   *removeStopFlag,
+		NGrams:            *ngramsFlag,
+		Tokenizer:         tokenizer,
+		CharNGramMin:      *charMinFlag,
+		CharNGramMax:      *charMaxFlag,
+		DetectLanguage:    *detectFlag,
+		StopWordsFile:     stopWordsFile,
+		Normalizers:       normalizers,
+		ExtraStopWords:    extraStopWords,
+		ExcludedStopWords: excludedStopWords,
+		Verbose:           *verboseFlag,
+		ModelFile:         modelFile,
+		AutosaveInterval:  *autosaveFlag,
+		DecayHalfLife:     *decayHalfLifeFlag,
+		DecayInterval:     *decayIntervalFlag,
+		ScoringMode:       scoringMode,
+		Smoothing:         *smoothingFlag,
+		Prior:             prior,
 	}, nil
 }
 
+// readStopWordsFile parses a stop-word customization file; see stopwords.Parse.
+func readStopWordsFile(path string) (extra, excluded []string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open stop words file: %w", err)
+	}
+	defer f.Close()
+
+	extra, excluded, err = stopwords.Parse(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read stop words file %s: %w", path, err)
+	}
+	return extra, excluded, nil
+}
+
+// validateCharNGrams checks a character n-gram range against maxCharNGrams.
+func validateCharNGrams(minN, maxN int) error {
+	if minN < 1 || maxN < minN || maxN > maxCharNGrams {
+		return fmt.Errorf("invalid character n-gram range: %d-%d (want 1 <= min <= max <= %d)", minN, maxN, maxCharNGrams)
+	}
+	return nil
+}
+
 type httpServer interface {
 	ListenAndServe() error
 	Shutdown(ctx context.Context) error
@@ -133,63 +343,150 @@ var (
 	}
 	logFatal = func(v ...interface{}) { log.Fatal(v...) }
 	runMain  = func() error {
-		cfg, err := loadServerConfig(flag.CommandLine, os.Args[1:], os.Getenv)
-		if err != nil {
-			return err
+		// A leading non-flag argument names a subcommand; without one the
+		// binary serves HTTP as it always has.
+		if args := os.Args[1:]; len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			run, ok := commands[args[0]]
+			if !ok {
+				return fmt.Errorf("unknown command %q (want serve, train, import, classify, eval, or inspect)", args[0])
+			}
+			fs := flag.NewFlagSet("gobayes "+args[0], flag.ExitOnError)
+			return run(fs, args[1:], os.Getenv, os.Stdin, os.Stdout)
 		}
+		return runServer(flag.CommandLine, os.Args[1:], os.Getenv)
+	}
+)
 
-		mux := http.NewServeMux()
-		controller := new(ClassifierAPI)
-		controller.classifier = bayes.NewClassifierWithOptions(cfg.Language, cfg.RemoveStopWords)
-		controller.ready.Store(true)
-		controller.RegisterRoutes(mux)
+// newConfiguredClassifier returns an untrained classifier with the tokenizer
+// and scoring options in cfg.
+func newConfiguredClassifier(cfg *serverConfig) (*bayes.Classifier, error) {
+	tokenizerOpts := []bayes.TokenizerOption{
+		bayes.WithNGrams(cfg.NGrams),
+		bayes.WithTokenizer(cfg.Tokenizer),
+		bayes.WithCharNGrams(cfg.CharNGramMin, cfg.CharNGramMax),
+		bayes.WithStopWords(cfg.ExtraStopWords, cfg.ExcludedStopWords),
+		bayes.WithNormalizers(cfg.Normalizers...),
+	}
+	if cfg.DetectLanguage {
+		tokenizerOpts = append(tokenizerOpts, bayes.WithLanguageDetection())
+	}
+	classifier := bayes.NewClassifierWithOptions(cfg.Language, cfg.RemoveStopWords, tokenizerOpts...)
+	if err := classifier.SetScoringOptions(bayes.ScoringOptions{Mode: cfg.ScoringMode, Smoothing: cfg.Smoothing, Prior: cfg.Prior}); err != nil {
+		return nil, err
+	}
+	return classifier, nil
+}
 
-		var handler http.Handler = mux
-		if cfg.AuthToken != "" {
-			handler = withAuthorizationToken(handler, cfg.AuthToken)
-		}
-		if cfg.Verbose {
-			handler = withVerbose(handler)
-		}
+// runServer runs the HTTP server until SIGINT or SIGTERM, then drains in-flight
+// requests and saves the model.
+func runServer(fs *flag.FlagSet, args []string, getenv func(string) string) error {
+	cfg, err := loadServerConfig(fs, args, getenv)
+	if err != nil {
+		return err
+	}
 
-		addr := net.JoinHostPort(cfg.Host, cfg.Port)
-		server := newServer(addr, handler)
-		log.Printf("Server is listening on %s.", addr)
+	mux := http.NewServeMux()
+	controller := new(ClassifierAPI)
+	controller.classifier, err = newConfiguredClassifier(cfg)
+	if err != nil {
+		return err
+	}
+	controller.modelFile = cfg.ModelFile
+	controller.RegisterRoutes(mux)
 
-		go func() {
-			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				logFatal(err)
-			}
-		}()
+	var handler http.Handler = controller.withReadiness(mux)
+	if cfg.AuthToken != "" {
+		handler = withAuthorizationToken(handler, cfg.AuthToken)
+	}
+	if cfg.Verbose {
+		handler = withVerbose(handler)
+	}
 
-		sigCh := makeSignalChannel()
-		notifySignals(sigCh, syscall.SIGINT, syscall.SIGTERM)
-		<-sigCh
-		controller.ready.Store(false)
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+	server := newServer(addr, handler)
+	log.Printf("Server is listening on %s.", addr)
 
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logFatal(err)
+		}
+	}()
+
+	// Readiness is only reported, and requests other than probes are only
+	// served, once any persisted model has been restored.
+	if err := controller.loadModel(); err != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
+		return errors.Join(err, server.Shutdown(ctx))
+	}
+	controller.ready.Store(true)
 
-		return server.Shutdown(ctx)
+	stopBackground := make(chan struct{})
+	if cfg.ModelFile != "" && cfg.AutosaveInterval > 0 {
+		go controller.autosave(cfg.AutosaveInterval, stopBackground)
 	}
-)
+	if cfg.DecayHalfLife > 0 {
+		go controller.decayModels(cfg.DecayHalfLife, cfg.DecayInterval, stopBackground)
+	}
+
+	sigCh := makeSignalChannel()
+	notifySignals(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+	controller.ready.Store(false)
+	close(stopBackground)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Drain in-flight requests first so the final save includes their changes.
+	shutdownErr := server.Shutdown(ctx)
+	return errors.Join(shutdownErr, controller.saveModel())
+}
 
 // ClassifierAPI serves classifier HTTP endpoints and shared classifier state.
 type ClassifierAPI struct {
-	classifier *bayes.Classifier
-	ready      atomic.Bool
+	classifier    *bayes.Classifier
+	ready         atomic.Bool
+	modelFile     string                 // optional path for load-on-boot and autosave
+	saveMu        sync.Mutex             // serializes model saves
+	savedRevision uint64                 // classifier revision at last load or save
+	savedTenants  map[string]savedTenant // named classifiers at last load or save; guarded by saveMu
+
+	tenantsMu  sync.RWMutex
+	tenants    map[string]*tenant // named classifiers served under /classifiers/{name}/
+	defaultMux *http.ServeMux     // classifier routes of the default classifier
 }
 
-// RegisterRoutes registers all API routes on the provided ServeMux.
+// RegisterRoutes registers all API routes on the provided ServeMux. The
+// root classifier routes serve the default classifier.
 func (c *ClassifierAPI) RegisterRoutes(mux *http.ServeMux) {
+	c.registerClassifierRoutes(mux)
+	c.defaultMux = http.NewServeMux()
+	c.registerClassifierRoutes(c.defaultMux)
+	mux.HandleFunc("/classifiers", c.ClassifiersHandler)
+	mux.HandleFunc("/classifiers/", c.NamedClassifierHandler)
+	mux.HandleFunc("/healthz", HealthHandler)
+	mux.HandleFunc("/readyz", c.ReadyHandler)
+}
+
+// registerClassifierRoutes registers the routes that operate on c.classifier.
+func (c *ClassifierAPI) registerClassifierRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/info", c.InfoHandler)
 	mux.HandleFunc("/train/", c.TrainHandler)
 	mux.HandleFunc("/untrain/", c.UntrainHandler)
+	mux.HandleFunc("/categories/", c.CategoryHandler)
+	mux.HandleFunc("/tokens/", c.TokenHandler)
 	mux.HandleFunc("/classify", c.ClassifyHandler)
 	mux.HandleFunc("/score", c.ScoreHandler)
+	mux.HandleFunc("/probabilities", c.ProbabilitiesHandler)
+	mux.HandleFunc("/explain", c.ExplainHandler)
+	mux.HandleFunc("/batch/train", c.BatchTrainHandler)
+	mux.HandleFunc("/batch/untrain", c.BatchUntrainHandler)
+	mux.HandleFunc("/batch/classify", c.BatchClassifyHandler)
+	mux.HandleFunc("/import", c.ImportHandler)
+	mux.HandleFunc("/evaluate", c.EvaluateHandler)
+	mux.HandleFunc("/prune", c.PruneHandler)
 	mux.HandleFunc("/flush", c.FlushHandler)
-	mux.HandleFunc("/healthz", HealthHandler)
-	mux.HandleFunc("/readyz", c.ReadyHandler)
 }
 
 // writeJSON marshals a value and writes it as a JSON HTTP response.
@@ -214,7 +511,21 @@ func writeError(w http.ResponseWriter, status int, message string) {
 
 // readBody reads a bounded request body and returns the payload string.
 func readBody(w http.ResponseWriter, req *http.Request) (string, bool) {
-	req.Body = http.MaxBytesReader(w, req.Body, maxRequestBodyBytes)
+	return readBodyLimit(w, req, maxRequestBodyBytes)
+}
+
+// extendDeadlines gives a bulk request bulkRequestTimeout from now to be read
+// and answered. Writers that do not support deadlines keep the defaults.
+func extendDeadlines(w http.ResponseWriter) {
+	deadline := time.Now().Add(bulkRequestTimeout)
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+}
+
+// readBodyLimit reads a request body of at most limit bytes and returns the payload string.
+func readBodyLimit(w http.ResponseWriter, req *http.Request, limit int64) (string, bool) {
+	req.Body = http.MaxBytesReader(w, req.Body, limit)
 	defer req.Body.Close()
 
 	body, err := io.ReadAll(req.Body)
@@ -245,6 +556,66 @@ func categoryFromPath(path, prefix string) (string, bool) {
 	return category, true
 }
 
+// classifyOptionsFromQuery parses the optional n, minScore, minMargin, and
+// unknown query parameters accepted by /classify.
+func classifyOptionsFromQuery(query url.Values) (bayes.ClassifyOptions, error) {
+	opts := bayes.ClassifyOptions{Unknown: query.Get("unknown")}
+	if raw := query.Get("n"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("invalid n: %q", raw)
+		}
+		opts.N = n
+	}
+	if raw := query.Get("minScore"); raw != "" {
+		minScore, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(minScore) || math.IsInf(minScore, 0) {
+			return opts, fmt.Errorf("invalid minScore: %q", raw)
+		}
+		opts.MinScore = &minScore
+	}
+	if raw := query.Get("minMargin"); raw != "" {
+		minMargin, err := strconv.ParseFloat(raw, 64)
+		if err != nil || minMargin < 0 || math.IsInf(minMargin, 0) {
+			return opts, fmt.Errorf("invalid minMargin: %q", raw)
+		}
+		opts.MinMargin = minMargin
+	}
+	return opts, nil
+}
+
+// weightFromQuery parses the optional weight query parameter accepted by
+// /train and /untrain. It defaults to 1.
+func weightFromQuery(query url.Values) (float64, error) {
+	raw := query.Get("weight")
+	if raw == "" {
+		return 1, nil
+	}
+	weight, err := strconv.ParseFloat(raw, 64)
+	if err != nil || !(weight > 0) || math.IsInf(weight, 0) {
+		return 0, fmt.Errorf("invalid weight: %q", raw)
+	}
+	return weight, nil
+}
+
+// inputFormatFromRequest picks the input format from the request
+// Content-Type: text/html bodies are parsed as HTML and message/rfc822 bodies
+// as email. Anything else, including a missing header, is plain text.
+func inputFormatFromRequest(req *http.Request) bayes.InputFormat {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return bayes.InputText
+	}
+	switch mediaType {
+	case "text/html":
+		return bayes.InputHTML
+	case "message/rfc822":
+		return bayes.InputEmail
+	default:
+		return bayes.InputText
+	}
+}
+
 // requireMethod enforces a single HTTP method and writes 405 on mismatch.
 func requireMethod(w http.ResponseWriter, req *http.Request, method string) bool {
 	if req.Method != method {
@@ -259,17 +630,22 @@ func requireMethod(w http.ResponseWriter, req *http.Request, method string) bool
 func withVerbose(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		log.Printf("[gobayes] %s %s", req.Method, req.URL.Path)
-		body, _ := io.ReadAll(req.Body)
-		req.Body = io.NopCloser(strings.NewReader(string(body)))
-		if len(body) > 0 {
-			preview := string(body)
+		// The body is copied as the handler reads it rather than read up
+		// front, which would happen before bulk handlers extend deadlines.
+		body := &bytesBuffer{}
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(req.Body, body), req.Body}
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK, body: &bytesBuffer{}}
+		next.ServeHTTP(rec, req)
+		if body.Len() > 0 {
+			preview := body.String()
 			if len(preview) > 200 {
 				preview = preview[:200] + "..."
 			}
-			log.Printf("[gobayes] request body (%d bytes): %s", len(body), preview)
+			log.Printf("[gobayes] request body (%d bytes): %s", body.Len(), preview)
 		}
-		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK, body: &bytesBuffer{}}
-		next.ServeHTTP(rec, req)
 		log.Printf("[gobayes] response %d", rec.status)
 		if rec.body.Len() > 0 {
 			preview := rec.body.String()
@@ -296,7 +672,7 @@ func (b *bytesBuffer) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
-func (b *bytesBuffer) Len() int   { return len(b.b) }
+func (b *bytesBuffer) Len() int       { return len(b.b) }
 func (b *bytesBuffer) String() string { return string(b.b) }
 
 func (r *responseRecorder) WriteHeader(code int) {
@@ -309,6 +685,11 @@ func (r *responseRecorder) Write(p []byte) (int, error) {
 	return r.ResponseWriter.Write(p)
 }
 
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // withAuthorizationToken wraps a handler with bearer-token authorization checks.
 func withAuthorizationToken(next http.Handler, expectedToken string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -335,6 +716,19 @@ func withAuthorizationToken(next http.Handler, expectedToken string) http.Handle
 	})
 }
 
+// withReadiness wraps a handler so that every route except the probes answers
+// 503 while the server is not ready. Requests made while the persisted model
+// loads would otherwise be overwritten by it.
+func (c *ClassifierAPI) withReadiness(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/healthz" || req.URL.Path == "/readyz" || c.ready.Load() {
+			next.ServeHTTP(w, req)
+			return
+		}
+		writeError(w, http.StatusServiceUnavailable, "not ready")
+	})
+}
+
 // InfoHandler returns the current classifier training state.
 func (c *ClassifierAPI) InfoHandler(w http.ResponseWriter, req *http.Request) {
 	if !requireMethod(w, req, http.MethodGet) {
@@ -344,7 +738,8 @@ func (c *ClassifierAPI) InfoHandler(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, http.StatusOK, NewInfoClassifierResponse(c))
 }
 
-// TrainHandler trains a category using request body text.
+// TrainHandler trains a category using request body text, parsed according
+// to its Content-Type and counted weight times.
 func (c *ClassifierAPI) TrainHandler(w http.ResponseWriter, req *http.Request) {
 	if !requireMethod(w, req, http.MethodPost) {
 		return
@@ -356,16 +751,26 @@ func (c *ClassifierAPI) TrainHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	weight, err := weightFromQuery(req.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	body, ok := readBody(w, req)
 	if !ok {
 		return
 	}
 
-	_ = c.classifier.Train(category, body)
+	if err := c.classifier.TrainInputWeighted(category, inputFormatFromRequest(req), body, weight); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	writeJSON(w, http.StatusOK, NewTrainingClassifierResponse(c, true))
 }
 
-// UntrainHandler untrains a category using request body text.
+// UntrainHandler untrains a category using request body text, parsed
+// according to its Content-Type and counted weight times.
 func (c *ClassifierAPI) UntrainHandler(w http.ResponseWriter, req *http.Request) {
 	if !requireMethod(w, req, http.MethodPost) {
 		return
@@ -377,27 +782,48 @@ func (c *ClassifierAPI) UntrainHandler(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	weight, err := weightFromQuery(req.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	body, ok := readBody(w, req)
 	if !ok {
 		return
 	}
 
-	_ = c.classifier.Untrain(category, body)
+	if err := c.classifier.UntrainInputWeighted(category, inputFormatFromRequest(req), body, weight); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	writeJSON(w, http.StatusOK, NewTrainingClassifierResponse(c, true))
 }
 
-// ClassifyHandler classifies request body text and returns the top match.
+// ClassifyHandler classifies request body text and returns the top match,
+// optionally with a ranking of the top n categories and abstain thresholds.
 func (c *ClassifierAPI) ClassifyHandler(w http.ResponseWriter, req *http.Request) {
 	if !requireMethod(w, req, http.MethodPost) {
 		return
 	}
 
+	opts, err := classifyOptionsFromQuery(req.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	body, ok := readBody(w, req)
 	if !ok {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, c.classifier.Classify(body))
+	result, err := c.classifier.ClassifyInput(inputFormatFromRequest(req), body, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
 }
 
 // ScoreHandler returns per-category scores for request body text.
@@ -411,7 +837,40 @@ func (c *ClassifierAPI) ScoreHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, c.classifier.Score(body))
+	scores, err := c.classifier.ScoreInput(inputFormatFromRequest(req), body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, scores)
+}
+
+// ProbabilitiesHandler returns normalized per-category probabilities for request body text.
+func (c *ClassifierAPI) ProbabilitiesHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	body, ok := readBody(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, c.classifier.Probabilities(body))
+}
+
+// ExplainHandler returns per-token score contributions for request body text.
+func (c *ClassifierAPI) ExplainHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	body, ok := readBody(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, c.classifier.Explain(body))
 }
 
 // FlushHandler deletes all training data and gives us a fresh slate.
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postBatch posts body to a batch endpoint and returns the recorder.
+func postBatch(t *testing.T, mux *http.ServeMux, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return rr
+}
+
+// TestBatchTrainJSONArrayAndNDJSON verifies both batch formats train and report per-item results.
+func TestBatchTrainJSONArrayAndNDJSON(t *testing.T) {
+	api, mux := newTestServer()
+
+	rr := postBatch(t, mux, "/batch/train", `[{"category":"spam","text":"buy cheap pills"},{"category":"bad name","text":"x"}]`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rr.Code)
+	}
+	assertJSONContentType(t, rr)
+	var resp BatchTrainingResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Success || len(resp.Results) != 2 || !resp.Results[0].Success || resp.Results[1].Success || resp.Results[1].Error == "" {
+		t.Fatalf("unexpected batch results: %+v", resp)
+	}
+	if resp.Categories["spam"] == nil || resp.Categories["spam"].DocumentCount != 1 {
+		t.Fatalf("expected spam trained with one document, got %+v", resp.Categories)
+	}
+
+	rr = postBatch(t, mux, "/batch/train", "{\"category\":\"ham\",\"text\":\"team meeting\"}\n{\"category\":\"ham\",\"text\":\"project notes\"}\n")
+	resp = BatchTrainingResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !resp.Success || len(resp.Results) != 2 || resp.Categories["ham"].DocumentCount != 2 {
+		t.Fatalf("unexpected NDJSON batch response: %+v", resp)
+	}
+	if got := api.classifier.Classify("meeting notes").Category; got != "ham" {
+		t.Fatalf("expected ham classification after batch training, got %q", got)
+	}
+}
+
+// TestBatchUntrainAndClassify verifies batch untraining and batch classification.
+func TestBatchUntrainAndClassify(t *testing.T) {
+	_, mux := newTestServer()
+	postBatch(t, mux, "/batch/train", `[{"category":"spam","text":"buy cheap pills"},{"category":"ham","text":"team meeting"}]`)
+
+	rr := postBatch(t, mux, "/batch/classify", `[{"text":"cheap pills"},{"text":"meeting"},{"text":"unseen"}]`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rr.Code)
+	}
+	var classified BatchClassifyResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &classified); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(classified.Results) != 3 || classified.Results[0].Category != "spam" || classified.Results[1].Category != "ham" || classified.Results[2].Category != "" {
+		t.Fatalf("unexpected batch classifications: %+v", classified.Results)
+	}
+
+	rr = postBatch(t, mux, "/batch/untrain", `[{"category":"spam","text":"buy cheap pills"}]`)
+	var resp BatchTrainingResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !resp.Success || resp.Categories["spam"] != nil || resp.Categories["ham"] == nil {
+		t.Fatalf("unexpected untrain response: %+v", resp)
+	}
+}
+
+// TestBatchRejectsMalformedBodies verifies malformed batches fail without training anything.
+func TestBatchRejectsMalformedBodies(t *testing.T) {
+	api, mux := newTestServer()
+	for _, body := range []string{
+		`[{"category":"spam","text":"x"}`,
+		`[{"category":"spam","text":"x"}] trailing`,
+		`[{"category":"spam","txt":"x"}]`,
+		"{\"category\":\"spam\",\"text\":\"x\"}\nnot json",
+	} {
+		rr := postBatch(t, mux, "/batch/train", body)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %q, got %d", body, rr.Code)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+	for _, path := range []string{"/batch/untrain", "/batch/classify"} {
+		if rr := postBatch(t, mux, path, "not json"); rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for a malformed %s body, got %d", path, rr.Code)
+		}
+	}
+	if rr := postBatch(t, mux, "/batch/train", strings.Repeat(" ", maxBatchBodyBytes+1)); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for an oversized batch, got %d", rr.Code)
+	}
+	if len(api.classifier.Summaries()) != 0 {
+		t.Fatal("expected malformed batches to leave the model untouched")
+	}
+
+	for _, path := range []string{"/batch/train", "/batch/untrain", "/batch/classify"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != http.MethodPost {
+			t.Fatalf("expected 405 for GET %s, got %d", path, rr.Code)
+		}
+	}
+
+	rr := postBatch(t, mux, "/batch/classify", "")
+	var classified BatchClassifyResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &classified); err != nil || rr.Code != http.StatusOK || len(classified.Results) != 0 {
+		t.Fatalf("expected empty results for empty body, got %d %s", rr.Code, rr.Body.String())
+	}
+}
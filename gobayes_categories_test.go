@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// TestCategoryHandler verifies deleting, renaming, and merging categories.
+func TestCategoryHandler(t *testing.T) {
+	api, mux := newTestServer()
+	serve(mux, http.MethodPost, "/train/spam", "buy now")
+	serve(mux, http.MethodPost, "/train/phishing", "login now")
+	serve(mux, http.MethodPost, "/train/ham", "hello friend")
+	serve(mux, http.MethodPost, "/train/misc", "whatever")
+
+	rr := serve(mux, http.MethodPost, "/categories/phishing/merge", `{"sources":["spam"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	assertJSONContentType(t, rr)
+	var resp TrainingClassifierResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if _, ok := resp.Categories["spam"]; ok || resp.Categories["phishing"].TokenTally != 4 {
+		t.Fatalf("unexpected categories after merge: %+v", resp.Categories)
+	}
+
+	rr = serve(mux, http.MethodPost, "/categories/phishing/rename", `{"name":"junk"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := serve(mux, http.MethodDelete, "/categories/misc", ""); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	summaries := api.classifier.Summaries()
+	if len(summaries) != 2 || summaries["junk"].TokenTally != 4 || summaries["ham"].TokenTally != 2 {
+		t.Fatalf("unexpected model: %+v", summaries)
+	}
+
+	// Named classifiers serve the same routes.
+	serve(mux, http.MethodPost, "/classifiers/tenant", "")
+	serve(mux, http.MethodPost, "/classifiers/tenant/train/spam", "buy now")
+	if rr := serve(mux, http.MethodDelete, "/classifiers/tenant/categories/spam", ""); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodDelete, "/categories/missing", "", http.StatusNotFound},
+		{http.MethodDelete, "/categories/", "", http.StatusNotFound},
+		{http.MethodDelete, "/categories/bad%20name", "", http.StatusNotFound},
+		{http.MethodPost, "/categories/junk/split", "", http.StatusNotFound},
+		{http.MethodPost, "/categories/missing/rename", `{"name":"other"}`, http.StatusNotFound},
+		{http.MethodPost, "/categories/junk/rename", `{"name":"ham"}`, http.StatusConflict},
+		{http.MethodPost, "/categories/junk/rename", `{"name":"bad name"}`, http.StatusBadRequest},
+		{http.MethodPost, "/categories/junk/rename", `{"to":"other"}`, http.StatusBadRequest},
+		{http.MethodPost, "/categories/junk/merge", `{"sources":["missing"]}`, http.StatusNotFound},
+		{http.MethodPost, "/categories/junk/merge", `{"sources":[]}`, http.StatusBadRequest},
+		{http.MethodPost, "/categories/junk/merge", `not json`, http.StatusBadRequest},
+		{http.MethodPost, "/categories/junk", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/categories/junk/rename", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/categories/junk/merge", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/categories/junk/rename", strings.Repeat("a", maxRequestBodyBytes+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		rr := serve(mux, tt.method, tt.path, tt.body)
+		if rr.Code != tt.want {
+			t.Fatalf("%s %s: unexpected status: got %d want %d body=%s", tt.method, tt.path, rr.Code, tt.want, rr.Body.String())
+		}
+		assertJSONErrorShape(t, rr)
+	}
+	if len(api.classifier.Summaries()) != 2 {
+		t.Fatal("expected failed requests to leave the model unchanged")
+	}
+}
+
+// TestCategoryTokensHandler verifies paging through a category's tokens.
+func TestCategoryTokensHandler(t *testing.T) {
+	_, mux := newTestServer()
+	serve(mux, http.MethodPost, "/train/spam", "buy buy buy cheap cheap pills")
+
+	rr := serve(mux, http.MethodGet, "/categories/spam/tokens?limit=2&offset=1", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	assertJSONContentType(t, rr)
+	var page bayes.TokenPage
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if page.Total != 3 || page.Offset != 1 || len(page.Tokens) != 2 || page.Tokens[0] != (bayes.TokenCount{Token: "cheap", Count: 2, Documents: 1}) {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	rr = serve(mux, http.MethodGet, "/categories/spam/tokens?sort=token&reverse=true", "")
+	page = bayes.TokenPage{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(page.Tokens) != 3 || page.Tokens[0].Token != "pill" || page.Tokens[2].Token != "buy" {
+		t.Fatalf("unexpected sorted page: %+v", page)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/categories/ham/tokens", http.StatusNotFound},
+		{"/categories/spam/tokens?offset=-1", http.StatusBadRequest},
+		{"/categories/spam/tokens?limit=0", http.StatusBadRequest},
+		{"/categories/spam/tokens?limit=1001", http.StatusBadRequest},
+		{"/categories/spam/tokens?sort=rank", http.StatusBadRequest},
+		{"/categories/spam/tokens?reverse=maybe", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := serve(mux, http.MethodGet, tt.path, "")
+		if rr.Code != tt.want {
+			t.Fatalf("%s: unexpected status: got %d want %d", tt.path, rr.Code, tt.want)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+	if rr := serve(mux, http.MethodPost, "/categories/spam/tokens", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+}
+
+// TestDiscriminativeTokensHandler verifies ranking the tokens that favor a category.
+func TestDiscriminativeTokensHandler(t *testing.T) {
+	_, mux := newTestServer()
+	serve(mux, http.MethodPost, "/train/spam", "free free offer hello hello")
+	serve(mux, http.MethodPost, "/train/ham", "meeting hello hello")
+
+	rr := serve(mux, http.MethodGet, "/categories/spam/discriminative?n=1", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var resp DiscriminativeTokensResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Category != "spam" || len(resp.Tokens) != 1 || resp.Tokens[0].Token != "free" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	for path, want := range map[string]int{
+		"/categories/spam/discriminative?n=0":              http.StatusBadRequest,
+		"/categories/spam/discriminative?n=x":              http.StatusBadRequest,
+		"/categories/missing/discriminative":               http.StatusNotFound,
+		"/classifiers/none/categories/spam/discriminative": http.StatusNotFound,
+	} {
+		rr := serve(mux, http.MethodGet, path, "")
+		if rr.Code != want {
+			t.Fatalf("%s: unexpected status: got %d want %d", path, rr.Code, want)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+	if rr := serve(mux, http.MethodPost, "/categories/spam/discriminative", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+}
+
+// TestTokenHandler verifies looking up a token's counts after tokenizing it.
+func TestTokenHandler(t *testing.T) {
+	_, mux := newTestServer()
+	serve(mux, http.MethodPost, "/train/spam", "buying pills")
+	serve(mux, http.MethodPost, "/train/ham", "hello")
+
+	rr := serve(mux, http.MethodGet, "/tokens/Buying", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	assertJSONContentType(t, rr)
+	var resp TokenLookupResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Query != "Buying" || len(resp.Tokens) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	lookup := resp.Tokens[0]
+	if lookup.Token != "buy" || lookup.Total != 1 || lookup.Categories["spam"] != 1 || lookup.Categories["ham"] != 0 || len(lookup.Categories) != 2 {
+		t.Fatalf("unexpected lookup: %+v", lookup)
+	}
+
+	if rr := serve(mux, http.MethodGet, "/tokens/%21%21", ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"tokens":[]`) {
+		t.Fatalf("expected an empty token list, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve(mux, http.MethodGet, "/tokens/", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+	if rr := serve(mux, http.MethodPost, "/tokens/buy", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/hickeroar/gobayes/v4/bayes/eval"
+)
+
+// runCommand runs a subcommand with an empty environment and returns its output.
+func runCommand(t *testing.T, name, stdin string, args ...string) (string, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("gobayes "+name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var out bytes.Buffer
+	err := commands[name](fs, args, func(string) string { return "" }, strings.NewReader(stdin), &out)
+	return out.String(), err
+}
+
+// writeFiles creates files under dir from a map of relative paths to contents.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestCommandsWorkOnModelFile verifies train, classify, eval, and inspect
+// operate on a model file with directory, file, NDJSON, and stdin inputs.
+func TestCommandsWorkOnModelFile(t *testing.T) {
+	dir := t.TempDir()
+	model := filepath.Join(dir, "model.json")
+	writeFiles(t, dir, map[string]string{
+		"corpus/spam/1.txt":   "cheap pills now",
+		"corpus/spam/2.txt":   "buy cheap pills",
+		"corpus/ham/1.txt":    "team meeting notes",
+		"corpus/ham/2.txt":    "meeting agenda",
+		"extra.ndjson":        "{\"category\":\"spam\",\"text\":\"pills discount\"}\n{\"text\":\"agenda for the meeting\"}\n",
+		"unlabeled/query.txt": "cheap pills",
+	})
+
+	out, err := runCommand(t, "train", "", "--model-file", model, filepath.Join(dir, "corpus"))
+	if err != nil {
+		t.Fatalf("train: %v", err)
+	}
+	var trained cliTrainResponse
+	if err := json.Unmarshal([]byte(out), &trained); err != nil || trained.Trained != 4 || trained.Categories["spam"].DocumentCount != 2 {
+		t.Fatalf("unexpected train output: %s", out)
+	}
+
+	if _, err := runCommand(t, "train", "", "--model-file", model, "--category", "ham", filepath.Join(dir, "extra.ndjson")); err != nil {
+		t.Fatalf("train NDJSON: %v", err)
+	}
+	out, err = runCommand(t, "train", "lunch meeting", "--model-file", model, "--category", "ham", "--weight", "2")
+	if err != nil {
+		t.Fatalf("train stdin: %v", err)
+	}
+	if err := json.Unmarshal([]byte(out), &trained); err != nil || trained.Categories["ham"].DocumentCount != 5 || trained.Categories["spam"].DocumentCount != 3 {
+		t.Fatalf("unexpected train output: %s", out)
+	}
+	if _, err := runCommand(t, "train", "lunch meeting", "--model-file", model, "--category", "ham", "--weight", "2", "--untrain"); err != nil {
+		t.Fatalf("untrain stdin: %v", err)
+	}
+
+	out, err = runCommand(t, "classify", "team meeting", "--model-file", model, "--n", "2", filepath.Join(dir, "unlabeled"), "-")
+	if err != nil {
+		t.Fatalf("classify: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one result per input, got %q", out)
+	}
+	var first, second cliClassifyResult
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.Category != "spam" || !strings.HasSuffix(first.Input, "query.txt") || len(first.Ranking) != 1 {
+		t.Fatalf("unexpected classify result: %s", lines[0])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil || second.Category != "ham" || second.Input != "-" {
+		t.Fatalf("unexpected classify result: %s", lines[1])
+	}
+	if _, err := runCommand(t, "classify", "team meeting", "--model-file", model, "--category", "ham"); err == nil || !strings.Contains(err.Error(), "-category") {
+		t.Fatalf("expected classify to reject --category, got %v", err)
+	}
+
+	holdout := "{\"category\":\"spam\",\"text\":\"cheap\"}\n{\"category\":\"ham\",\"text\":\"agenda\"}\n"
+	out, err = runCommand(t, "eval", holdout, "--model-file", model, "--samples")
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	var report eval.Report
+	if err := json.Unmarshal([]byte(out), &report); err != nil || report.Samples != 2 || report.Accuracy != 1 {
+		t.Fatalf("unexpected eval output: %s", out)
+	}
+
+	out, err = runCommand(t, "eval", "", "--folds", "2", "--seed", "3", filepath.Join(dir, "corpus"))
+	if err != nil {
+		t.Fatalf("cross-validate: %v", err)
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil || report.Samples != 4 || len(report.FoldAccuracy) != 2 {
+		t.Fatalf("unexpected cross-validation output: %s", out)
+	}
+
+	out, err = runCommand(t, "inspect", "", "--model-file", model)
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	var inspected cliInspectResponse
+	if err := json.Unmarshal([]byte(out), &inspected); err != nil || inspected.ModelFile != model || inspected.ScoringMode != "classic" || inspected.Classifier.Categories != 2 || inspected.Categories["ham"].DocumentCount != 3 {
+		t.Fatalf("unexpected inspect output: %s", out)
+	}
+}
+
+// TestCommandErrors verifies subcommands reject missing models, unlabeled
+// samples, and invalid flags.
+func TestCommandErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.json")
+	model := filepath.Join(dir, "model.json")
+	writeFiles(t, dir, map[string]string{"flat/a.txt": "hello", "bad.ndjson": "not json"})
+	if err := os.Mkdir(filepath.Join(dir, "dangling"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "gone.txt"), filepath.Join(dir, "dangling", "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand(t, "train", "hello", "--model-file", model, "--category", "spam"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		stdin string
+		args  []string
+	}{
+		{"train", "hello", []string{"--category", "spam"}},
+		{"train", "hello", []string{"--model-file", missing}},
+		{"train", "hello", []string{"--model-file", missing, "--category", "not valid"}},
+		{"train", "hello", []string{"--model-file", missing, "--category", "spam", "--weight", "0"}},
+		{"train", "hello", []string{"--model-file", missing, "--category", "spam", "--format", "pdf"}},
+		{"train", "", []string{"--model-file", missing, filepath.Join(dir, "flat")}},
+		{"train", "not json", []string{"--model-file", missing, "--category", "spam", "--samples"}},
+		{"train", "", []string{"--model-file", missing, filepath.Join(dir, "nope.txt")}},
+		{"train", "", []string{"--model-file", missing, "--category", "spam", filepath.Join(dir, "bad.ndjson")}},
+		{"train", "", []string{"--model-file", missing, filepath.Join(dir, "dangling")}},
+		{"train", "hello", []string{"--unknown-flag"}},
+		{"train", "hello", []string{"--model-file", missing, "--category", "spam", "--smoothing", "NaN"}},
+		{"train", "bad", []string{"--model-file", missing, "--category", "spam", "--format", "email"}},
+		{"train", "hello", []string{"--model-file", filepath.Join(dir, "nodir", "model.json"), "--category", "spam"}},
+		{"classify", "hello", []string{"--model-file", missing}},
+		{"classify", "hello", nil},
+		{"classify", "hello", []string{"--model-file", model, "--format", "pdf"}},
+		{"classify", "hello", []string{"--model-file", model, "--n", "-1"}},
+		{"classify", "", []string{"--model-file", model, filepath.Join(dir, "nope.txt")}},
+		{"classify", "bad", []string{"--model-file", model, "--format", "email"}},
+		{"eval", "hello", []string{"--unknown-flag"}},
+		{"eval", "", []string{"--model-file", model, filepath.Join(dir, "nope.txt")}},
+		{"eval", "hello", []string{"--folds", "2"}},
+		{"eval", "{\"category\":\"spam\",\"text\":\"x\"}", []string{"--folds", "2", "--samples"}},
+		{"eval", "{\"category\":\"spam\",\"text\":\"x\"}", []string{"--model-file", missing, "--samples"}},
+		{"inspect", "", []string{"--model-file", missing}},
+		{"inspect", "", []string{"--model-file", missing, "extra"}},
+		{"inspect", "", []string{"--unknown-flag"}},
+	}
+	for _, tc := range cases {
+		if _, err := runCommand(t, tc.name, tc.stdin, tc.args...); err == nil {
+			t.Fatalf("%s %v: expected error", tc.name, tc.args)
+		}
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Fatal("expected failed commands not to create the model file")
+	}
+}
+
+// TestCommandIOErrors verifies subcommands report failures reading stdin and
+// writing their output.
+func TestCommandIOErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	if _, err := readInputs(nil, "", false, iotest.ErrReader(errBoom)); !errors.Is(err, errBoom) {
+		t.Fatalf("expected stdin read error, got %v", err)
+	}
+
+	model := filepath.Join(t.TempDir(), "model.json")
+	if _, err := runCommand(t, "train", "hello", "--model-file", model, "--category", "spam"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+	pr, pw := io.Pipe()
+	pr.CloseWithError(errBoom)
+	fs := flag.NewFlagSet("gobayes classify", flag.ContinueOnError)
+	err := classifyCommand(fs, []string{"--model-file", model}, func(string) string { return "" }, strings.NewReader("hello"), pw)
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected output write error, got %v", err)
+	}
+}
+
+// TestRunMainRejectsUnknownCommand verifies a misspelled subcommand is an
+// error instead of starting the server.
+func TestRunMainRejectsUnknownCommand(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	os.Args = []string{"gobayes", "trian"}
+	if err := runMain(); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Fatalf("expected unknown command error, got %v", err)
+	}
+}
+
+// TestRunMainRunsServeCommand verifies the serve subcommand runs the server
+// with its background tasks and reports listen failures.
+func TestRunMainRunsServeCommand(t *testing.T) {
+	oldMakeSignal := makeSignalChannel
+	oldNotify := notifySignals
+	oldNewServer := newServer
+	oldLogFatal := logFatal
+	oldArgs := os.Args
+	defer func() {
+		makeSignalChannel = oldMakeSignal
+		notifySignals = oldNotify
+		newServer = oldNewServer
+		logFatal = oldLogFatal
+		os.Args = oldArgs
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	makeSignalChannel = func() chan os.Signal { return sigCh }
+	notifySignals = func(chan<- os.Signal, ...os.Signal) {}
+	listenErr := errors.New("address in use")
+	newServer = func(string, http.Handler) httpServer {
+		return &fakeServer{listenErr: listenErr}
+	}
+	fatalCh := make(chan error, 1)
+	logFatal = func(v ...interface{}) { fatalCh <- v[0].(error) }
+
+	model := filepath.Join(t.TempDir(), "model.json")
+	os.Args = []string{"gobayes", "serve", "--model-file", model, "--autosave-interval", "1h", "--decay-half-life", "1h", "--detect-language"}
+	done := make(chan error, 1)
+	go func() {
+		done <- runMain()
+	}()
+
+	select {
+	case err := <-fatalCh:
+		if !errors.Is(err, listenErr) {
+			t.Fatalf("unexpected fatal error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the listen failure")
+	}
+	sigCh <- syscall.SIGTERM
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected nil runMain error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for runMain to exit")
+	}
+}
+
+// TestRunServerRejectsInvalidScoring verifies scoring options the classifier
+// refuses stop the server before it starts.
+func TestRunServerRejectsInvalidScoring(t *testing.T) {
+	fs := flag.NewFlagSet("gobayes serve", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := runServer(fs, []string{"--smoothing", "NaN"}, func(string) string { return "" }); err == nil {
+		t.Fatal("expected invalid smoothing to be rejected")
+	}
+}
+
+// TestImportCommand verifies the import subcommand streams directories,
+// CSV, TSV, and NDJSON corpora into the model file.
+func TestImportCommand(t *testing.T) {
+	dir := t.TempDir()
+	model := filepath.Join(dir, "model.json")
+	writeFiles(t, dir, map[string]string{
+		"corpus/spam/1.txt": "cheap pills",
+		"corpus/README":     "not a sample",
+		"labels.csv":        "text,label\ncheap deal,spam\nteam lunch,ham\n",
+		"labels.tsv":        "text\tlabel\nmeeting notes\tham\n",
+		"notes.txt":         "plain text",
+		"more.ndjson":       "{\"category\":\"ham\",\"text\":\"minutes\"}\n",
+	})
+	// Opening a socket fails even though it exists.
+	listener, err := net.Listen("unix", filepath.Join(dir, "sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	out, err := runCommand(t, "import", "{\"category\":\"ham\",\"text\":\"agenda\"}\n",
+		"--model-file", model, "--label-column", "label",
+		filepath.Join(dir, "corpus"), filepath.Join(dir, "labels.csv"), filepath.Join(dir, "labels.tsv"), "-")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	var resp cliImportResponse
+	if err := json.Unmarshal([]byte(out), &resp); err != nil || len(resp.Imports) != 4 {
+		t.Fatalf("unexpected import output: %s", out)
+	}
+	if got := resp.Imports[0]; got.Imported != 1 || got.Rejected != 1 || got.Rejects[0].Source != "README" {
+		t.Fatalf("unexpected directory import: %+v", got)
+	}
+	if got := resp.Imports[1]; got.Categories["ham"] != 1 || got.Categories["spam"] != 1 {
+		t.Fatalf("unexpected CSV import: %+v", got)
+	}
+	if resp.Imports[2].Imported != 1 || resp.Imports[3].Source != "-" || resp.Imports[3].Imported != 1 {
+		t.Fatalf("unexpected TSV or stdin import: %+v", resp.Imports)
+	}
+	if resp.Categories["ham"].DocumentCount != 3 || resp.Categories["spam"].DocumentCount != 2 {
+		t.Fatalf("unexpected categories: %s", out)
+	}
+
+	if _, err := runCommand(t, "import", "category,text\nham,hello\n", "--model-file", model, "--csv"); err != nil {
+		t.Fatalf("import CSV from stdin: %v", err)
+	}
+	if _, err := runCommand(t, "import", "", "--model-file", model, filepath.Join(dir, "more.ndjson")); err != nil {
+		t.Fatalf("import NDJSON file: %v", err)
+	}
+	for _, args := range [][]string{
+		{filepath.Join(dir, "corpus")},
+		{"--model-file", model, filepath.Join(dir, "notes.txt")},
+		{"--model-file", model, filepath.Join(dir, "missing")},
+		{"--model-file", model, "--format", "pdf", filepath.Join(dir, "corpus")},
+		{"--model-file", model, "--text-column", "body", filepath.Join(dir, "labels.csv")},
+		{"--unknown-flag"},
+		{"--model-file", model, "--smoothing", "NaN", filepath.Join(dir, "corpus")},
+		{"--model-file", model, filepath.Join(dir, "sock")},
+		{"--model-file", filepath.Join(dir, "nodir", "model.json"), filepath.Join(dir, "corpus")},
+	} {
+		if _, err := runCommand(t, "import", "", args...); err == nil {
+			t.Fatalf("%v: expected error", args)
+		}
+	}
+}
@@ -3,8 +3,15 @@ package main
 import (
 	"bytes"
 	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
 )
 
 func TestEnvOrDefault_Empty(t *testing.T) {
@@ -161,7 +168,7 @@ func TestLoadServerConfig_HelpShowsDoubleDash(t *testing.T) {
 	getenv := func(string) string { return "" }
 	_, _ = loadServerConfig(fs, []string{"--help"}, getenv)
 	out := buf.String()
-	for _, want := range []string{"--host", "--port", "--auth-token", "--language", "--verbose"} {
+	for _, want := range []string{"--host", "--port", "--auth-token", "--language", "--verbose", "--model-file", "--autosave-interval"} {
 		if !strings.Contains(out, want) {
 			t.Errorf("help output missing %q: %s", want, out)
 		}
@@ -189,3 +196,453 @@ func TestLoadServerConfig_EmptyHostAndPortNormalized(t *testing.T) {
 		t.Errorf("empty host/port should normalize: host=%q port=%q", cfg.Host, cfg.Port)
 	}
 }
+
+func TestEnvDuration(t *testing.T) {
+	getenv := func(string) string { return "" }
+	if got, err := envDuration(getenv, "K", time.Minute); err != nil || got != time.Minute {
+		t.Errorf("envDuration(empty) = %v, %v", got, err)
+	}
+	getenv = func(string) string { return " 30s " }
+	if got, err := envDuration(getenv, "K", time.Minute); err != nil || got != 30*time.Second {
+		t.Errorf("envDuration(30s) = %v, %v", got, err)
+	}
+	getenv = func(string) string { return "soon" }
+	if _, err := envDuration(getenv, "K", time.Minute); err == nil {
+		t.Error("envDuration(invalid) expected error")
+	}
+}
+
+func TestLoadServerConfig_ModelFileAndAutosave(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	getenv := func(key string) string {
+		switch key {
+		case "GOBAYES_MODEL_FILE":
+			return "/var/lib/gobayes/model.json"
+		case "GOBAYES_AUTOSAVE_INTERVAL":
+			return "5m"
+		default:
+			return ""
+		}
+	}
+	cfg, err := loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.ModelFile != "/var/lib/gobayes/model.json" || cfg.AutosaveInterval != 5*time.Minute {
+		t.Errorf("env: modelFile=%q autosave=%v", cfg.ModelFile, cfg.AutosaveInterval)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{"--model-file", "model.json", "--autosave-interval", "0"}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if !filepath.IsAbs(cfg.ModelFile) || filepath.Base(cfg.ModelFile) != "model.json" {
+		t.Errorf("relative model file should resolve to absolute: %q", cfg.ModelFile)
+	}
+	if cfg.AutosaveInterval != 0 {
+		t.Errorf("flag should override env: autosave=%v", cfg.AutosaveInterval)
+	}
+}
+
+func TestLoadServerConfig_ModelFileDefaults(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	getenv := func(string) string { return "" }
+	cfg, err := loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.ModelFile != "" || cfg.AutosaveInterval != time.Minute {
+		t.Errorf("defaults: modelFile=%q autosave=%v", cfg.ModelFile, cfg.AutosaveInterval)
+	}
+}
+
+func TestLoadServerConfig_ModelFileUnresolvable(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Remove(dir); err != nil {
+		t.Skipf("cannot remove the working directory: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	noenv := func(string) string { return "" }
+	if _, err := loadServerConfig(fs, []string{"--model-file", "model.json"}, noenv); err == nil || !strings.Contains(err.Error(), "resolve model file") {
+		t.Fatalf("expected a relative model file to fail without a working directory, got %v", err)
+	}
+}
+
+func TestLoadServerConfig_InvalidAutosaveInterval(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	getenv := func(key string) string {
+		if key == "GOBAYES_AUTOSAVE_INTERVAL" {
+			return "often"
+		}
+		return ""
+	}
+	if _, err := loadServerConfig(fs, []string{}, getenv); err == nil {
+		t.Fatal("expected error for invalid GOBAYES_AUTOSAVE_INTERVAL")
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	noenv := func(string) string { return "" }
+	if _, err := loadServerConfig(fs, []string{"--autosave-interval", "-1s"}, noenv); err == nil {
+		t.Fatal("expected error for negative autosave interval")
+	}
+}
+
+func TestLoadServerConfig_Decay(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	noenv := func(string) string { return "" }
+	cfg, err := loadServerConfig(fs, []string{}, noenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.DecayHalfLife != 0 || cfg.DecayInterval != time.Hour {
+		t.Errorf("defaults: halfLife=%v interval=%v", cfg.DecayHalfLife, cfg.DecayInterval)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	getenv := func(key string) string {
+		switch key {
+		case "GOBAYES_DECAY_HALF_LIFE":
+			return "168h"
+		case "GOBAYES_DECAY_INTERVAL":
+			return "10m"
+		default:
+			return ""
+		}
+	}
+	cfg, err = loadServerConfig(fs, []string{"--decay-interval", "30m"}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.DecayHalfLife != 168*time.Hour || cfg.DecayInterval != 30*time.Minute {
+		t.Errorf("env and flags: halfLife=%v interval=%v", cfg.DecayHalfLife, cfg.DecayInterval)
+	}
+
+	for _, args := range [][]string{{"--decay-half-life", "-1h"}, {"--decay-interval", "0"}} {
+		fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		if _, err := loadServerConfig(fs, args, noenv); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+
+	for _, key := range []string{"GOBAYES_DECAY_HALF_LIFE", "GOBAYES_DECAY_INTERVAL"} {
+		fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		badenv := func(k string) string {
+			if k == key {
+				return "weekly"
+			}
+			return ""
+		}
+		if _, err := loadServerConfig(fs, []string{}, badenv); err == nil {
+			t.Errorf("expected error for invalid %s", key)
+		}
+	}
+}
+
+func TestLoadServerConfig_ScoringMode(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	getenv := func(key string) string {
+		switch key {
+		case "GOBAYES_SCORING_MODE":
+			return "Multinomial"
+		case "GOBAYES_SMOOTHING":
+			return "0.5"
+		default:
+			return ""
+		}
+	}
+	cfg, err := loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.ScoringMode != bayes.ScoringMultinomial || cfg.Smoothing != 0.5 {
+		t.Errorf("env: scoring=%q smoothing=%v", cfg.ScoringMode, cfg.Smoothing)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{"--scoring-mode", "classic", "--smoothing", "2"}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.ScoringMode != bayes.ScoringClassic || cfg.Smoothing != 2 {
+		t.Errorf("flags: scoring=%q smoothing=%v", cfg.ScoringMode, cfg.Smoothing)
+	}
+}
+
+func TestLoadServerConfig_NGrams(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := loadServerConfig(fs, []string{}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.NGrams != 1 {
+		t.Errorf("default ngrams = %d, want 1", cfg.NGrams)
+	}
+
+	getenv := func(key string) string {
+		if key == "GOBAYES_NGRAMS" {
+			return "2"
+		}
+		return ""
+	}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.NGrams != 2 {
+		t.Errorf("env ngrams = %d, want 2", cfg.NGrams)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{"--ngrams", "3"}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.NGrams != 3 {
+		t.Errorf("flag ngrams = %d, want 3", cfg.NGrams)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	badenv := func(key string) string {
+		if key == "GOBAYES_NGRAMS" {
+			return "two"
+		}
+		return ""
+	}
+	if _, err := loadServerConfig(fs, []string{}, badenv); err == nil {
+		t.Error("expected error for invalid GOBAYES_NGRAMS")
+	}
+}
+
+func TestLoadServerConfig_Tokenizer(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := loadServerConfig(fs, []string{}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.Tokenizer != "" || cfg.CharNGramMin != 1 || cfg.CharNGramMax != 2 {
+		t.Errorf("defaults: tokenizer=%q char ngrams=%d-%d", cfg.Tokenizer, cfg.CharNGramMin, cfg.CharNGramMax)
+	}
+
+	getenv := func(key string) string {
+		switch key {
+		case "GOBAYES_TOKENIZER":
+			return "Chars"
+		case "GOBAYES_CHAR_NGRAM_MIN":
+			return "2"
+		case "GOBAYES_CHAR_NGRAM_MAX":
+			return "3"
+		}
+		return ""
+	}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.Tokenizer != bayes.TokenizerChars || cfg.CharNGramMin != 2 || cfg.CharNGramMax != 3 {
+		t.Errorf("env: tokenizer=%q char ngrams=%d-%d", cfg.Tokenizer, cfg.CharNGramMin, cfg.CharNGramMax)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{"--tokenizer", "words", "--char-ngram-min", "1", "--char-ngram-max", "1"}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.Tokenizer != bayes.TokenizerWords || cfg.CharNGramMin != 1 || cfg.CharNGramMax != 1 {
+		t.Errorf("flags: tokenizer=%q char ngrams=%d-%d", cfg.Tokenizer, cfg.CharNGramMin, cfg.CharNGramMax)
+	}
+
+	for _, key := range []string{"GOBAYES_CHAR_NGRAM_MIN", "GOBAYES_CHAR_NGRAM_MAX"} {
+		fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		badenv := func(k string) string {
+			if k == key {
+				return "two"
+			}
+			return ""
+		}
+		if _, err := loadServerConfig(fs, []string{}, badenv); err == nil {
+			t.Errorf("expected error for invalid %s", key)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--tokenizer", "bigrams"},
+		{"--char-ngram-min", "0"},
+		{"--char-ngram-min", "3", "--char-ngram-max", "2"},
+		{"--char-ngram-max", "6"},
+	} {
+		fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		if _, err := loadServerConfig(fs, args, func(string) string { return "" }); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestLoadServerConfig_DetectLanguage(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := loadServerConfig(fs, []string{}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.DetectLanguage {
+		t.Error("expected language detection off by default")
+	}
+
+	getenv := func(key string) string {
+		if key == "GOBAYES_DETECT_LANGUAGE" {
+			return "yes"
+		}
+		return ""
+	}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if !cfg.DetectLanguage {
+		t.Error("expected GOBAYES_DETECT_LANGUAGE to enable detection")
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{"--detect-language=false"}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.DetectLanguage {
+		t.Error("expected --detect-language=false to override env")
+	}
+}
+
+func TestLoadServerConfig_StopWordsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stopwords.txt")
+	if err := os.WriteFile(path, []byte("# domain\nunsubscribe\nAcme\n-not\n"), 0o600); err != nil {
+		t.Fatalf("write stop words file: %v", err)
+	}
+	getenv := func(key string) string {
+		if key == "GOBAYES_STOP_WORDS_FILE" {
+			return path
+		}
+		return ""
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.StopWordsFile != path || !slices.Equal(cfg.ExtraStopWords, []string{"unsubscribe", "acme"}) || !slices.Equal(cfg.ExcludedStopWords, []string{"not"}) {
+		t.Errorf("unexpected stop words config: file=%q extra=%v excluded=%v", cfg.StopWordsFile, cfg.ExtraStopWords, cfg.ExcludedStopWords)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := loadServerConfig(fs, []string{"--stop-words-file", filepath.Join(t.TempDir(), "missing.txt")}, getenv); err == nil {
+		t.Error("expected error for missing stop words file")
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := loadServerConfig(fs, []string{"--stop-words-file", t.TempDir()}, getenv); err == nil {
+		t.Error("expected error for an unreadable stop words file")
+	}
+}
+
+func TestLoadServerConfig_Normalize(t *testing.T) {
+	getenv := func(key string) string {
+		if key == "GOBAYES_NORMALIZE" {
+			return "money,urls"
+		}
+		return ""
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if !slices.Equal(cfg.Normalizers, []string{bayes.NormalizeURLs, bayes.NormalizeMoney}) {
+		t.Errorf("env normalizers = %v", cfg.Normalizers)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{"--normalize", ""}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.Normalizers != nil {
+		t.Errorf("expected --normalize= to disable normalizers, got %v", cfg.Normalizers)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := loadServerConfig(fs, []string{"--normalize", "urls,phones"}, getenv); err == nil {
+		t.Error("expected error for unknown normalizer")
+	}
+}
+
+func TestLoadServerConfig_Prior(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := loadServerConfig(fs, []string{}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.Prior != bayes.PriorTokens {
+		t.Errorf("default prior = %q, want %q", cfg.Prior, bayes.PriorTokens)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	getenv := func(key string) string {
+		if key == "GOBAYES_PRIOR" {
+			return "Documents"
+		}
+		return ""
+	}
+	cfg, err = loadServerConfig(fs, []string{}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.Prior != bayes.PriorDocuments {
+		t.Errorf("env prior = %q, want %q", cfg.Prior, bayes.PriorDocuments)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err = loadServerConfig(fs, []string{"--prior", "tokens"}, getenv)
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.Prior != bayes.PriorTokens {
+		t.Errorf("flag prior = %q, want %q", cfg.Prior, bayes.PriorTokens)
+	}
+}
+
+func TestLoadServerConfig_InvalidScoring(t *testing.T) {
+	noenv := func(string) string { return "" }
+	for _, args := range [][]string{
+		{"--scoring-mode", "fancy"},
+		{"--smoothing", "0"},
+		{"--prior", "vibes"},
+		{"--ngrams", "0"},
+		{"--ngrams", "4"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		if _, err := loadServerConfig(fs, args, noenv); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	badenv := func(key string) string {
+		if key == "GOBAYES_SMOOTHING" {
+			return "lots"
+		}
+		return ""
+	}
+	if _, err := loadServerConfig(fs, []string{}, badenv); err == nil {
+		t.Error("expected error for invalid GOBAYES_SMOOTHING")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// TestDecayAllDecaysEveryClassifier verifies decay reaches the default and named classifiers.
+func TestDecayAllDecaysEveryClassifier(t *testing.T) {
+	api, mux := newTestServer()
+	serve(mux, http.MethodPost, "/train/spam", "cheap pills")
+	serve(mux, http.MethodPost, "/classifiers/support", "")
+	serve(mux, http.MethodPost, "/classifiers/support/train/billing", "refund invoice")
+
+	start := time.Now()
+	api.decayAll(start, time.Hour)
+	api.decayAll(start.Add(time.Hour), time.Hour)
+
+	if got := api.classifier.Summaries()["spam"].TokenTally; got != 1 {
+		t.Fatalf("unexpected default tally: %v", got)
+	}
+	support, _, _ := api.lookupTenant("support")
+	if got := support.classifier.Summaries()["billing"].TokenTally; got != 1 {
+		t.Fatalf("unexpected named classifier tally: %v", got)
+	}
+}
+
+// TestDecayModelsDecaysDowntimeAtStartup verifies a saved model decays for
+// the time the server was not running.
+func TestDecayModelsDecaysDowntimeAtStartup(t *testing.T) {
+	api := newPersistentTestAPI(t)
+	if err := api.classifier.Train("spam", "cheap pills"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+	api.decayAll(time.Now().Add(-time.Hour), time.Hour)
+	if err := api.saveModel(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	restarted := &ClassifierAPI{classifier: bayes.NewClassifier(), modelFile: api.modelFile}
+	if err := restarted.loadModel(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	stop := make(chan struct{})
+	close(stop)
+	restarted.decayModels(time.Hour, time.Hour, stop)
+
+	got := restarted.classifier.Summaries()["spam"].TokenTally
+	if got < 0.99 || got > 1.01 {
+		t.Fatalf("expected tally halved by an hour of downtime, got %v", got)
+	}
+}
+
+// TestDecayModelsRunsOnSchedule verifies the decay loop applies decay until stopped.
+func TestDecayModelsRunsOnSchedule(t *testing.T) {
+	api, _ := newTestServer()
+	if err := api.classifier.Train("spam", "buy now"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		api.decayModels(time.Nanosecond, 5*time.Millisecond, stop)
+		close(done)
+	}()
+
+	// The startup decay only records the time; a tick decays the counts,
+	// which over many half-lives drops them.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := api.classifier.Summaries()["spam"]; !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for decay to drop the counts")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stop)
+	<-done
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hickeroar/gobayes/v4/bayes/eval"
+)
+
+// TestEvaluateHandler verifies /evaluate scores a holdout set without
+// training on it and rejects invalid sets.
+func TestEvaluateHandler(t *testing.T) {
+	api, mux := newTestServer()
+	serve(mux, http.MethodPost, "/train/spam", "cheap pills now")
+	serve(mux, http.MethodPost, "/train/ham", "team meeting notes")
+	revision := api.classifier.Revision()
+
+	body := `[{"category":"spam","text":"cheap pills"},{"category":"ham","text":"meeting at noon"},{"category":"ham","text":"cheap lunch"}]`
+	rr := serve(mux, http.MethodPost, "/evaluate", body)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	assertJSONContentType(t, rr)
+	var report eval.Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if report.Samples != 3 || report.Correct != 2 || report.ConfusionMatrix["ham"]["spam"] != 1 || report.Categories["spam"].Precision != 0.5 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if api.classifier.Revision() != revision {
+		t.Fatal("expected evaluation not to change the model")
+	}
+
+	rr = serve(mux, http.MethodPost, "/classifiers/default/evaluate", "{\"category\":\"spam\",\"text\":\"pills\"}\n")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status for NDJSON: got %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	for _, body := range []string{"", "[]", `[{"category":"no spaces","text":"x"}]`, `[{"label":"spam"}]`} {
+		rr := serve(mux, http.MethodPost, "/evaluate", body)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%q: unexpected status: got %d want %d", body, rr.Code, http.StatusBadRequest)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+	if rr := serve(mux, http.MethodGet, "/evaluate", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+}
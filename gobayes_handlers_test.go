@@ -11,7 +11,7 @@ import (
 	"sync"
 	"testing"
 
-	"github.com/hickeroar/gobayes/v3/bayes"
+	"github.com/hickeroar/gobayes/v4/bayes"
 )
 
 // assertJSONContentType verifies the response content type is JSON.
@@ -155,14 +155,18 @@ func TestTrainInfoFlushLifecycle(t *testing.T) {
 	}
 
 	var infoResp struct {
-		Categories map[string]json.RawMessage
+		Categories map[string]CategoryInfo
 	}
 	if err := json.Unmarshal(infoRR.Body.Bytes(), &infoResp); err != nil {
 		t.Fatalf("failed to unmarshal info response: %v", err)
 	}
-	if _, ok := infoResp.Categories["spam"]; !ok {
+	spam, ok := infoResp.Categories["spam"]
+	if !ok {
 		t.Fatal("expected spam category in info response")
 	}
+	if spam.DocumentCount != 1 {
+		t.Fatalf("expected spam documentCount 1, got %v", spam.DocumentCount)
+	}
 
 	flushReq := httptest.NewRequest(http.MethodPost, "/flush", nil)
 	flushRR := httptest.NewRecorder()
@@ -269,6 +273,157 @@ func TestUntrainHandlerBadBody(t *testing.T) {
 	assertJSONErrorShape(t, rr)
 }
 
+// TestProbabilitiesHandler verifies probabilities are normalized and classify reports the winner's share.
+func TestProbabilitiesHandler(t *testing.T) {
+	_, mux := newTestServer()
+	for path, body := range map[string]string{"/train/spam": "buy cheap pills now", "/train/ham": "team meeting schedule"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("unexpected train status for %s: %d", path, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/probabilities", strings.NewReader("cheap meeting pills")))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected probabilities status: %d", rr.Code)
+	}
+	assertJSONContentType(t, rr)
+	var probabilities map[string]float64
+	if err := json.Unmarshal(rr.Body.Bytes(), &probabilities); err != nil {
+		t.Fatalf("decode probabilities: %v", err)
+	}
+	sum := probabilities["spam"] + probabilities["ham"]
+	if len(probabilities) != 2 || sum < 0.999999 || sum > 1.000001 {
+		t.Fatalf("expected two probabilities summing to 1, got %v", probabilities)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/classify", strings.NewReader("cheap meeting pills")))
+	var classification bayes.Classification
+	if err := json.Unmarshal(rr.Body.Bytes(), &classification); err != nil {
+		t.Fatalf("decode classification: %v", err)
+	}
+	if classification.Category != "spam" || classification.Probability != probabilities["spam"] {
+		t.Fatalf("unexpected classification: %+v (probabilities %v)", classification, probabilities)
+	}
+}
+
+// TestProbabilitiesHandlerBadBody verifies probabilities handler bad body.
+func TestProbabilitiesHandlerBadBody(t *testing.T) {
+	_, mux := newTestServer()
+	req := httptest.NewRequest(http.MethodPost, "/probabilities", nil)
+	req.Body = io.NopCloser(errReader{})
+	rr := httptest.NewRecorder()
+
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: got %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	assertJSONErrorShape(t, rr)
+}
+
+// TestClassifyHandlerTopNAndAbstain verifies the n, minMargin, and unknown query parameters.
+func TestClassifyHandlerTopNAndAbstain(t *testing.T) {
+	_, mux := newTestServer()
+	for path, body := range map[string]string{"/train/spam": "buy cheap pills now", "/train/ham": "team meeting schedule"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("unexpected train status for %s: %d", path, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/classify?n=2", strings.NewReader("cheap meeting pills")))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected classify status: %d", rr.Code)
+	}
+	var ranked bayes.RankedClassification
+	if err := json.Unmarshal(rr.Body.Bytes(), &ranked); err != nil {
+		t.Fatalf("decode classification: %v", err)
+	}
+	if ranked.Category != "spam" || ranked.Abstained || len(ranked.Ranking) != 2 || ranked.Ranking[1].Category != "ham" {
+		t.Fatalf("unexpected ranked classification: %+v", ranked)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/classify?minMargin=100&unknown=unsure", strings.NewReader("cheap meeting pills")))
+	ranked = bayes.RankedClassification{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &ranked); err != nil {
+		t.Fatalf("decode classification: %v", err)
+	}
+	if ranked.Category != "unsure" || !ranked.Abstained || ranked.Ranking != nil {
+		t.Fatalf("expected abstained classification, got %+v", ranked)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/classify?minScore=1000", strings.NewReader("cheap meeting pills")))
+	ranked = bayes.RankedClassification{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &ranked); err != nil {
+		t.Fatalf("decode classification: %v", err)
+	}
+	if ranked.Category != "" || !ranked.Abstained {
+		t.Fatalf("expected abstained classification below minScore, got %+v", ranked)
+	}
+
+	for _, query := range []string{"n=0", "n=two", "minScore=abc", "minScore=NaN", "minMargin=-1"} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/classify?"+query, strings.NewReader("cheap")))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %q, got %d", query, rr.Code)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+}
+
+// TestExplainHandler verifies explain reports the classification and per-token contributions.
+func TestExplainHandler(t *testing.T) {
+	_, mux := newTestServer()
+	for path, body := range map[string]string{"/train/spam": "buy cheap pills now", "/train/ham": "team meeting schedule"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("unexpected train status for %s: %d", path, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/explain", strings.NewReader("cheap pills meeting")))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected explain status: %d", rr.Code)
+	}
+	assertJSONContentType(t, rr)
+	var explanation bayes.Explanation
+	if err := json.Unmarshal(rr.Body.Bytes(), &explanation); err != nil {
+		t.Fatalf("decode explanation: %v", err)
+	}
+	if explanation.Classification.Category != "spam" {
+		t.Fatalf("unexpected classification: %+v", explanation.Classification)
+	}
+	spam := explanation.Categories["spam"]
+	if len(spam.Tokens) != 2 || spam.Tokens[0].CategoryCount != 1 || spam.Tokens[0].Count != 1 {
+		t.Fatalf("unexpected spam tokens: %+v", spam.Tokens)
+	}
+}
+
+// TestExplainHandlerBadBody verifies explain handler bad body.
+func TestExplainHandlerBadBody(t *testing.T) {
+	_, mux := newTestServer()
+	req := httptest.NewRequest(http.MethodPost, "/explain", nil)
+	req.Body = io.NopCloser(errReader{})
+	rr := httptest.NewRecorder()
+
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: got %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	assertJSONErrorShape(t, rr)
+}
+
 // TestTrainHandlerMethodNotAllowed verifies train handler method not allowed.
 func TestTrainHandlerMethodNotAllowed(t *testing.T) {
 	_, mux := newTestServer()
@@ -392,6 +547,42 @@ func TestReadyEndpointNotReady(t *testing.T) {
 	assertJSONContentType(t, rr)
 }
 
+// TestWithReadinessRejectsRequestsUntilReady verifies only probes are served
+// while the server is not ready.
+func TestWithReadinessRejectsRequestsUntilReady(t *testing.T) {
+	api, mux := newTestServer()
+	api.ready.Store(false)
+	handler := api.withReadiness(mux)
+
+	for path, want := range map[string]int{
+		"/train/spam":         http.StatusServiceUnavailable,
+		"/classifiers/alerts": http.StatusServiceUnavailable,
+		"/healthz":            http.StatusOK,
+		"/readyz":             http.StatusServiceUnavailable,
+	} {
+		method := http.MethodPost
+		if path == "/healthz" || path == "/readyz" {
+			method = http.MethodGet
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader("buy now")))
+		if rr.Code != want {
+			t.Fatalf("%s: unexpected status: got %d, want %d", path, rr.Code, want)
+		}
+		assertJSONContentType(t, rr)
+	}
+	if len(api.classifier.Summaries()) != 0 || len(api.tenants) != 0 {
+		t.Fatal("expected requests before readiness to leave the models unchanged")
+	}
+
+	api.ready.Store(true)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/train/spam", strings.NewReader("buy now")))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status once ready: got %d", rr.Code)
+	}
+}
+
 // TestConcurrentRequests verifies concurrent requests.
 func TestConcurrentRequests(t *testing.T) {
 	_, mux := newTestServer()
@@ -449,6 +640,12 @@ func TestAPIContractMatrix(t *testing.T) {
 		{name: "classify wrong method", method: http.MethodGet, path: "/classify", status: http.StatusMethodNotAllowed, allowHeader: http.MethodPost, expectError: true},
 		{name: "classify oversized body", method: http.MethodPost, path: "/classify", body: oversized, status: http.StatusRequestEntityTooLarge, expectError: true},
 		{name: "score wrong method", method: http.MethodGet, path: "/score", status: http.StatusMethodNotAllowed, allowHeader: http.MethodPost, expectError: true},
+		{name: "probabilities post ok", method: http.MethodPost, path: "/probabilities", body: []byte("buy now"), status: http.StatusOK},
+		{name: "probabilities wrong method", method: http.MethodGet, path: "/probabilities", status: http.StatusMethodNotAllowed, allowHeader: http.MethodPost, expectError: true},
+		{name: "probabilities oversized body", method: http.MethodPost, path: "/probabilities", body: oversized, status: http.StatusRequestEntityTooLarge, expectError: true},
+		{name: "explain post ok", method: http.MethodPost, path: "/explain", body: []byte("buy now"), status: http.StatusOK},
+		{name: "explain wrong method", method: http.MethodGet, path: "/explain", status: http.StatusMethodNotAllowed, allowHeader: http.MethodPost, expectError: true},
+		{name: "explain oversized body", method: http.MethodPost, path: "/explain", body: oversized, status: http.StatusRequestEntityTooLarge, expectError: true},
 		{name: "flush wrong method", method: http.MethodGet, path: "/flush", status: http.StatusMethodNotAllowed, allowHeader: http.MethodPost, expectError: true},
 		{name: "healthz get ok", method: http.MethodGet, path: "/healthz", status: http.StatusOK},
 		{name: "readyz get ok", method: http.MethodGet, path: "/readyz", status: http.StatusOK},
@@ -478,3 +675,89 @@ func TestAPIContractMatrix(t *testing.T) {
 		})
 	}
 }
+
+// TestContentTypeInputFormats verifies HTML and email bodies are parsed by Content-Type.
+func TestContentTypeInputFormats(t *testing.T) {
+	api, mux := newTestServer()
+	post := func(path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	email := "From: Promo <promo@deals.example>\r\nSubject: Cheap pills\r\n" +
+		"Content-Type: text/plain\r\nContent-Transfer-Encoding: base64\r\n\r\nQnV5IG5vdw==\r\n"
+	if rr := post("/train/spam", "message/rfc822", email); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+	if rr := post("/train/ham", "text/html; charset=utf-8", "<p>Team <b>meeting</b></p><script>buy()</script>"); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+	summaries := api.classifier.Summaries()
+	if summaries["spam"].TokenTally != 5 || summaries["ham"].TokenTally != 2 {
+		t.Fatalf("unexpected token tallies: %+v", summaries)
+	}
+
+	rr := post("/classify?n=2", "message/rfc822", "From: x@deals.example\r\n\r\nhello")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+	var result bayes.RankedClassification
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil || result.Category != "spam" {
+		t.Fatalf("unexpected classification: %s", rr.Body.String())
+	}
+
+	if rr := post("/train/ham", "text/plain; charset=utf-8", "<p>meeting</p>"); rr.Code != http.StatusOK || api.classifier.Summaries()["ham"].TokenTally != 5 {
+		t.Fatalf("expected text/plain bodies to be trained as text, got %d", rr.Code)
+	}
+
+	rr = post("/score", "text/html", "<i>meeting</i>")
+	var scores map[string]float64
+	if err := json.Unmarshal(rr.Body.Bytes(), &scores); err != nil || scores["ham"] <= 0 || scores["spam"] != 0 {
+		t.Fatalf("unexpected scores: %s", rr.Body.String())
+	}
+
+	for _, path := range []string{"/train/spam", "/untrain/spam", "/classify", "/score"} {
+		rr := post(path, "message/rfc822", "not an email")
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: unexpected status: got %d want %d", path, rr.Code, http.StatusBadRequest)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+}
+
+// TestWeightQueryParameter verifies /train and /untrain honor the weight
+// query parameter and reject invalid weights.
+func TestWeightQueryParameter(t *testing.T) {
+	api, mux := newTestServer()
+	post := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("buy now"))
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post("/train/spam?weight=2.5"); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+	if got := api.classifier.Summaries()["spam"]; got.TokenTally != 5 || got.DocumentCount != 2.5 {
+		t.Fatalf("unexpected weighted summary: %+v", got)
+	}
+
+	for _, path := range []string{"/train/spam?weight=0", "/train/spam?weight=-1", "/untrain/spam?weight=abc", "/untrain/spam?weight=NaN", "/train/spam?weight=Inf"} {
+		rr := post(path)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: unexpected status: got %d want %d", path, rr.Code, http.StatusBadRequest)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+
+	if rr := post("/untrain/spam?weight=2.5"); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+	if _, ok := api.classifier.Summaries()["spam"]; ok {
+		t.Fatal("expected weighted untrain to remove the category")
+	}
+}
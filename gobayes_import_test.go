@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hickeroar/gobayes/v4/bayes/corpus"
+)
+
+// TestImportHandler verifies /import streams NDJSON and CSV bodies and
+// reports rejects.
+func TestImportHandler(t *testing.T) {
+	api, mux := newTestServer()
+	post := func(path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := post("/import", "application/x-ndjson", "{\"category\":\"spam\",\"text\":\"cheap pills\"}\n{\"category\":\"bad name\",\"text\":\"x\"}\n")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	assertJSONContentType(t, rr)
+	var report corpus.Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil || report.Imported != 1 || report.Rejected != 1 || report.Rejects[0].Source != "line 2" {
+		t.Fatalf("unexpected report: %s", rr.Body.String())
+	}
+
+	rr = post("/import?labelColumn=label&textColumn=body", "text/csv; charset=utf-8", "label,body\nham,team meeting\nham,lunch\n")
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil || report.Categories["ham"] != 2 {
+		t.Fatalf("unexpected CSV report: %s", rr.Body.String())
+	}
+	rr = post("/classifiers/default/import", "text/tab-separated-values", "category\ttext\nspam\tbuy now\n")
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil || report.Categories["spam"] != 1 {
+		t.Fatalf("unexpected TSV report: %s", rr.Body.String())
+	}
+	if got := api.classifier.Summaries(); got["ham"].DocumentCount != 2 || got["spam"].DocumentCount != 2 {
+		t.Fatalf("unexpected model after import: %+v", got)
+	}
+
+	rr = post("/import", "text/csv", "id,body\n1,x\n")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status for missing columns: got %d", rr.Code)
+	}
+	assertJSONErrorShape(t, rr)
+	if rr := serve(mux, http.MethodGet, "/import", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+
+	// Blank lines keep the oversized body cheap to stream.
+	blank := strings.Repeat(" ", 1<<20-1) + "\n"
+	chunks := make([]io.Reader, maxImportBodyBytes/len(blank)+1)
+	for i := range chunks {
+		chunks[i] = strings.NewReader(blank)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/import", io.MultiReader(chunks...))
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for an oversized import, got %d", rr.Code)
+	}
+}
+
+// TestImportHandlerOutlastsServerTimeouts verifies an import body that
+// arrives slowly is read past the server's read and write timeouts.
+func TestImportHandlerOutlastsServerTimeouts(t *testing.T) {
+	api, mux := newTestServer()
+	server := httptest.NewUnstartedServer(withVerbose(mux))
+	server.Config.ReadTimeout = 50 * time.Millisecond
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	body, bodyWriter := io.Pipe()
+	go func() {
+		_, _ = io.WriteString(bodyWriter, "{\"category\":\"spam\",\"text\":\"cheap pills\"}\n")
+		time.Sleep(200 * time.Millisecond)
+		_, _ = io.WriteString(bodyWriter, "{\"category\":\"ham\",\"text\":\"team lunch\"}\n")
+		bodyWriter.Close()
+	}()
+	resp, err := http.Post(server.URL+"/import", "application/x-ndjson", body)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: got %d", resp.StatusCode)
+	}
+	if got := api.classifier.Summaries(); got["spam"].DocumentCount != 1 || got["ham"].DocumentCount != 1 {
+		t.Fatalf("expected both samples to be imported, got %+v", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -177,6 +178,7 @@ func TestRunMainWithVerbose(t *testing.T) {
 // TestWithVerboseRequestAndResponseBody covers verbose middleware with non-empty request and response body.
 func TestWithVerboseRequestAndResponseBody(t *testing.T) {
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{"status":"ok"}`))
 	})
@@ -195,6 +197,7 @@ func TestWithVerboseRequestAndResponseBody(t *testing.T) {
 func TestWithVerboseLongBodyTruncation(t *testing.T) {
 	longBody := strings.Repeat("x", 300)
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(strings.Repeat("y", 300)))
 	})
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// newPersistentTestAPI creates a classifier API backed by a model file in a temp dir.
+func newPersistentTestAPI(t *testing.T) *ClassifierAPI {
+	t.Helper()
+	return &ClassifierAPI{
+		classifier: bayes.NewClassifier(),
+		modelFile:  filepath.Join(t.TempDir(), "model.json"),
+	}
+}
+
+// TestLoadModelMissingFileStartsEmpty verifies a missing model file is not an error.
+func TestLoadModelMissingFileStartsEmpty(t *testing.T) {
+	api := newPersistentTestAPI(t)
+	if err := api.loadModel(); err != nil {
+		t.Fatalf("expected missing model file to be ignored, got %v", err)
+	}
+	if len(api.classifier.Summaries()) != 0 {
+		t.Fatal("expected empty classifier after missing model file")
+	}
+}
+
+// TestLoadModelRejectsCorruptFile verifies a corrupt model file fails loading.
+func TestLoadModelRejectsCorruptFile(t *testing.T) {
+	api := newPersistentTestAPI(t)
+	if err := os.WriteFile(api.modelFile, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("write corrupt model: %v", err)
+	}
+	if err := api.loadModel(); err == nil {
+		t.Fatal("expected corrupt model file to fail loading")
+	}
+}
+
+// TestSaveModelOnlyWritesWhenDirty verifies unchanged models are not rewritten.
+func TestSaveModelOnlyWritesWhenDirty(t *testing.T) {
+	api := newPersistentTestAPI(t)
+	if err := api.loadModel(); err != nil {
+		t.Fatalf("load model: %v", err)
+	}
+
+	if err := api.saveModel(); err != nil {
+		t.Fatalf("save clean model: %v", err)
+	}
+	if _, err := os.Stat(api.modelFile); !os.IsNotExist(err) {
+		t.Fatalf("expected clean model not to be written, stat err=%v", err)
+	}
+
+	if err := api.classifier.Train("spam", "buy now"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+	if err := api.saveModel(); err != nil {
+		t.Fatalf("save dirty model: %v", err)
+	}
+	info, err := os.Stat(api.modelFile)
+	if err != nil {
+		t.Fatalf("expected model file after dirty save: %v", err)
+	}
+
+	// Backdate the file so a rewrite would be observable through ModTime.
+	old := info.ModTime().Add(-time.Hour)
+	if err := os.Chtimes(api.modelFile, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := api.saveModel(); err != nil {
+		t.Fatalf("save unchanged model: %v", err)
+	}
+	info, err = os.Stat(api.modelFile)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Fatal("expected unchanged model not to be rewritten")
+	}
+}
+
+// TestSaveModelWithoutModelFile verifies persistence is a no-op when unconfigured.
+func TestSaveModelWithoutModelFile(t *testing.T) {
+	api := &ClassifierAPI{classifier: bayes.NewClassifier()}
+	if err := api.classifier.Train("spam", "buy now"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+	if err := api.loadModel(); err != nil {
+		t.Fatalf("load without model file: %v", err)
+	}
+	if err := api.saveModel(); err != nil {
+		t.Fatalf("save without model file: %v", err)
+	}
+}
+
+// TestSaveModelReportsWriteError verifies save failures are surfaced.
+func TestSaveModelReportsWriteError(t *testing.T) {
+	api := &ClassifierAPI{
+		classifier: bayes.NewClassifier(),
+		modelFile:  filepath.Join(t.TempDir(), "missing-dir", "model.json"),
+	}
+	if err := api.classifier.Train("spam", "buy now"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+	if err := api.saveModel(); err == nil {
+		t.Fatal("expected save into missing directory to fail")
+	}
+}
+
+// TestSaveModelPersistsNamedClassifiers verifies named classifiers are saved
+// next to the model file, restored by loadModel, and removed once deleted.
+func TestSaveModelPersistsNamedClassifiers(t *testing.T) {
+	api := newPersistentTestAPI(t)
+	if _, err := api.createTenant("support", createClassifierRequest{Language: "spanish"}); err != nil {
+		t.Fatalf("create classifier: %v", err)
+	}
+	if err := api.saveModel(); err != nil {
+		t.Fatalf("save model: %v", err)
+	}
+	tenantFile := api.tenantModelFile("support")
+	if _, err := os.Stat(tenantFile); err != nil {
+		t.Fatalf("expected new named classifier to be saved: %v", err)
+	}
+	if _, err := os.Stat(api.modelFile); !os.IsNotExist(err) {
+		t.Fatalf("expected clean default model not to be written, stat err=%v", err)
+	}
+
+	support, _, _ := api.lookupTenant("support")
+	if err := support.classifier.Train("billing", "factura pendiente"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+	if err := api.saveModel(); err != nil {
+		t.Fatalf("save model: %v", err)
+	}
+
+	restored := newPersistentTestAPI(t)
+	restored.modelFile = api.modelFile
+	for _, name := range []string{"model.json.bad!name.json", "model.json.default.json", "model.json.notes.txt", "other.json"} {
+		if err := os.WriteFile(filepath.Join(filepath.Dir(api.modelFile), name), []byte("{not json"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := os.Mkdir(restored.tenantModelFile("dir"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := restored.loadModel(); err != nil {
+		t.Fatalf("load model: %v", err)
+	}
+	if len(restored.tenants) != 1 {
+		t.Fatalf("expected only the support classifier to be restored, got %v", restored.tenants)
+	}
+	loaded, _, ok := restored.lookupTenant("support")
+	if !ok || loaded.classifier.TokenizerOptions().Language != "spanish" || loaded.classifier.Summaries()["billing"].TokenTally == 0 {
+		t.Fatalf("unexpected restored classifier: %+v", loaded)
+	}
+
+	// Backdate the file so a rewrite would be observable through ModTime.
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(tenantFile, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := restored.saveModel(); err != nil {
+		t.Fatalf("save unchanged model: %v", err)
+	}
+	if info, err := os.Stat(tenantFile); err != nil || !info.ModTime().Equal(old) {
+		t.Fatalf("expected unchanged named classifier not to be rewritten, err=%v", err)
+	}
+
+	restored.deleteTenant("support")
+	if _, err := restored.createTenant("support", createClassifierRequest{}); err != nil {
+		t.Fatalf("recreate classifier: %v", err)
+	}
+	if err := restored.saveModel(); err != nil {
+		t.Fatalf("save recreated classifier: %v", err)
+	}
+	if info, err := os.Stat(tenantFile); err != nil || info.ModTime().Equal(old) {
+		t.Fatalf("expected recreated named classifier to be rewritten, err=%v", err)
+	}
+
+	restored.deleteTenant("support")
+	if err := restored.saveModel(); err != nil {
+		t.Fatalf("save after delete: %v", err)
+	}
+	if _, err := os.Stat(tenantFile); !os.IsNotExist(err) {
+		t.Fatalf("expected deleted named classifier file to be removed, stat err=%v", err)
+	}
+}
+
+// TestLoadModelRejectsCorruptNamedClassifier verifies a corrupt named
+// classifier file fails loading.
+func TestLoadModelRejectsCorruptNamedClassifier(t *testing.T) {
+	api := newPersistentTestAPI(t)
+	if err := os.WriteFile(api.tenantModelFile("support"), []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("write corrupt model: %v", err)
+	}
+	if err := api.loadModel(); err == nil || !strings.Contains(err.Error(), `"support"`) {
+		t.Fatalf("expected corrupt named classifier to fail loading, got %v", err)
+	}
+}
+
+// TestLoadModelReportsUnreadableDirectory verifies a model directory that
+// cannot be listed fails loading.
+func TestLoadModelReportsUnreadableDirectory(t *testing.T) {
+	oldReadDir := readDir
+	defer func() { readDir = oldReadDir }()
+	readDir = func(string) ([]os.DirEntry, error) { return nil, os.ErrPermission }
+
+	api := newPersistentTestAPI(t)
+	if err := api.loadModel(); err == nil {
+		t.Fatal("expected unreadable model directory to fail loading")
+	}
+}
+
+// TestSaveModelReportsNamedClassifierErrors verifies failures to save or
+// remove named classifier files are surfaced.
+func TestSaveModelReportsNamedClassifierErrors(t *testing.T) {
+	api := &ClassifierAPI{
+		classifier: bayes.NewClassifier(),
+		modelFile:  filepath.Join(t.TempDir(), "missing-dir", "model.json"),
+	}
+	if _, err := api.createTenant("support", createClassifierRequest{}); err != nil {
+		t.Fatalf("create classifier: %v", err)
+	}
+	if err := api.saveModel(); err == nil || !strings.Contains(err.Error(), `"support"`) {
+		t.Fatalf("expected named classifier save to fail, got %v", err)
+	}
+
+	api = newPersistentTestAPI(t)
+	if _, err := api.createTenant("support", createClassifierRequest{}); err != nil {
+		t.Fatalf("create classifier: %v", err)
+	}
+	if err := api.saveModel(); err != nil {
+		t.Fatalf("save model: %v", err)
+	}
+	// A non-empty directory in place of the file cannot be removed.
+	tenantFile := api.tenantModelFile("support")
+	if err := os.Remove(tenantFile); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tenantFile, "keep"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	api.deleteTenant("support")
+	if err := api.saveModel(); err == nil || !strings.Contains(err.Error(), `"support"`) {
+		t.Fatalf("expected named classifier removal to fail, got %v", err)
+	}
+}
+
+// TestAutosaveWritesDirtyModel verifies the autosave loop persists changes.
+func TestAutosaveWritesDirtyModel(t *testing.T) {
+	api := newPersistentTestAPI(t)
+	if err := api.classifier.Train("spam", "buy now"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		api.autosave(5*time.Millisecond, stop)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(api.modelFile); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for autosave")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stop)
+	<-done
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes and reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestAutosaveLogsFailures verifies the autosave loop logs a failed save and
+// keeps running.
+func TestAutosaveLogsFailures(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	api := &ClassifierAPI{classifier: bayes.NewClassifier(), modelFile: filepath.Join(dir, "file", "model.json")}
+	if err := api.classifier.Train("spam", "buy now"); err != nil {
+		t.Fatalf("train: %v", err)
+	}
+	var logs syncBuffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		api.autosave(5*time.Millisecond, stop)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(logs.String(), "autosave failed") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the autosave failure to be logged")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(stop)
+	<-done
+}
+
+// TestRunMainPersistsModelAcrossRestart verifies load on boot and save on shutdown.
+func TestRunMainPersistsModelAcrossRestart(t *testing.T) {
+	oldMakeSignal := makeSignalChannel
+	oldNotify := notifySignals
+	oldNewServer := newServer
+	oldLogFatal := logFatal
+	oldFlagCommandLine := flag.CommandLine
+	oldArgs := os.Args
+	defer func() {
+		makeSignalChannel = oldMakeSignal
+		notifySignals = oldNotify
+		newServer = oldNewServer
+		logFatal = oldLogFatal
+		flag.CommandLine = oldFlagCommandLine
+		os.Args = oldArgs
+	}()
+
+	modelFile := filepath.Join(t.TempDir(), "model.json")
+	notifySignals = func(chan<- os.Signal, ...os.Signal) {}
+	logFatal = func(...interface{}) {}
+
+	// run starts runMain, lets exercise drive the handler, then sends SIGTERM.
+	run := func(exercise func(http.Handler)) {
+		sigCh := make(chan os.Signal, 1)
+		makeSignalChannel = func() chan os.Signal { return sigCh }
+		handlerCh := make(chan http.Handler, 1)
+		newServer = func(_ string, handler http.Handler) httpServer {
+			handlerCh <- handler
+			return &fakeServer{listenErr: http.ErrServerClosed}
+		}
+		flag.CommandLine = flag.NewFlagSet("test", flag.ContinueOnError)
+		os.Args = []string{"gobayes.test", "--model-file", modelFile, "--autosave-interval", "0"}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- runMain()
+		}()
+
+		handler := <-handlerCh
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rr.Code == http.StatusOK {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for readiness")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		exercise(handler)
+		sigCh <- syscall.SIGTERM
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("expected nil runMain error, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for runMain to exit")
+		}
+	}
+
+	run(func(handler http.Handler) {
+		req := httptest.NewRequest(http.MethodPost, "/train/spam", strings.NewReader("buy now"))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("train: got status %d", rr.Code)
+		}
+		for _, step := range []struct{ path, body string }{
+			{path: "/classifiers/support", body: `{"language":"spanish"}`},
+			{path: "/classifiers/support/train/billing", body: "factura pendiente"},
+		} {
+			rr = httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, step.path, strings.NewReader(step.body)))
+			if rr.Code != http.StatusOK && rr.Code != http.StatusCreated {
+				t.Fatalf("%s: got status %d", step.path, rr.Code)
+			}
+		}
+	})
+	for _, path := range []string{modelFile, modelFile + ".support.json"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected model file after shutdown: %v", err)
+		}
+	}
+
+	run(func(handler http.Handler) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/info", nil))
+		if !strings.Contains(rr.Body.String(), `"spam"`) {
+			t.Fatalf("expected restored spam category, got %s", rr.Body.String())
+		}
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/classifiers/support/info", nil))
+		if !strings.Contains(rr.Body.String(), `"billing"`) {
+			t.Fatalf("expected restored named classifier, got %s", rr.Body.String())
+		}
+	})
+}
+
+// TestRunMainFailsOnCorruptModel verifies startup aborts when the model cannot be loaded.
+func TestRunMainFailsOnCorruptModel(t *testing.T) {
+	oldNewServer := newServer
+	oldLogFatal := logFatal
+	oldFlagCommandLine := flag.CommandLine
+	oldArgs := os.Args
+	defer func() {
+		newServer = oldNewServer
+		logFatal = oldLogFatal
+		flag.CommandLine = oldFlagCommandLine
+		os.Args = oldArgs
+	}()
+
+	modelFile := filepath.Join(t.TempDir(), "model.json")
+	if err := os.WriteFile(modelFile, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("write corrupt model: %v", err)
+	}
+	newServer = func(string, http.Handler) httpServer {
+		return &fakeServer{listenErr: http.ErrServerClosed}
+	}
+	logFatal = func(...interface{}) {}
+	flag.CommandLine = flag.NewFlagSet("test", flag.ContinueOnError)
+	os.Args = []string{"gobayes.test", "--model-file", modelFile}
+
+	if err := runMain(); err == nil {
+		t.Fatal("expected runMain to fail on corrupt model file")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// TestPruneHandler verifies dry-run and applied pruning through /prune.
+func TestPruneHandler(t *testing.T) {
+	api, mux := newTestServer()
+	serve(mux, http.MethodPost, "/train/spam", "cheap pills cheap")
+	serve(mux, http.MethodPost, "/train/ham", "meeting notes")
+
+	rr := serve(mux, http.MethodPost, "/prune?minCount=2&dryRun=true", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var report bayes.PruneReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if !report.DryRun || report.RemovedTokenCount != 3 || len(report.RemovedCategories) != 1 || report.RemovedCategories[0] != "ham" {
+		t.Fatalf("unexpected dry-run report: %+v", report)
+	}
+	if _, ok := api.classifier.Summaries()["ham"]; !ok {
+		t.Fatal("expected dry run to keep ham")
+	}
+
+	rr = serve(mux, http.MethodPost, "/prune?minCount=2", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+	summaries := api.classifier.Summaries()
+	if _, ok := summaries["ham"]; ok || summaries["spam"].TokenTally != 2 {
+		t.Fatalf("unexpected model after pruning: %+v", summaries)
+	}
+
+	for _, path := range []string{"/prune", "/prune?minCount=x", "/prune?topK=-1", "/prune?topK=5&method=gini", "/prune?minCount=2&dryRun=maybe"} {
+		rr := serve(mux, http.MethodPost, path, "")
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: unexpected status: got %d want %d", path, rr.Code, http.StatusBadRequest)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+	if rr := serve(mux, http.MethodGet, "/prune?minCount=2", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: got %d", rr.Code)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serve sends a request to mux and returns the recorder.
+func serve(mux *http.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rr
+}
+
+// TestNamedClassifierLifecycle verifies create, train, classify, list, and delete of a named classifier.
+func TestNamedClassifierLifecycle(t *testing.T) {
+	api, mux := newTestServer()
+
+	rr := serve(mux, http.MethodPost, "/classifiers/team-es", `{"language":"Spanish","removeStopWords":true,"ngrams":2}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("unexpected create status: %d %s", rr.Code, rr.Body.String())
+	}
+	var info ClassifierInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+	if info.Language != "spanish" || !info.RemoveStopWords || info.NGrams != 2 {
+		t.Fatalf("unexpected classifier info: %+v", info)
+	}
+
+	if rr := serve(mux, http.MethodPost, "/classifiers/team-es/train/spam", "compra pastillas baratas ahora"); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected train status: %d", rr.Code)
+	}
+	rr = serve(mux, http.MethodPost, "/classifiers/team-es/classify", "pastillas baratas")
+	var classification struct{ Category string }
+	if err := json.Unmarshal(rr.Body.Bytes(), &classification); err != nil || classification.Category != "spam" {
+		t.Fatalf("unexpected named classification: %s", rr.Body.String())
+	}
+	if len(api.classifier.Summaries()) != 0 {
+		t.Fatal("expected training a named classifier to leave the default classifier untouched")
+	}
+
+	rr = serve(mux, http.MethodGet, "/classifiers", "")
+	var list ClassifierListResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list response: %v", err)
+	}
+	if len(list.Classifiers) != 2 || list.Classifiers["team-es"].Categories != 1 || list.Classifiers[defaultClassifierName] == nil {
+		t.Fatalf("unexpected classifier list: %s", rr.Body.String())
+	}
+
+	if rr := serve(mux, http.MethodGet, "/classifiers/team-es", ""); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected get status: %d", rr.Code)
+	}
+	if rr := serve(mux, http.MethodDelete, "/classifiers/team-es", ""); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected delete status: %d", rr.Code)
+	}
+	for _, path := range []string{"/classifiers/team-es", "/classifiers/team-es/info"} {
+		if rr := serve(mux, http.MethodGet, path, ""); rr.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for %s after delete, got %d", path, rr.Code)
+		}
+	}
+}
+
+// TestNamedClassifierCharTokenizer verifies a named classifier can use the chars tokenizer.
+func TestNamedClassifierCharTokenizer(t *testing.T) {
+	_, mux := newTestServer()
+
+	rr := serve(mux, http.MethodPost, "/classifiers/zh", `{"language":"chinese","charNGramMax":3}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("unexpected create status: %d %s", rr.Code, rr.Body.String())
+	}
+	var info ClassifierInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+	if info.Tokenizer != "chars" || info.CharNGramMin != 1 || info.CharNGramMax != 3 {
+		t.Fatalf("unexpected classifier info: %+v", info)
+	}
+
+	serve(mux, http.MethodPost, "/classifiers/zh/train/sports", "足球比赛今天开始")
+	serve(mux, http.MethodPost, "/classifiers/zh/train/finance", "股票市场今天下跌")
+	rr = serve(mux, http.MethodPost, "/classifiers/zh/classify", "足球比赛")
+	var classification struct{ Category string }
+	if err := json.Unmarshal(rr.Body.Bytes(), &classification); err != nil || classification.Category != "sports" {
+		t.Fatalf("unexpected classification: %s", rr.Body.String())
+	}
+
+	rr = serve(mux, http.MethodPost, "/classifiers/ja", `{"language":"japanese","charNGramMin":3}`)
+	info = ClassifierInfo{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("unexpected create response: %d %s", rr.Code, rr.Body.String())
+	}
+	if info.CharNGramMin != 3 || info.CharNGramMax != 3 {
+		t.Fatalf("expected the maximum raised to the minimum, got %+v", info)
+	}
+}
+
+// TestNamedClassifierLanguageDetection verifies classify reports the detected language.
+func TestNamedClassifierLanguageDetection(t *testing.T) {
+	_, mux := newTestServer()
+
+	rr := serve(mux, http.MethodPost, "/classifiers/mixed", `{"detectLanguage":true}`)
+	var info ClassifierInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil || !info.DetectLanguage {
+		t.Fatalf("unexpected create response: %d %s", rr.Code, rr.Body.String())
+	}
+
+	serve(mux, http.MethodPost, "/classifiers/mixed/train/billing", "Quiero un reembolso de mi factura")
+	rr = serve(mux, http.MethodPost, "/classifiers/mixed/classify", "el reembolso de la factura")
+	var classification struct{ Category, Language string }
+	if err := json.Unmarshal(rr.Body.Bytes(), &classification); err != nil || classification.Language != "spanish" {
+		t.Fatalf("unexpected classification: %s", rr.Body.String())
+	}
+
+	rr = serve(mux, http.MethodPost, "/classify", "el reembolso de la factura")
+	if strings.Contains(rr.Body.String(), `"language"`) {
+		t.Fatalf("expected no language without detection, got %s", rr.Body.String())
+	}
+}
+
+// TestNamedClassifierStopWords verifies named classifiers accept stop-word and normalizer customizations.
+func TestNamedClassifierStopWords(t *testing.T) {
+	_, mux := newTestServer()
+
+	rr := serve(mux, http.MethodPost, "/classifiers/custom", `{"removeStopWords":true,"extraStopWords":["Acme"],"excludedStopWords":["not"],"normalizers":["money"]}`)
+	var info ClassifierInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+	if len(info.ExtraStopWords) != 1 || info.ExtraStopWords[0] != "acme" || len(info.ExcludedStopWords) != 1 || info.ExcludedStopWords[0] != "not" || len(info.Normalizers) != 1 {
+		t.Fatalf("unexpected classifier info: %s", rr.Body.String())
+	}
+
+	serve(mux, http.MethodPost, "/classifiers/custom/train/spam", "acme is not cheap at $5")
+	rr = serve(mux, http.MethodGet, "/classifiers/custom/info", "")
+	var categories InfoClassifierResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &categories); err != nil {
+		t.Fatalf("decode info response: %v", err)
+	}
+	if got := categories.Categories["spam"].TokenTally; got != 3 {
+		t.Fatalf("expected not, cheap, and __money__ to be trained, got tally %v", got)
+	}
+}
+
+// TestDefaultClassifierAlias verifies /classifiers/default serves the root classifier.
+func TestDefaultClassifierAlias(t *testing.T) {
+	api, mux := newTestServer()
+
+	if rr := serve(mux, http.MethodPost, "/classifiers/default/train/spam", "buy now"); rr.Code != http.StatusOK {
+		t.Fatalf("unexpected train status: %d", rr.Code)
+	}
+	if _, ok := api.classifier.Summaries()["spam"]; !ok {
+		t.Fatal("expected /classifiers/default to train the root classifier")
+	}
+	if rr := serve(mux, http.MethodPost, "/classifiers/default", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 creating default, got %d", rr.Code)
+	}
+	if rr := serve(mux, http.MethodDelete, "/classifiers/default", ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 deleting default, got %d", rr.Code)
+	}
+}
+
+// TestNamedClassifierErrors verifies invalid names, duplicates, bad options, and methods.
+func TestNamedClassifierErrors(t *testing.T) {
+	_, mux := newTestServer()
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{method: http.MethodPost, path: "/classifiers/bad!name", status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/classifiers/", status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/classifiers/missing/train/spam", body: "x", status: http.StatusNotFound},
+		{method: http.MethodDelete, path: "/classifiers/missing", status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/classifiers/opts", body: `{"lang":"french"}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/classifiers/opts", body: `{"ngrams":4}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/classifiers/opts", body: `{"tokenizer":"bigrams"}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/classifiers/opts", body: `{"normalizers":["phones"]}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/classifiers/opts", body: `{"tokenizer":"chars","charNGramMax":6}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/classifiers/opts", body: `{"tokenizer":"chars","charNGramMin":0}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/classifiers/opts", body: `{"tokenizer":"chars","charNGramMin":3,"charNGramMax":2}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/classifiers/opts", body: strings.Repeat("a", maxRequestBodyBytes+1), status: http.StatusRequestEntityTooLarge},
+		{method: http.MethodPut, path: "/classifiers/opts", status: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: "/classifiers", status: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rr := serve(mux, tt.method, tt.path, tt.body)
+		if rr.Code != tt.status {
+			t.Fatalf("%s %s: got %d, want %d", tt.method, tt.path, rr.Code, tt.status)
+		}
+		assertJSONErrorShape(t, rr)
+	}
+
+	if rr := serve(mux, http.MethodPost, "/classifiers/dup", ""); rr.Code != http.StatusCreated {
+		t.Fatalf("unexpected create status: %d", rr.Code)
+	}
+	if rr := serve(mux, http.MethodPost, "/classifiers/dup", ""); rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for duplicate, got %d", rr.Code)
+	}
+	if rr := serve(mux, http.MethodGet, "/classifiers/dup/healthz", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected probes to be served only at the root, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/hickeroar/gobayes/v4/bayes/corpus"
+)
+
+const maxImportBodyBytes = 256 << 20 // 256 MiB
+
+// csvOptionsFor returns the CSV options for a text/csv or
+// text/tab-separated-values media type, reading the column names from the
+// labelColumn and textColumn query parameters. ok is false for other types.
+func csvOptionsFor(mediaType string, req *http.Request) (opts corpus.CSVOptions, ok bool) {
+	switch mediaType {
+	case "text/csv":
+	case "text/tab-separated-values":
+		opts.Comma = '\t'
+	default:
+		return opts, false
+	}
+	query := req.URL.Query()
+	opts.LabelColumn = query.Get("labelColumn")
+	opts.TextColumn = query.Get("textColumn")
+	return opts, true
+}
+
+// ImportHandler streams a labeled corpus into the classifier and reports
+// per-category counts and rejected samples. CSV bodies (text/csv, or
+// text/tab-separated-values) need a header row; any other body is read as
+// NDJSON of {"category", "text"} objects. Samples trained before a read
+// error remain trained.
+func (c *ClassifierAPI) ImportHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	extendDeadlines(w)
+	req.Body = http.MaxBytesReader(w, req.Body, maxImportBodyBytes)
+	defer req.Body.Close()
+
+	var report corpus.Report
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if opts, ok := csvOptionsFor(mediaType, req); ok {
+		report, err = corpus.ImportCSV(c.classifier, req.Body, opts)
+	} else {
+		report, err = corpus.ImportNDJSON(c.classifier, req.Body)
+	}
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+var readDir = os.ReadDir
+
+// savedTenant is a named classifier as of its last load or save.
+type savedTenant struct {
+	classifier *bayes.Classifier
+	revision   uint64
+}
+
+// tenantModelFile returns the model file of the named classifier name, which
+// is kept next to the default model file.
+func (c *ClassifierAPI) tenantModelFile(name string) string {
+	return c.modelFile + "." + name + ".json"
+}
+
+// loadModel restores classifier state from the configured model file and the
+// named classifiers saved next to it. A missing file is not an error; the
+// server starts with an empty model.
+func (c *ClassifierAPI) loadModel() error {
+	if c.modelFile == "" {
+		return nil
+	}
+
+	if err := c.classifier.LoadFromFile(c.modelFile); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("load model: %w", err)
+		}
+		log.Printf("Model file %s not found; starting with an empty model.", c.modelFile)
+	} else {
+		log.Printf("Loaded model from %s.", c.modelFile)
+	}
+	saved, err := c.loadTenants()
+	if err != nil {
+		return err
+	}
+
+	c.saveMu.Lock()
+	c.savedRevision = c.classifier.Revision()
+	c.savedTenants = saved
+	c.saveMu.Unlock()
+	return nil
+}
+
+// loadTenants restores every named classifier whose model file is next to
+// the default model file.
+func (c *ClassifierAPI) loadTenants() (map[string]savedTenant, error) {
+	dir, base := filepath.Split(c.modelFile)
+	entries, err := readDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("load named classifiers: %w", err)
+	}
+
+	saved := make(map[string]savedTenant)
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), base+".")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		name, ok = strings.CutSuffix(name, ".json")
+		if !ok || !categoryPathPattern.MatchString(name) || name == defaultClassifierName {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		classifier := bayes.NewClassifier()
+		if err := classifier.LoadFromFile(path); err != nil {
+			return nil, fmt.Errorf("load classifier %q: %w", name, err)
+		}
+		c.tenantsMu.Lock()
+		c.addTenantLocked(name, classifier)
+		c.tenantsMu.Unlock()
+		saved[name] = savedTenant{classifier: classifier, revision: classifier.Revision()}
+		log.Printf("Loaded classifier %q from %s.", name, path)
+	}
+	return saved, nil
+}
+
+// saveModel writes the classifier to the configured model file when it has
+// changed since the last load or save, and does the same for each named
+// classifier.
+func (c *ClassifierAPI) saveModel() error {
+	if c.modelFile == "" {
+		return nil
+	}
+
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	// Read the revision before saving: a concurrent mutation makes the
+	// snapshot newer than recorded, which only costs one redundant save.
+	if revision := c.classifier.Revision(); revision != c.savedRevision {
+		if err := c.classifier.SaveToFile(c.modelFile); err != nil {
+			return fmt.Errorf("save model: %w", err)
+		}
+		c.savedRevision = revision
+	}
+	return c.saveTenants()
+}
+
+// saveTenants writes each named classifier that was created or changed since
+// the last load or save to its model file, and removes the model files of
+// deleted classifiers. Callers must hold saveMu.
+func (c *ClassifierAPI) saveTenants() error {
+	c.tenantsMu.RLock()
+	current := make(map[string]*bayes.Classifier, len(c.tenants))
+	for name, t := range c.tenants {
+		current[name] = t.api.classifier
+	}
+	c.tenantsMu.RUnlock()
+
+	if c.savedTenants == nil {
+		c.savedTenants = make(map[string]savedTenant)
+	}
+	var errs []error
+	for name, classifier := range current {
+		revision := classifier.Revision()
+		// A classifier deleted and recreated under the same name is a new
+		// classifier, whatever its revision.
+		if saved, ok := c.savedTenants[name]; ok && saved.classifier == classifier && saved.revision == revision {
+			continue
+		}
+		if err := classifier.SaveToFile(c.tenantModelFile(name)); err != nil {
+			errs = append(errs, fmt.Errorf("save classifier %q: %w", name, err))
+			continue
+		}
+		c.savedTenants[name] = savedTenant{classifier: classifier, revision: revision}
+	}
+	for name := range c.savedTenants {
+		if _, ok := current[name]; ok {
+			continue
+		}
+		if err := os.Remove(c.tenantModelFile(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("remove classifier %q: %w", name, err))
+			continue
+		}
+		delete(c.savedTenants, name)
+	}
+	return errors.Join(errs...)
+}
+
+// autosave saves the model every interval until stop is closed.
+func (c *ClassifierAPI) autosave(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.saveModel(); err != nil {
+				log.Printf("autosave failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// maxPruneReportTokens caps the removed tokens listed in a /prune response.
+const maxPruneReportTokens = 1000
+
+// pruneOptionsFromQuery parses the minCount, topK, method, and dryRun query
+// parameters accepted by /prune.
+func pruneOptionsFromQuery(query url.Values) (bayes.PruneOptions, error) {
+	opts := bayes.PruneOptions{Method: bayes.PruneMethod(query.Get("method"))}
+	if raw := query.Get("minCount"); raw != "" {
+		minCount, err := strconv.Atoi(raw)
+		if err != nil || minCount < 0 {
+			return opts, fmt.Errorf("invalid minCount: %q", raw)
+		}
+		opts.MinCount = minCount
+	}
+	if raw := query.Get("topK"); raw != "" {
+		topK, err := strconv.Atoi(raw)
+		if err != nil || topK < 0 {
+			return opts, fmt.Errorf("invalid topK: %q", raw)
+		}
+		opts.TopK = topK
+	}
+	if raw := query.Get("dryRun"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid dryRun: %q", raw)
+		}
+		opts.DryRun = dryRun
+	}
+	return opts, nil
+}
+
+// PruneHandler removes rare or uninformative tokens from the model, or with
+// dryRun reports what would be removed. At most maxPruneReportTokens removed
+// tokens are listed; removedTokenCount reports the full number.
+func (c *ClassifierAPI) PruneHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodPost) {
+		return
+	}
+
+	opts, err := pruneOptionsFromQuery(req.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := c.classifier.Prune(opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	report.RemovedTokens = report.RemovedTokens[:min(len(report.RemovedTokens), maxPruneReportTokens)]
+	writeJSON(w, http.StatusOK, report)
+}
@@ -1,10 +1,13 @@
 package main
 
+import "github.com/hickeroar/gobayes/v4/bayes"
+
 // CategoryInfo describes summary data for a trained category.
 type CategoryInfo struct {
-	TokenTally   int     `json:"tokenTally"`   // Total tokens in this category
-	ProbNotInCat float64 `json:"probNotInCat"` // Probability that an arbitrary token is not in this category
-	ProbInCat    float64 `json:"probInCat"`    // Probability that an arbitrary token is in this category
+	TokenTally    float64 `json:"tokenTally"`    // Total tokens in this category, fractional after decay
+	DocumentCount float64 `json:"documentCount"` // Total documents (samples) trained into this category, fractional after decay
+	ProbNotInCat  float64 `json:"probNotInCat"`  // Prior that an arbitrary token (or sample, with the documents prior source) is not in this category
+	ProbInCat     float64 `json:"probInCat"`     // Prior that an arbitrary token (or sample, with the documents prior source) is in this category
 }
 
 // getCategoryList returns a summary view of all categories.
@@ -13,9 +16,10 @@ func getCategoryList(c *ClassifierAPI) map[string]*CategoryInfo {
 	list := make(map[string]*CategoryInfo)
 	for name, cat := range categories {
 		catInfo := &CategoryInfo{
-			TokenTally:   cat.TokenTally,
-			ProbNotInCat: cat.ProbNotInCat,
-			ProbInCat:    cat.ProbInCat,
+			TokenTally:    cat.TokenTally,
+			DocumentCount: cat.DocumentCount,
+			ProbNotInCat:  cat.ProbNotInCat,
+			ProbInCat:     cat.ProbInCat,
 		}
 		list[name] = catInfo
 	}
@@ -47,3 +51,89 @@ func NewInfoClassifierResponse(c *ClassifierAPI) *InfoClassifierResponse {
 		Categories: getCategoryList(c),
 	}
 }
+
+// BatchItemResult reports the outcome of one batch training item.
+type BatchItemResult struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchTrainingResponse is returned by the batch train and untrain endpoints.
+type BatchTrainingResponse struct {
+	Success    bool                     `json:"success"` // true when every item succeeded
+	Results    []BatchItemResult        `json:"results"` // one result per item, in request order
+	Categories map[string]*CategoryInfo `json:"categories"`
+}
+
+// NewBatchTrainingResponse builds a BatchTrainingResponse from per-item errors.
+func NewBatchTrainingResponse(c *ClassifierAPI, errs []error) *BatchTrainingResponse {
+	resp := &BatchTrainingResponse{
+		Success:    true,
+		Results:    make([]BatchItemResult, len(errs)),
+		Categories: getCategoryList(c),
+	}
+	for i, err := range errs {
+		if err != nil {
+			resp.Success = false
+			resp.Results[i] = BatchItemResult{Error: err.Error()}
+			continue
+		}
+		resp.Results[i] = BatchItemResult{Success: true}
+	}
+	return resp
+}
+
+// BatchClassifyResponse is returned by the batch classify endpoint.
+type BatchClassifyResponse struct {
+	Results []bayes.Classification `json:"results"` // one classification per item, in request order
+}
+
+// TokenLookupResponse is returned by the token lookup endpoint.
+type TokenLookupResponse struct {
+	Query  string              `json:"query"`  // token as requested, before tokenizing
+	Tokens []bayes.TokenLookup `json:"tokens"` // one entry per distinct token the tokenizer produced
+}
+
+// DiscriminativeTokensResponse is returned by the discriminative tokens endpoint.
+type DiscriminativeTokensResponse struct {
+	Category string             `json:"category"`
+	Tokens   []bayes.TokenScore `json:"tokens"` // highest score first
+}
+
+// ClassifierInfo describes a classifier served under /classifiers/{name}.
+type ClassifierInfo struct {
+	Language          string   `json:"language"`                    // Stemming and stop-word language
+	RemoveStopWords   bool     `json:"removeStopWords"`             // Whether stop words are filtered
+	NGrams            int      `json:"ngrams"`                      // Longest word n-gram emitted by the tokenizer
+	Tokenizer         string   `json:"tokenizer"`                   // Tokenizer name: words or chars
+	CharNGramMin      int      `json:"charNGramMin,omitempty"`      // Shortest character n-gram (chars only)
+	CharNGramMax      int      `json:"charNGramMax,omitempty"`      // Longest character n-gram (chars only)
+	DetectLanguage    bool     `json:"detectLanguage"`              // Whether each sample's language is detected
+	ExtraStopWords    []string `json:"extraStopWords,omitempty"`    // Words filtered in addition to the built-in stop list
+	ExcludedStopWords []string `json:"excludedStopWords,omitempty"` // Built-in stop words kept as tokens
+	Normalizers       []string `json:"normalizers,omitempty"`       // Normalization stages applied before tokenizing
+	Categories        int      `json:"categories"`                  // Number of trained categories
+}
+
+// newClassifierInfo builds a ClassifierInfo for an API's classifier.
+func newClassifierInfo(c *ClassifierAPI) *ClassifierInfo {
+	opts := c.classifier.TokenizerOptions()
+	return &ClassifierInfo{
+		Language:          opts.Language,
+		RemoveStopWords:   opts.RemoveStopWords,
+		NGrams:            opts.NGrams,
+		Tokenizer:         opts.Tokenizer,
+		CharNGramMin:      opts.CharNGramMin,
+		CharNGramMax:      opts.CharNGramMax,
+		DetectLanguage:    opts.DetectLanguage,
+		ExtraStopWords:    opts.ExtraStopWords,
+		ExcludedStopWords: opts.ExcludedStopWords,
+		Normalizers:       opts.Normalizers,
+		Categories:        len(c.classifier.Summaries()),
+	}
+}
+
+// ClassifierListResponse is returned by the classifier list endpoint.
+type ClassifierListResponse struct {
+	Classifiers map[string]*ClassifierInfo `json:"classifiers"`
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hickeroar/gobayes/v4/bayes"
+)
+
+// defaultClassifierName addresses the root classifier under /classifiers/.
+const defaultClassifierName = "default"
+
+// tenant is a named classifier and the routes that serve it.
+type tenant struct {
+	api *ClassifierAPI
+	mux *http.ServeMux
+}
+
+// createClassifierRequest is the optional JSON body of POST /classifiers/{name}.
+type createClassifierRequest struct {
+	Language        string `json:"language"`
+	RemoveStopWords bool   `json:"removeStopWords"`
+	NGrams          int    `json:"ngrams"`
+	Tokenizer       string `json:"tokenizer"`
+	// CharNGramMin and CharNGramMax default to 1 and 2 when omitted, with
+	// the maximum raised to an explicit minimum.
+	CharNGramMin   *int `json:"charNGramMin"`
+	CharNGramMax   *int `json:"charNGramMax"`
+	DetectLanguage bool `json:"detectLanguage"`
+	// ExtraStopWords and ExcludedStopWords customize the stop list; see
+	// bayes.TokenizerOptions.
+	ExtraStopWords    []string `json:"extraStopWords"`
+	ExcludedStopWords []string `json:"excludedStopWords"`
+	Normalizers       []string `json:"normalizers"`
+}
+
+// lookupTenant returns the classifier routes for name, including the default classifier.
+func (c *ClassifierAPI) lookupTenant(name string) (*ClassifierAPI, *http.ServeMux, bool) {
+	if name == defaultClassifierName {
+		return c, c.defaultMux, true
+	}
+
+	c.tenantsMu.RLock()
+	defer c.tenantsMu.RUnlock()
+	t, ok := c.tenants[name]
+	if !ok {
+		return nil, nil, false
+	}
+	return t.api, t.mux, true
+}
+
+// errClassifierExists reports that a named classifier already exists.
+var errClassifierExists = errors.New("classifier already exists")
+
+// createTenant adds a named classifier. New classifiers use the default
+// classifier's scoring options.
+func (c *ClassifierAPI) createTenant(name string, req createClassifierRequest) (*ClassifierAPI, error) {
+	classifier, err := newTenantClassifier(req, c.classifier.ScoringOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	c.tenantsMu.Lock()
+	defer c.tenantsMu.Unlock()
+	if _, exists := c.tenants[name]; exists || name == defaultClassifierName {
+		return nil, errClassifierExists
+	}
+	return c.addTenantLocked(name, classifier), nil
+}
+
+// addTenantLocked serves classifier as the named classifier name. Callers
+// must hold tenantsMu.
+func (c *ClassifierAPI) addTenantLocked(name string, classifier *bayes.Classifier) *ClassifierAPI {
+	if c.tenants == nil {
+		c.tenants = make(map[string]*tenant)
+	}
+	api := &ClassifierAPI{classifier: classifier}
+	mux := http.NewServeMux()
+	api.registerClassifierRoutes(mux)
+	c.tenants[name] = &tenant{api: api, mux: mux}
+	return api
+}
+
+// newTenantClassifier validates req and returns the classifier it describes
+// with the given scoring options.
+func newTenantClassifier(req createClassifierRequest, scoring bayes.ScoringOptions) (*bayes.Classifier, error) {
+	if req.NGrams != 0 && (req.NGrams < 1 || req.NGrams > maxNGrams) {
+		return nil, fmt.Errorf("ngrams must be between 1 and %d", maxNGrams)
+	}
+	if _, err := bayes.ParseTokenizer(req.Tokenizer); err != nil {
+		return nil, err
+	}
+	if _, err := bayes.ParseNormalizers(strings.Join(req.Normalizers, ",")); err != nil {
+		return nil, err
+	}
+	charMin, charMax := 1, 2
+	if req.CharNGramMin != nil {
+		charMin = *req.CharNGramMin
+	}
+	if req.CharNGramMax != nil {
+		charMax = *req.CharNGramMax
+	} else {
+		charMax = max(charMax, charMin)
+	}
+	if err := validateCharNGrams(charMin, charMax); err != nil {
+		return nil, err
+	}
+
+	opts := []bayes.TokenizerOption{
+		bayes.WithNGrams(req.NGrams),
+		bayes.WithTokenizer(req.Tokenizer),
+		bayes.WithCharNGrams(charMin, charMax),
+		bayes.WithStopWords(req.ExtraStopWords, req.ExcludedStopWords),
+		bayes.WithNormalizers(req.Normalizers...),
+	}
+	if req.DetectLanguage {
+		opts = append(opts, bayes.WithLanguageDetection())
+	}
+	classifier := bayes.NewClassifierWithOptions(req.Language, req.RemoveStopWords, opts...)
+	return classifier, classifier.SetScoringOptions(scoring)
+}
+
+// deleteTenant removes a named classifier, reporting whether it existed.
+func (c *ClassifierAPI) deleteTenant(name string) bool {
+	c.tenantsMu.Lock()
+	defer c.tenantsMu.Unlock()
+	if _, ok := c.tenants[name]; !ok {
+		return false
+	}
+	delete(c.tenants, name)
+	return true
+}
+
+// ClassifiersHandler lists the default and named classifiers.
+func (c *ClassifierAPI) ClassifiersHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodGet) {
+		return
+	}
+
+	resp := &ClassifierListResponse{
+		Classifiers: map[string]*ClassifierInfo{defaultClassifierName: newClassifierInfo(c)},
+	}
+	c.tenantsMu.RLock()
+	for name, t := range c.tenants {
+		resp.Classifiers[name] = newClassifierInfo(t.api)
+	}
+	c.tenantsMu.RUnlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// NamedClassifierHandler creates, describes, and deletes named classifiers at
+// /classifiers/{name}, and serves the classifier routes (for example
+// /classifiers/{name}/train/{category}) of an existing one.
+func (c *ClassifierAPI) NamedClassifierHandler(w http.ResponseWriter, req *http.Request) {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/classifiers/"), "/")
+	if !categoryPathPattern.MatchString(name) {
+		writeError(w, http.StatusNotFound, "invalid classifier route")
+		return
+	}
+
+	if rest != "" {
+		_, mux, ok := c.lookupTenant(name)
+		if !ok {
+			writeError(w, http.StatusNotFound, "classifier not found")
+			return
+		}
+		routed := req.Clone(req.Context())
+		routed.URL.Path = "/" + rest
+		routed.URL.RawPath = ""
+		mux.ServeHTTP(w, routed)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		api, _, ok := c.lookupTenant(name)
+		if !ok {
+			writeError(w, http.StatusNotFound, "classifier not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, newClassifierInfo(api))
+	case http.MethodPost:
+		c.createClassifier(w, req, name)
+	case http.MethodDelete:
+		if name == defaultClassifierName {
+			writeError(w, http.StatusBadRequest, "the default classifier cannot be deleted")
+			return
+		}
+		if !c.deleteTenant(name) {
+			writeError(w, http.StatusNotFound, "classifier not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// createClassifier handles POST /classifiers/{name}.
+func (c *ClassifierAPI) createClassifier(w http.ResponseWriter, req *http.Request, name string) {
+	body, ok := readBody(w, req)
+	if !ok {
+		return
+	}
+
+	var create createClassifierRequest
+	if strings.TrimSpace(body) != "" {
+		if err := decodeStrict(body, &create); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid classifier options: %v", err))
+			return
+		}
+	}
+	api, err := c.createTenant(name, create)
+	if errors.Is(err, errClassifierExists) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid classifier options: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, newClassifierInfo(api))
+}
+
+// decodeStrict decodes a single JSON value from body, rejecting unknown
+// fields and trailing data.
+func decodeStrict(body string, value any) error {
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(value); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after value")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TokenHandler reports how often /tokens/{token} was trained into every
+// category. The token is run through the classifier's tokenizer first, so
+// "Buying" looks up the stem "buy", and text that yields several tokens, such
+// as a phrase with n-grams enabled, reports each of them.
+func (c *ClassifierAPI) TokenHandler(w http.ResponseWriter, req *http.Request) {
+	if !requireMethod(w, req, http.MethodGet) {
+		return
+	}
+
+	token := strings.TrimPrefix(req.URL.Path, "/tokens/")
+	if strings.TrimSpace(token) == "" {
+		writeError(w, http.StatusNotFound, "invalid token route")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &TokenLookupResponse{Query: token, Tokens: c.classifier.LookupToken(token)})
+}